@@ -0,0 +1,135 @@
+// Command trace-sink stands in for an OpenTelemetry collector during
+// development. It accepts OTLP/HTTP JSON on /v1/traces and prints each
+// batch as an indented span tree.
+//
+//	go run ./cmd/trace-sink -addr :4318
+//	OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318 go run .
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type request struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []span `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type span struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Start        string `json:"startTimeUnixNano"`
+	End          string `json:"endTimeUnixNano"`
+	Attributes   []struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s span) times() (time.Time, time.Duration) {
+	start, _ := strconv.ParseInt(s.Start, 10, 64)
+	end, _ := strconv.ParseInt(s.End, 10, 64)
+	return time.Unix(0, start), time.Duration(end - start)
+}
+
+func main() {
+	addr := flag.String("addr", ":4318", "listen address")
+	raw := flag.Bool("raw", false, "print request bodies instead of span trees")
+	flag.Parse()
+
+	http.HandleFunc("POST /v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if *raw {
+			fmt.Printf("%s\n", body)
+			w.Write([]byte("{}"))
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var spans []span
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+		printTrees(os.Stdout, spans)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	})
+
+	log.Printf("trace-sink listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// printTrees prints one tree per trace. Spans whose parent is in another
+// batch are printed as roots.
+func printTrees(w io.Writer, spans []span) {
+	byID := make(map[string]bool)
+	children := make(map[string][]span)
+	for _, s := range spans {
+		byID[s.SpanID] = true
+	}
+	var roots []span
+	for _, s := range spans {
+		if s.ParentSpanID != "" && byID[s.ParentSpanID] {
+			children[s.ParentSpanID] = append(children[s.ParentSpanID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+	byStart := func(a, b span) int {
+		return strings.Compare(fmt.Sprintf("%020s", a.Start), fmt.Sprintf("%020s", b.Start))
+	}
+	slices.SortFunc(roots, byStart)
+
+	var print func(s span, depth int, traceStart time.Time)
+	print = func(s span, depth int, traceStart time.Time) {
+		start, duration := s.times()
+		line := fmt.Sprintf("%s%s  +%s  %s", strings.Repeat("  ", depth), s.Name, start.Sub(traceStart), duration)
+		for _, attr := range s.Attributes {
+			for _, v := range attr.Value {
+				line += fmt.Sprintf("  %s=%v", attr.Key, v)
+			}
+		}
+		if s.Status != nil && s.Status.Message != "" {
+			line += "  error=" + strconv.Quote(s.Status.Message)
+		}
+		fmt.Fprintln(w, line)
+		kids := children[s.SpanID]
+		slices.SortFunc(kids, byStart)
+		for _, child := range kids {
+			print(child, depth+1, traceStart)
+		}
+	}
+	for _, root := range roots {
+		start, _ := root.times()
+		fmt.Fprintf(w, "trace %s\n", root.TraceID)
+		print(root, 1, start)
+	}
+}
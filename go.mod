@@ -2,11 +2,17 @@ module hypermedia-sync
 
 go 1.24.0
 
-require github.com/labstack/echo/v4 v4.13.4
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/a-h/templ v0.3.924
+	github.com/labstack/echo/v4 v4.13.4
+	golang.org/x/image v0.27.0
+	golang.org/x/time v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/Oudwins/tailwind-merge-go v0.2.0 // indirect
-	github.com/a-h/templ v0.3.924 // indirect
 	github.com/labstack/gommon v0.4.2 // indirect
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
@@ -16,5 +22,4 @@ require (
 	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
-	golang.org/x/time v0.11.0 // indirect
 )
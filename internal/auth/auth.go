@@ -0,0 +1,209 @@
+// Package auth signs visitors in through an identity provider and records
+// who they are on their session. Signing in is optional; experiments decide
+// whether they need it.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/session"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	flowCookie = "auth_flow"
+	// flowTimeout is how long a visitor has to finish signing in at the
+	// provider.
+	flowTimeout = 10 * time.Minute
+)
+
+// User is who the provider says signed in.
+type User struct {
+	Subject string
+	Email   string
+	// EmailVerified is whether the provider vouches that Email belongs to
+	// the user. AllowedDomains only admits verified emails.
+	EmailVerified bool
+	Name          string
+}
+
+// Flow is the state of one sign-in, kept in a signed cookie between the
+// login and callback requests.
+type Flow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+	Expires  int64  `json:"exp"`
+}
+
+// Provider sends visitors off to sign in and checks what they come back
+// with.
+type Provider interface {
+	// Begin starts a sign-in, typically by redirecting to the provider.
+	// The provider must send flow.State back to /auth/callback.
+	Begin(c echo.Context, flow Flow) error
+	// Complete checks the callback request and returns the signed-in user.
+	Complete(c echo.Context, flow Flow) (User, error)
+}
+
+type Config struct {
+	// Secret signs the flow cookie; it is the session secret.
+	Secret []byte
+	// SessionLength is how long a sign-in lasts.
+	SessionLength time.Duration
+	// AllowedDomains restricts sign-in to these email domains. Empty
+	// allows any user the provider accepts.
+	AllowedDomains []string
+}
+
+// LoginURL is where to send a visitor to sign in and come back to returnTo.
+func LoginURL(returnTo string) string {
+	return "/auth/login?" + url.Values{"return_to": {returnTo}}.Encode()
+}
+
+// RegisterRoutes adds the login, callback, and logout routes.
+func RegisterRoutes(e *echo.Echo, provider Provider, cfg Config) {
+	e.GET("/auth/login", loginHandler(provider, cfg))
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/auth/callback", callbackHandler(provider, cfg))
+	e.POST("/auth/logout", logoutHandler(cfg))
+}
+
+func loginHandler(provider Provider, cfg Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		returnTo := c.QueryParam("return_to")
+		if returnTo == "" {
+			returnTo = refererPath(c)
+		}
+		flow := Flow{
+			State:    randomString(),
+			Nonce:    randomString(),
+			Verifier: randomString() + randomString(),
+			ReturnTo: localPath(returnTo),
+			Expires:  time.Now().Add(flowTimeout).Unix(),
+		}
+		setFlowCookie(c, session.Seal(cfg.Secret, flow), int(flowTimeout.Seconds()))
+		return provider.Begin(c, flow)
+	}
+}
+
+func callbackHandler(provider Provider, cfg Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		logger := logging.FromContext(c.Request().Context())
+		var flow Flow
+		cookie, err := c.Cookie(flowCookie)
+		if err != nil || !session.Open(cfg.Secret, cookie.Value, &flow) || time.Now().Unix() > flow.Expires {
+			return c.String(400, "Sign-in expired. Go back and try again.")
+		}
+		setFlowCookie(c, "", -1)
+		if subtle.ConstantTimeCompare([]byte(c.FormValue("state")), []byte(flow.State)) != 1 {
+			return c.String(400, "Sign-in expired. Go back and try again.")
+		}
+		if reason := c.FormValue("error"); reason != "" {
+			logger.Info("sign-in refused by provider", "error", reason, "description", c.FormValue("error_description"))
+			return c.String(401, "Sign-in was cancelled or refused.")
+		}
+
+		user, err := provider.Complete(c, flow)
+		if err != nil {
+			logger.Warn("sign-in failed", "err", err)
+			return c.String(401, "Sign-in failed.")
+		}
+		if len(cfg.AllowedDomains) > 0 && !user.EmailVerified {
+			logger.Info("sign-in without a verified email", "sub", user.Subject, "email", user.Email)
+			return c.String(403, "Your account isn't allowed to sign in here.")
+		}
+		if !allowedEmail(user.Email, cfg.AllowedDomains) {
+			logger.Info("sign-in from disallowed domain", "sub", user.Subject, "email", user.Email)
+			return c.String(403, "Your account isn't allowed to sign in here.")
+		}
+
+		s := session.Get(c)
+		s.Subject, s.Email = user.Subject, user.Email
+		s.SignedInUntil = time.Now().Add(cfg.SessionLength).Unix()
+		if name := strings.Join(strings.Fields(user.Name), " "); name != "" && utf8.RuneCountInString(name) <= session.MaxNameLength {
+			s.Name = name
+		}
+		session.Save(c, cfg.Secret, s)
+		logger.Info("signed in", "sub", user.Subject, "email", user.Email)
+		return c.Redirect(http.StatusSeeOther, flow.ReturnTo)
+	}
+}
+
+// setFlowCookie sets the flow cookie, or deletes it when maxAge is negative.
+func setFlowCookie(c echo.Context, value string, maxAge int) {
+	c.SetCookie(&http.Cookie{
+		Name:     flowCookie,
+		Value:    value,
+		Path:     "/auth",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   c.Scheme() == "https",
+		MaxAge:   maxAge,
+	})
+}
+
+// logoutHandler forgets the signed-in user but keeps the session's name
+// and color. HTMX requests reload the page so it re-renders signed out.
+func logoutHandler(cfg Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s := session.Get(c)
+		if s.Subject != "" {
+			logging.FromContext(c.Request().Context()).Info("signed out", "sub", s.Subject)
+		}
+		s.Subject, s.Email, s.SignedInUntil = "", "", 0
+		session.Save(c, cfg.Secret, s)
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			c.Response().Header().Set("HX-Refresh", "true")
+			return c.NoContent(http.StatusNoContent)
+		}
+		return c.Redirect(http.StatusSeeOther, refererPath(c))
+	}
+}
+
+func allowedEmail(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	_, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	return ok && slices.ContainsFunc(domains, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimPrefix(allowed, "@"), domain)
+	})
+}
+
+// localPath keeps returnTo only if it is a path on this site, so the login
+// route can't be used to redirect elsewhere.
+func localPath(returnTo string) string {
+	u, err := url.Parse(returnTo)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(returnTo, "//") || strings.Contains(returnTo, "\\") || strings.HasPrefix(u.Path, "/auth/") {
+		return "/"
+	}
+	return u.RequestURI()
+}
+
+// refererPath returns the path of the referring page on this site, or "/"
+// if there isn't one.
+func refererPath(c echo.Context) string {
+	ref, err := url.Parse(c.Request().Referer())
+	if err != nil || ref.Host != c.Request().Host {
+		return "/"
+	}
+	return localPath(ref.RequestURI())
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// clockSkew is how far the provider's clock may be ahead of ours.
+const clockSkew = time.Minute
+
+type OIDCConfig struct {
+	// Issuer is the provider's issuer URL; its discovery document is at
+	// Issuer + "/.well-known/openid-configuration".
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is this server's /auth/callback as registered with the
+	// provider.
+	RedirectURL string
+}
+
+// OIDC signs visitors in with the OpenID Connect authorization code flow,
+// using PKCE, and verifies RS256 ID tokens against the provider's keys.
+type OIDC struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	// mu guards the discovery document and keys, which are fetched on
+	// first use so the server starts even while the provider is down.
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewOIDC(cfg OIDCConfig) *OIDC {
+	return &OIDC{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OIDC) Begin(c echo.Context, flow Flow) error {
+	d, err := p.discover(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("discovering %s: %w", p.cfg.Issuer, err)
+	}
+	challenge := sha256.Sum256([]byte(flow.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.Redirect(http.StatusFound, d.AuthorizationEndpoint+sep+query.Encode())
+}
+
+func (p *OIDC) Complete(c echo.Context, flow Flow) (User, error) {
+	ctx := c.Request().Context()
+	d, err := p.discover(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	code := c.FormValue("code")
+	if code == "" {
+		return User{}, errors.New("callback has no code")
+	}
+	idToken, err := p.exchange(ctx, d, code, flow.Verifier)
+	if err != nil {
+		return User{}, err
+	}
+	cl, err := p.verify(ctx, d, idToken)
+	if err != nil {
+		return User{}, err
+	}
+	if cl.Nonce != flow.Nonce {
+		return User{}, errors.New("id token nonce doesn't match")
+	}
+
+	// A missing email_verified claim keeps the email for display but
+	// doesn't count as verified.
+	user := User{Subject: cl.Subject, Name: cl.Name, EmailVerified: cl.EmailVerified != nil && *cl.EmailVerified}
+	if cl.EmailVerified == nil || *cl.EmailVerified {
+		user.Email = cl.Email
+	}
+	return user, nil
+}
+
+func (p *OIDC) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var d discovery
+	if err := p.getJSON(ctx, strings.TrimRight(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimRight(d.Issuer, "/") != strings.TrimRight(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("discovery document is for issuer %q", d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+	p.discovery = &d
+	return &d, nil
+}
+
+// exchange trades the authorization code for an ID token.
+func (p *OIDC) exchange(ctx context.Context, d *discovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", describe(resp)
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+type claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expires       int64    `json:"exp"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified *bool    `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// audience is the aud claim, which may be a string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*a = audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// verify checks the ID token's signature, issuer, audience, and expiry.
+func (p *OIDC) verify(ctx context.Context, d *discovery, token string) (claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims{}, errors.New("id token is not a JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims{}, fmt.Errorf("decoding id token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return claims{}, fmt.Errorf("id token uses unsupported algorithm %q", header.Alg)
+	}
+	key, err := p.key(ctx, d, header.Kid)
+	if err != nil {
+		return claims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims{}, fmt.Errorf("decoding id token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return claims{}, errors.New("id token signature is invalid")
+	}
+
+	var cl claims
+	if err := decodeSegment(parts[1], &cl); err != nil {
+		return claims{}, fmt.Errorf("decoding id token claims: %w", err)
+	}
+	switch {
+	case cl.Issuer != d.Issuer:
+		return claims{}, fmt.Errorf("id token issued by %q", cl.Issuer)
+	case !slices.Contains(cl.Audience, p.cfg.ClientID):
+		return claims{}, errors.New("id token is for another client")
+	case time.Now().Add(-clockSkew).Unix() >= cl.Expires:
+		return claims{}, errors.New("id token has expired")
+	case cl.Subject == "":
+		return claims{}, errors.New("id token has no subject")
+	}
+	return cl, nil
+}
+
+// key returns the signing key with the given ID, refetching the key set
+// when it's unknown in case the provider rotated its keys.
+func (p *OIDC) key(ctx context.Context, d *discovery, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no signing key with id %q", kid)
+}
+
+func (p *OIDC) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return describe(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func describe(resp *http.Response) error {
+	return fmt.Errorf("%s %s returned %s", resp.Request.Method, resp.Request.URL.Redacted(), resp.Status)
+}
@@ -0,0 +1,460 @@
+// Package config loads server, hub, and experiment settings. Values come
+// from defaults, an optional YAML or TOML file, environment variables, and
+// command-line flags, each overriding the one before.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server      Server      `yaml:"server" toml:"server"`
+	Log         Log         `yaml:"log" toml:"log"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing"`
+	Auth        Auth        `yaml:"auth" toml:"auth"`
+	RateLimit   RateLimit   `yaml:"rate_limit" toml:"rate_limit"`
+	Hub         Hub         `yaml:"hub" toml:"hub"`
+	Connections Connections `yaml:"connections" toml:"connections"`
+	Experiments Experiments `yaml:"experiments" toml:"experiments"`
+	Checkboxes  Checkboxes  `yaml:"checkboxes" toml:"checkboxes"`
+	Canvas      Canvas      `yaml:"canvas" toml:"canvas"`
+	Chat        Chat        `yaml:"chat" toml:"chat"`
+	Polls       Polls       `yaml:"polls" toml:"polls"`
+	Kanban      Kanban      `yaml:"kanban" toml:"kanban"`
+	Editor      Editor      `yaml:"editor" toml:"editor"`
+	Games       Games       `yaml:"games" toml:"games"`
+	Life        Life        `yaml:"life" toml:"life"`
+	Dashboard   Dashboard   `yaml:"dashboard" toml:"dashboard"`
+	Quiz        Quiz        `yaml:"quiz" toml:"quiz"`
+}
+
+type Server struct {
+	Port            int           `yaml:"port" toml:"port"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// AdminToken enables the admin routes when set.
+	AdminToken string `yaml:"admin_token" toml:"admin_token"`
+	// SessionSecret signs session cookies. When empty a random secret is
+	// used, and sessions don't survive a restart.
+	SessionSecret string `yaml:"session_secret" toml:"session_secret"`
+	// TrustedProxies lists the CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header is believed. When empty the client IP is the
+	// connection's remote address.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type Log struct {
+	// Level is debug, info, warn, or error.
+	Level string `yaml:"level" toml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format" toml:"format"`
+}
+
+// SlogLevel returns Level as a slog.Level. It assumes Validate passed.
+func (l Log) SlogLevel() slog.Level {
+	var level slog.Level
+	level.UnmarshalText([]byte(l.Level))
+	return level
+}
+
+// Tracing is off unless Endpoint is set.
+type Tracing struct {
+	// Endpoint is the OTLP/HTTP collector base URL, e.g. http://localhost:4318.
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// Auth is off unless Provider is set. The oidc provider needs Issuer,
+// ClientID, and RedirectURL; dev signs anyone in and is for local testing.
+type Auth struct {
+	Provider     string `yaml:"provider" toml:"provider"`
+	Issuer       string `yaml:"issuer" toml:"issuer"`
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+	// RedirectURL is this server's /auth/callback as registered with the
+	// provider.
+	RedirectURL string `yaml:"redirect_url" toml:"redirect_url"`
+	// SessionLength is how long a sign-in lasts.
+	SessionLength time.Duration `yaml:"session_length" toml:"session_length"`
+	// AllowedDomains restricts sign-in to these email domains.
+	AllowedDomains []string `yaml:"allowed_domains" toml:"allowed_domains"`
+}
+
+// RateLimit configures the token buckets applied to requests. Rate and
+// Burst are the default per-visitor bucket; Routes overrides it by route
+// pattern. IPRate and IPBurst cap each IP across all limited routes.
+type RateLimit struct {
+	Rate    float64               `yaml:"rate" toml:"rate"`
+	Burst   int                   `yaml:"burst" toml:"burst"`
+	Expires time.Duration         `yaml:"expires" toml:"expires"`
+	IPRate  float64               `yaml:"ip_rate" toml:"ip_rate"`
+	IPBurst int                   `yaml:"ip_burst" toml:"ip_burst"`
+	Routes  map[string]RouteLimit `yaml:"routes" toml:"routes"`
+}
+
+// RouteLimit is one route's bucket. A zero Rate exempts the route.
+type RouteLimit struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+type Hub struct {
+	// Buffer is how many broadcasts can queue before Broadcast blocks.
+	Buffer int `yaml:"buffer" toml:"buffer"`
+}
+
+// Connections caps open /events streams in total, per IP, and per session.
+// A zero max is unlimited. Each policy is reject or evict-oldest.
+type Connections struct {
+	Max              int    `yaml:"max" toml:"max"`
+	Policy           string `yaml:"policy" toml:"policy"`
+	MaxPerIP         int    `yaml:"max_per_ip" toml:"max_per_ip"`
+	PerIPPolicy      string `yaml:"per_ip_policy" toml:"per_ip_policy"`
+	MaxPerSession    int    `yaml:"max_per_session" toml:"max_per_session"`
+	PerSessionPolicy string `yaml:"per_session_policy" toml:"per_session_policy"`
+}
+
+type Experiments struct {
+	// Status overrides the lifecycle status of experiments by ID.
+	Status map[string]experiment.Status `yaml:"status" toml:"status"`
+	// Access sets who may view and edit experiments by ID. Experiments
+	// not listed are open to everyone.
+	Access map[string]experiment.Access `yaml:"access" toml:"access"`
+}
+
+type Checkboxes struct {
+	Count int `yaml:"count" toml:"count"`
+}
+
+type Canvas struct {
+	Width             int           `yaml:"width" toml:"width"`
+	Height            int           `yaml:"height" toml:"height"`
+	SimplifyTolerance float64       `yaml:"simplify_tolerance" toml:"simplify_tolerance"`
+	MaxElements       int           `yaml:"max_elements" toml:"max_elements"`
+	CompactAfter      time.Duration `yaml:"compact_after" toml:"compact_after"`
+	CompactInterval   time.Duration `yaml:"compact_interval" toml:"compact_interval"`
+	ClearPolicy       string        `yaml:"clear_policy" toml:"clear_policy"`
+	VoteQuorum        float64       `yaml:"vote_quorum" toml:"vote_quorum"`
+	VoteWindow        time.Duration `yaml:"vote_window" toml:"vote_window"`
+	RestoreWindow     time.Duration `yaml:"restore_window" toml:"restore_window"`
+}
+
+type Chat struct {
+	MaxRooms       int `yaml:"max_rooms" toml:"max_rooms"`
+	MaxRoomHistory int `yaml:"max_room_history" toml:"max_room_history"`
+}
+
+type Polls struct {
+	MaxPolls int `yaml:"max_polls" toml:"max_polls"`
+}
+
+type Kanban struct {
+	MaxCards int `yaml:"max_cards" toml:"max_cards"`
+}
+
+type Editor struct {
+	// MaxDocumentLength is in UTF-16 code units.
+	MaxDocumentLength int `yaml:"max_document_length" toml:"max_document_length"`
+}
+
+type Games struct {
+	MaxRooms int `yaml:"max_rooms" toml:"max_rooms"`
+}
+
+type Life struct {
+	// Speed is the starting generations per second.
+	Speed int `yaml:"speed" toml:"speed"`
+}
+
+type Dashboard struct {
+	SampleInterval time.Duration `yaml:"sample_interval" toml:"sample_interval"`
+}
+
+type Quiz struct {
+	QuestionsDir string        `yaml:"questions_dir" toml:"questions_dir"`
+	TimeLimit    time.Duration `yaml:"time_limit" toml:"time_limit"`
+}
+
+// clearPolicies mirrors the canvas experiment's ClearPolicy values.
+var clearPolicies = []string{"instant", "owner", "vote", "soft"}
+
+var authProviders = []string{"", "oidc", "dev"}
+
+// connectionPolicies mirrors sse.LimitPolicy.
+var connectionPolicies = []string{"reject", "evict-oldest"}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:            8080,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			ServiceName: "hypermedia-sync",
+			SampleRatio: 1,
+		},
+		Auth: Auth{SessionLength: 12 * time.Hour},
+		RateLimit: RateLimit{
+			Rate:    10,
+			Burst:   20,
+			Expires: time.Minute,
+			IPRate:  50,
+			IPBurst: 100,
+			Routes: map[string]RouteLimit{
+				"/static*": {},
+				"/livez":   {},
+				"/readyz":  {},
+				"/health":  {},
+				"/metrics": {},
+				// EventSource reconnects on its own; allow for that and
+				// for moving between pages
+				"/events":                             {Rate: 1, Burst: 10},
+				"/experiments/canvas-draw-sync/draw":  {Rate: 30, Burst: 60},
+				"/experiments/canvas-draw-sync/clear": {Rate: 0.1, Burst: 2},
+			},
+		},
+		Hub: Hub{Buffer: 100},
+		Connections: Connections{
+			Max:              5000,
+			Policy:           "reject",
+			MaxPerIP:         50,
+			PerIPPolicy:      "reject",
+			MaxPerSession:    10,
+			PerSessionPolicy: "evict-oldest",
+		},
+		Checkboxes: Checkboxes{Count: 10000},
+		Canvas: Canvas{
+			Width:             1200,
+			Height:            800,
+			SimplifyTolerance: 1.0,
+			MaxElements:       500,
+			CompactAfter:      10 * time.Minute,
+			CompactInterval:   30 * time.Second,
+			ClearPolicy:       "soft",
+			VoteQuorum:        0.5,
+			VoteWindow:        time.Minute,
+			RestoreWindow:     30 * time.Second,
+		},
+		Chat:      Chat{MaxRooms: 20, MaxRoomHistory: 1000},
+		Polls:     Polls{MaxPolls: 100},
+		Kanban:    Kanban{MaxCards: 500},
+		Editor:    Editor{MaxDocumentLength: 100000},
+		Games:     Games{MaxRooms: 100},
+		Life:      Life{Speed: 5},
+		Dashboard: Dashboard{SampleInterval: time.Second},
+		Quiz:      Quiz{TimeLimit: 20 * time.Second},
+	}
+}
+
+// Load builds the configuration from args (without the program name) and
+// the environment. The file is named by -config or CONFIG_FILE; its format
+// follows the extension (.yaml, .yml, or .toml).
+func Load(args []string) (Config, error) {
+	// The first pass only finds the file and rejects bad flags, since flags
+	// have to be applied after the file and environment.
+	path := os.Getenv("CONFIG_FILE")
+	scratch := Default()
+	if err := scratch.flagSet(&path, os.Stderr).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	for _, o := range cfg.options() {
+		if value := os.Getenv(o.env); o.env != "" && value != "" {
+			if err := o.value.Set(value); err != nil {
+				return Config{}, fmt.Errorf("invalid %s %q: %w", o.env, value, err)
+			}
+		}
+	}
+	if err := cfg.flagSet(&path, io.Discard).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) flagSet(path *string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("hypermedia-sync", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(path, "config", *path, "YAML or TOML config file ($CONFIG_FILE)")
+	for _, o := range c.options() {
+		if o.flag == "" {
+			continue
+		}
+		usage := o.usage
+		if o.env != "" {
+			usage += " ($" + o.env + ")"
+		}
+		fs.Var(o.value, o.flag, usage)
+	}
+	return fs
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parsing %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml, or .toml", path)
+	}
+	return nil
+}
+
+// Validate reports every out-of-range setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	for _, cidr := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(cidr)
+		check(err == nil, "server.trusted_proxies entry %q must be a CIDR range such as 10.0.0.0/8", cidr)
+	}
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level must be debug, info, warn, or error")
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format must be json or text")
+	check(c.Tracing.Endpoint == "" || strings.HasPrefix(c.Tracing.Endpoint, "http://") || strings.HasPrefix(c.Tracing.Endpoint, "https://"), "tracing.endpoint must be an http or https URL")
+	check(c.Tracing.ServiceName != "", "tracing.service_name must not be empty")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(slices.Contains(authProviders, c.Auth.Provider), "auth.provider must be oidc, dev, or empty")
+	if c.Auth.Provider == "oidc" {
+		check(strings.HasPrefix(c.Auth.Issuer, "http://") || strings.HasPrefix(c.Auth.Issuer, "https://"), "auth.issuer must be an http or https URL")
+		check(c.Auth.ClientID != "", "auth.client_id is required for the oidc provider")
+		check(strings.HasPrefix(c.Auth.RedirectURL, "http://") || strings.HasPrefix(c.Auth.RedirectURL, "https://"), "auth.redirect_url must be an http or https URL")
+	}
+	check(c.Auth.SessionLength > 0, "auth.session_length must be positive")
+	check(c.RateLimit.Rate > 0, "rate_limit.rate must be positive")
+	check(c.RateLimit.Burst >= 1, "rate_limit.burst must be at least 1")
+	check(c.RateLimit.Expires > 0, "rate_limit.expires must be positive")
+	check(c.RateLimit.IPRate > 0, "rate_limit.ip_rate must be positive")
+	check(c.RateLimit.IPBurst >= 1, "rate_limit.ip_burst must be at least 1")
+	for route, limit := range c.RateLimit.Routes {
+		check(strings.HasPrefix(route, "/"), "rate_limit.routes: route %q must start with /", route)
+		check(limit.Rate >= 0, "rate_limit.routes: rate for %q must not be negative", route)
+		check(limit.Rate == 0 || limit.Burst >= 1, "rate_limit.routes: burst for %q must be at least 1", route)
+	}
+	check(c.Hub.Buffer >= 1, "hub.buffer must be at least 1")
+	check(c.Connections.Max >= 0, "connections.max must not be negative")
+	check(c.Connections.MaxPerIP >= 0, "connections.max_per_ip must not be negative")
+	check(c.Connections.MaxPerSession >= 0, "connections.max_per_session must not be negative")
+	check(slices.Contains(connectionPolicies, c.Connections.Policy), "connections.policy must be reject or evict-oldest")
+	check(slices.Contains(connectionPolicies, c.Connections.PerIPPolicy), "connections.per_ip_policy must be reject or evict-oldest")
+	check(slices.Contains(connectionPolicies, c.Connections.PerSessionPolicy), "connections.per_session_policy must be reject or evict-oldest")
+	for id, status := range c.Experiments.Status {
+		check(status.Valid(), "experiments.status: unknown status %q for experiment %q", status, id)
+	}
+	for id, access := range c.Experiments.Access {
+		check(access.Valid(), "experiments.access: unknown access %q for experiment %q", access, id)
+		check(access == experiment.AccessAnonymous || c.Auth.Provider != "", "experiments.access: %s for experiment %q needs auth.provider", access, id)
+	}
+	check(c.Checkboxes.Count >= 1 && c.Checkboxes.Count <= 100000, "checkboxes.count must be between 1 and 100000")
+	check(c.Canvas.Width >= 1 && c.Canvas.Width <= 10000, "canvas.width must be between 1 and 10000")
+	check(c.Canvas.Height >= 1 && c.Canvas.Height <= 10000, "canvas.height must be between 1 and 10000")
+	check(c.Canvas.SimplifyTolerance >= 0, "canvas.simplify_tolerance must not be negative")
+	check(c.Canvas.MaxElements >= 0, "canvas.max_elements must not be negative")
+	check(c.Canvas.CompactAfter >= 0, "canvas.compact_after must not be negative")
+	check(c.Canvas.CompactInterval > 0, "canvas.compact_interval must be positive")
+	check(slices.Contains(clearPolicies, c.Canvas.ClearPolicy), "canvas.clear_policy must be one of %s", strings.Join(clearPolicies, ", "))
+	check(c.Canvas.VoteQuorum > 0 && c.Canvas.VoteQuorum <= 1, "canvas.vote_quorum must be in (0, 1]")
+	check(c.Canvas.VoteWindow > 0, "canvas.vote_window must be positive")
+	check(c.Canvas.RestoreWindow > 0, "canvas.restore_window must be positive")
+	// chat always has its three default rooms.
+	check(c.Chat.MaxRooms >= 3 && c.Chat.MaxRooms <= 1000, "chat.max_rooms must be between 3 and 1000")
+	check(c.Chat.MaxRoomHistory >= 1 && c.Chat.MaxRoomHistory <= 100000, "chat.max_room_history must be between 1 and 100000")
+	check(c.Polls.MaxPolls >= 1 && c.Polls.MaxPolls <= 10000, "polls.max_polls must be between 1 and 10000")
+	check(c.Kanban.MaxCards >= 1 && c.Kanban.MaxCards <= 10000, "kanban.max_cards must be between 1 and 10000")
+	check(c.Editor.MaxDocumentLength >= 1 && c.Editor.MaxDocumentLength <= 1000000, "editor.max_document_length must be between 1 and 1000000")
+	check(c.Games.MaxRooms >= 1 && c.Games.MaxRooms <= 10000, "games.max_rooms must be between 1 and 10000")
+	check(c.Life.Speed >= 1 && c.Life.Speed <= 60, "life.speed must be between 1 and 60")
+	check(c.Dashboard.SampleInterval >= 100*time.Millisecond && c.Dashboard.SampleInterval <= time.Minute, "dashboard.sample_interval must be between 100ms and 1m")
+	check(c.Quiz.TimeLimit >= 5*time.Second && c.Quiz.TimeLimit <= 2*time.Minute, "quiz.time_limit must be between 5s and 2m")
+
+	return errors.Join(errs...)
+}
+
+// String renders the configuration as YAML with secrets redacted, so it
+// can be printed or saved as a starting config file.
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.redacted())
+	if err != nil {
+		return fmt.Sprintf("error rendering config: %v\n", err)
+	}
+	return string(out)
+}
+
+// LogValue logs the configuration as nested groups keyed like the file,
+// with secrets redacted.
+func (c Config) LogValue() slog.Value {
+	var fields map[string]any
+	out, err := yaml.Marshal(c.redacted())
+	if err == nil {
+		err = yaml.Unmarshal(out, &fields)
+	}
+	if err != nil {
+		return slog.StringValue(fmt.Sprintf("error rendering config: %v", err))
+	}
+	return slog.AnyValue(fields)
+}
+
+func (c Config) redacted() Config {
+	if c.Server.AdminToken != "" {
+		c.Server.AdminToken = "[redacted]"
+	}
+	if c.Server.SessionSecret != "" {
+		c.Server.SessionSecret = "[redacted]"
+	}
+	if c.Auth.ClientSecret != "" {
+		c.Auth.ClientSecret = "[redacted]"
+	}
+	return c
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+)
+
+// option binds a setting to its flag and environment variable. Either name
+// may be empty; secrets have no flag so they stay out of process listings.
+type option struct {
+	flag  string
+	env   string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) options() []option {
+	return []option{
+		{"port", "PORT", "HTTP listen port", (*intValue)(&c.Server.Port)},
+		{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "how long to drain connections on shutdown", (*durationValue)(&c.Server.ShutdownTimeout)},
+		{"", "ADMIN_TOKEN", "", (*stringValue)(&c.Server.AdminToken)},
+		{"", "SESSION_SECRET", "", (*stringValue)(&c.Server.SessionSecret)},
+		{"trusted-proxies", "TRUSTED_PROXIES", "CIDR ranges of reverse proxies whose X-Forwarded-For is trusted, comma separated", (*listValue)(&c.Server.TrustedProxies)},
+
+		{"log-level", "LOG_LEVEL", "minimum log level: debug, info, warn, or error", (*stringValue)(&c.Log.Level)},
+		{"log-format", "LOG_FORMAT", "log output format: json or text", (*stringValue)(&c.Log.Format)},
+
+		{"otlp-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTLP/HTTP collector URL; empty disables tracing", (*stringValue)(&c.Tracing.Endpoint)},
+		{"service-name", "OTEL_SERVICE_NAME", "service name reported with traces", (*stringValue)(&c.Tracing.ServiceName)},
+		{"trace-sample-ratio", "OTEL_TRACES_SAMPLER_ARG", "fraction of new traces recorded", (*floatValue)(&c.Tracing.SampleRatio)},
+
+		{"auth-provider", "AUTH_PROVIDER", "sign-in provider: oidc, dev, or empty to disable", (*stringValue)(&c.Auth.Provider)},
+		{"oidc-issuer", "OIDC_ISSUER", "OpenID Connect issuer URL", (*stringValue)(&c.Auth.Issuer)},
+		{"oidc-client-id", "OIDC_CLIENT_ID", "OpenID Connect client ID", (*stringValue)(&c.Auth.ClientID)},
+		{"", "OIDC_CLIENT_SECRET", "", (*stringValue)(&c.Auth.ClientSecret)},
+		{"oidc-redirect-url", "OIDC_REDIRECT_URL", "this server's /auth/callback URL as registered with the provider", (*stringValue)(&c.Auth.RedirectURL)},
+		{"auth-session-length", "AUTH_SESSION_LENGTH", "how long a sign-in lasts", (*durationValue)(&c.Auth.SessionLength)},
+		{"auth-allowed-domains", "AUTH_ALLOWED_DOMAINS", "email domains allowed to sign in, comma separated; empty allows all", (*listValue)(&c.Auth.AllowedDomains)},
+
+		{"rate-limit", "RATE_LIMIT", "requests per second allowed per visitor on routes without their own limit", (*floatValue)(&c.RateLimit.Rate)},
+		{"rate-burst", "RATE_LIMIT_BURST", "requests allowed in a burst per visitor on routes without their own limit", (*intValue)(&c.RateLimit.Burst)},
+		{"rate-expires", "RATE_LIMIT_EXPIRES", "how long an idle visitor's limiter is kept", (*durationValue)(&c.RateLimit.Expires)},
+		{"rate-limit-ip", "RATE_LIMIT_IP", "requests per second allowed per IP across all limited routes", (*floatValue)(&c.RateLimit.IPRate)},
+		{"rate-burst-ip", "RATE_LIMIT_IP_BURST", "requests allowed in a burst per IP", (*intValue)(&c.RateLimit.IPBurst)},
+		{"rate-routes", "RATE_LIMIT_ROUTES", "per-route limits as route=rate:burst or route=off, comma separated", (*routesValue)(&c.RateLimit.Routes)},
+
+		{"hub-buffer", "HUB_BUFFER", "broadcasts queued before senders block", (*intValue)(&c.Hub.Buffer)},
+
+		{"max-connections", "MAX_CONNECTIONS", "open /events streams allowed in total; 0 is unlimited", (*intValue)(&c.Connections.Max)},
+		{"connection-policy", "CONNECTION_POLICY", "when over max-connections: reject or evict-oldest", (*stringValue)(&c.Connections.Policy)},
+		{"max-connections-per-ip", "MAX_CONNECTIONS_PER_IP", "open /events streams allowed per IP; 0 is unlimited", (*intValue)(&c.Connections.MaxPerIP)},
+		{"connection-policy-per-ip", "CONNECTION_POLICY_PER_IP", "when over max-connections-per-ip: reject or evict-oldest", (*stringValue)(&c.Connections.PerIPPolicy)},
+		{"max-connections-per-session", "MAX_CONNECTIONS_PER_SESSION", "open /events streams allowed per session; 0 is unlimited", (*intValue)(&c.Connections.MaxPerSession)},
+		{"connection-policy-per-session", "CONNECTION_POLICY_PER_SESSION", "when over max-connections-per-session: reject or evict-oldest", (*stringValue)(&c.Connections.PerSessionPolicy)},
+
+		{"experiment-status", "EXPERIMENT_STATUS", "experiment statuses as id=status,id=status", (*statusValue)(&c.Experiments.Status)},
+		{"experiment-access", "EXPERIMENT_ACCESS", "experiment access as id=anonymous|auth-to-edit|auth-to-view, comma separated", (*accessValue)(&c.Experiments.Access)},
+
+		{"checkboxes", "CHECKBOXES_COUNT", "number of shared checkboxes", (*intValue)(&c.Checkboxes.Count)},
+
+		{"canvas-width", "CANVAS_WIDTH", "canvas width in pixels", (*intValue)(&c.Canvas.Width)},
+		{"canvas-height", "CANVAS_HEIGHT", "canvas height in pixels", (*intValue)(&c.Canvas.Height)},
+		{"canvas-simplify-tolerance", "CANVAS_SIMPLIFY_TOLERANCE", "stroke simplification tolerance in pixels", (*floatValue)(&c.Canvas.SimplifyTolerance)},
+		{"canvas-max-elements", "CANVAS_MAX_ELEMENTS", "elements kept before the oldest are flattened", (*intValue)(&c.Canvas.MaxElements)},
+		{"canvas-compact-after", "CANVAS_COMPACT_AFTER", "age at which elements may be flattened", (*durationValue)(&c.Canvas.CompactAfter)},
+		{"canvas-compact-interval", "CANVAS_COMPACT_INTERVAL", "how often the compactor runs", (*durationValue)(&c.Canvas.CompactInterval)},
+		{"canvas-clear-policy", "CANVAS_CLEAR_POLICY", "initial clear policy: instant, owner, vote, or soft", (*stringValue)(&c.Canvas.ClearPolicy)},
+		{"canvas-clear-vote-quorum", "CANVAS_CLEAR_VOTE_QUORUM", "fraction of online users needed to vote a clear", (*floatValue)(&c.Canvas.VoteQuorum)},
+		{"canvas-clear-vote-window", "CANVAS_CLEAR_VOTE_WINDOW", "how long a clear vote counts", (*durationValue)(&c.Canvas.VoteWindow)},
+		{"canvas-restore-window", "CANVAS_RESTORE_WINDOW", "how long a soft clear can be undone", (*durationValue)(&c.Canvas.RestoreWindow)},
+
+		{"chat-max-rooms", "CHAT_MAX_ROOMS", "chat rooms allowed, including the three defaults", (*intValue)(&c.Chat.MaxRooms)},
+		{"chat-max-room-history", "CHAT_MAX_ROOM_HISTORY", "messages each chat room keeps", (*intValue)(&c.Chat.MaxRoomHistory)},
+
+		{"polls-max-polls", "POLLS_MAX_POLLS", "polls kept in memory at once", (*intValue)(&c.Polls.MaxPolls)},
+
+		{"kanban-max-cards", "KANBAN_MAX_CARDS", "cards the kanban board holds", (*intValue)(&c.Kanban.MaxCards)},
+
+		{"editor-max-document-length", "EDITOR_MAX_DOCUMENT_LENGTH", "shared document length in UTF-16 code units", (*intValue)(&c.Editor.MaxDocumentLength)},
+
+		{"games-max-rooms", "GAMES_MAX_ROOMS", "game rooms open at once", (*intValue)(&c.Games.MaxRooms)},
+
+		{"life-speed", "LIFE_SPEED", "Game of Life starting generations per second", (*intValue)(&c.Life.Speed)},
+
+		{"dashboard-sample-interval", "DASHBOARD_SAMPLE_INTERVAL", "time between dashboard samples", (*durationValue)(&c.Dashboard.SampleInterval)},
+
+		{"quiz-questions-dir", "QUIZ_QUESTIONS_DIR", "directory of extra quiz question sets", (*stringValue)(&c.Quiz.QuestionsDir)},
+		{"quiz-time-limit", "QUIZ_TIME_LIMIT", "default answer window per question", (*durationValue)(&c.Quiz.TimeLimit)},
+	}
+}
+
+// The value types below implement flag.Value over fields of Config, in the
+// same way the flag package's own values do.
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("not an integer")
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return errors.New("not a number")
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("not a duration")
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+// listValue replaces the list with comma-separated entries.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			*v = append(*v, entry)
+		}
+	}
+	return nil
+}
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+// statusValue merges "id=status" entries over statuses from the file.
+type statusValue map[string]experiment.Status
+
+func (v *statusValue) Set(s string) error {
+	statuses, err := experiment.ParseStatuses(s)
+	if err != nil {
+		return err
+	}
+	if *v == nil {
+		*v = make(statusValue)
+	}
+	maps.Copy(*v, statuses)
+	return nil
+}
+
+func (v *statusValue) String() string {
+	var entries []string
+	for _, id := range slices.Sorted(maps.Keys(*v)) {
+		entries = append(entries, id+"="+string((*v)[id]))
+	}
+	return strings.Join(entries, ",")
+}
+
+// accessValue merges "id=access" entries over access from the file.
+type accessValue map[string]experiment.Access
+
+func (v *accessValue) Set(s string) error {
+	policies, err := experiment.ParseAccess(s)
+	if err != nil {
+		return err
+	}
+	if *v == nil {
+		*v = make(accessValue)
+	}
+	maps.Copy(*v, policies)
+	return nil
+}
+
+func (v *accessValue) String() string {
+	var entries []string
+	for _, id := range slices.Sorted(maps.Keys(*v)) {
+		entries = append(entries, id+"="+string((*v)[id]))
+	}
+	return strings.Join(entries, ",")
+}
+
+// routesValue merges "route=rate:burst" and "route=off" entries over the
+// routes from the defaults and the file.
+type routesValue map[string]RouteLimit
+
+func (v *routesValue) Set(s string) error {
+	if *v == nil {
+		*v = make(routesValue)
+	}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid route limit %q, want route=rate:burst or route=off", entry)
+		}
+		route, limit = strings.TrimSpace(route), strings.TrimSpace(limit)
+		if limit == "off" {
+			(*v)[route] = RouteLimit{}
+			continue
+		}
+		rate, burst, ok := strings.Cut(limit, ":")
+		r, rateErr := strconv.ParseFloat(rate, 64)
+		b, burstErr := strconv.Atoi(burst)
+		if !ok || rateErr != nil || burstErr != nil {
+			return fmt.Errorf("invalid limit %q for route %q, want rate:burst or off", limit, route)
+		}
+		(*v)[route] = RouteLimit{Rate: r, Burst: b}
+	}
+	return nil
+}
+
+func (v *routesValue) String() string {
+	var entries []string
+	for _, route := range slices.Sorted(maps.Keys(*v)) {
+		limit := (*v)[route]
+		if limit.Rate == 0 {
+			entries = append(entries, route+"=off")
+		} else {
+			entries = append(entries, fmt.Sprintf("%s=%g:%d", route, limit.Rate, limit.Burst))
+		}
+	}
+	return strings.Join(entries, ",")
+}
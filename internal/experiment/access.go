@@ -0,0 +1,71 @@
+package experiment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Access is who may use an experiment, independent of its status.
+type Access string
+
+const (
+	// AccessAnonymous lets anyone view and edit.
+	AccessAnonymous Access = "anonymous"
+	// AccessAuthToEdit lets anyone view but only signed-in users edit.
+	AccessAuthToEdit Access = "auth-to-edit"
+	// AccessAuthToView requires signing in to see the experiment at all,
+	// including its live updates.
+	AccessAuthToView Access = "auth-to-view"
+)
+
+func (a Access) Valid() bool {
+	switch a {
+	case AccessAnonymous, AccessAuthToEdit, AccessAuthToView:
+		return true
+	}
+	return false
+}
+
+// Label is shown in the listing for experiments that need signing in.
+func (a Access) Label() string {
+	switch a {
+	case AccessAuthToEdit:
+		return "Sign in to edit"
+	case AccessAuthToView:
+		return "Sign in to view"
+	}
+	return ""
+}
+
+// Allows reports whether a visitor may view the experiment, or edit it if
+// edit is set.
+func (a Access) Allows(signedIn, edit bool) bool {
+	switch a {
+	case AccessAuthToView:
+		return signedIn
+	case AccessAuthToEdit:
+		return signedIn || !edit
+	}
+	return true
+}
+
+// ParseAccess parses "id=access,id=access" as used by EXPERIMENT_ACCESS.
+func ParseAccess(value string) (map[string]Access, error) {
+	policies := make(map[string]Access)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, access, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid experiment access %q, want id=access", entry)
+		}
+		id, access = strings.TrimSpace(id), strings.TrimSpace(access)
+		if !Access(access).Valid() {
+			return nil, fmt.Errorf("unknown access %q for experiment %q", access, id)
+		}
+		policies[id] = Access(access)
+	}
+	return policies, nil
+}
@@ -0,0 +1,62 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Checker is implemented by experiments that can report whether their state
+// is usable. Check should return promptly once ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckLock reports whether a lock guarding experiment state can be taken
+// before ctx is done, which catches handlers that have deadlocked or are
+// holding it far too long. It polls rather than blocking so an abandoned
+// check doesn't leave a goroutine queued on the lock.
+func CheckLock(ctx context.Context, tryLock func() bool, unlock func()) error {
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if tryLock() {
+			unlock()
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("state lock unavailable: %w", ctx.Err())
+		}
+	}
+}
+
+// Check runs every experiment's Checker concurrently and returns their
+// results by experiment ID. Drafts and experiments without a Checker are
+// left out.
+func (r *Registry) Check(ctx context.Context) map[string]error {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]error)
+	)
+	for _, exp := range r.All() {
+		checker, ok := exp.(Checker)
+		id := exp.Metadata().ID
+		if !ok || r.Status(id) == StatusDraft {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := checker.Check(ctx)
+			mu.Lock()
+			results[id] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
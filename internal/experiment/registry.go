@@ -0,0 +1,202 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metadata describes an experiment for listings and routing.
+type Metadata struct {
+	ID          string
+	Name        string
+	Description string
+	// Thumbnail is an optional image URL shown on the listing card.
+	Thumbnail string
+}
+
+// Path is where the experiment's routes are mounted.
+func (m Metadata) Path() string {
+	return "/experiments/" + m.ID
+}
+
+// Experiment is a self-contained demo that the registry mounts and runs.
+type Experiment interface {
+	Metadata() Metadata
+	// RegisterRoutes adds the experiment's handlers to a group already
+	// mounted at Metadata().Path(). The page itself is served at "".
+	RegisterRoutes(g *echo.Group)
+	// Topics lists the SSE topics the experiment broadcasts on. Clients can
+	// subscribe to them with /events?topic=<name>.
+	Topics() []string
+	// Start launches background work, which must stop when ctx is done.
+	Start(ctx context.Context) error
+	// Stop releases any state held by the experiment.
+	Stop(ctx context.Context) error
+}
+
+type Registry struct {
+	mu          sync.RWMutex
+	experiments []Experiment
+	byID        map[string]Experiment
+	topics      map[string]string // topic -> experiment ID
+	statuses    map[string]Status
+	access      map[string]Access
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		byID:     make(map[string]Experiment),
+		topics:   make(map[string]string),
+		statuses: make(map[string]Status),
+		access:   make(map[string]Access),
+	}
+}
+
+// Register adds an experiment. IDs and topics must be unique.
+func (r *Registry) Register(exp Experiment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	meta := exp.Metadata()
+	if meta.ID == "" {
+		return errors.New("experiment ID is required")
+	}
+	if _, exists := r.byID[meta.ID]; exists {
+		return fmt.Errorf("experiment %q already registered", meta.ID)
+	}
+	for _, topic := range exp.Topics() {
+		if owner, exists := r.topics[topic]; exists {
+			return fmt.Errorf("topic %q of experiment %q already used by %q", topic, meta.ID, owner)
+		}
+	}
+
+	r.experiments = append(r.experiments, exp)
+	r.byID[meta.ID] = exp
+	r.statuses[meta.ID] = StatusActive
+	r.access[meta.ID] = AccessAnonymous
+	for _, topic := range exp.Topics() {
+		r.topics[topic] = meta.ID
+	}
+	return nil
+}
+
+// All returns experiments in registration order.
+func (r *Registry) All() []Experiment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Experiment(nil), r.experiments...)
+}
+
+func (r *Registry) Get(id string) (Experiment, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	exp, ok := r.byID[id]
+	return exp, ok
+}
+
+// Status returns the experiment's current status.
+func (r *Registry) Status(id string) Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.statuses[id]
+}
+
+// SetStatus changes an experiment's status. It takes effect on the next
+// request; callers are responsible for telling connected clients.
+func (r *Registry) SetStatus(id string, status Status) error {
+	if !status.Valid() {
+		return fmt.Errorf("unknown status %q", status)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.byID[id]; !exists {
+		return fmt.Errorf("experiment %q not registered", id)
+	}
+	r.statuses[id] = status
+	return nil
+}
+
+// Access returns who may use the experiment.
+func (r *Registry) Access(id string) Access {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.access[id]
+}
+
+// SetAccess changes who may use an experiment. Like SetStatus, it takes
+// effect on the next request.
+func (r *Registry) SetAccess(id string, access Access) error {
+	if !access.Valid() {
+		return fmt.Errorf("unknown access %q", access)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.byID[id]; !exists {
+		return fmt.Errorf("experiment %q not registered", id)
+	}
+	r.access[id] = access
+	return nil
+}
+
+// HiddenTopics returns the topics of experiments the visitor may not view,
+// whose events must not reach their live connections.
+func (r *Registry) HiddenTopics(signedIn bool) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var hidden []string
+	for topic, id := range r.topics {
+		if !r.access[id].Allows(signedIn, false) {
+			hidden = append(hidden, topic)
+		}
+	}
+	return hidden
+}
+
+// Topics returns every registered SSE topic.
+func (r *Registry) Topics() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	topics := make([]string, 0, len(r.topics))
+	for topic := range r.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Mount registers each experiment's routes under its path and tells the hub
+// which topics clients may subscribe to.
+func (r *Registry) Mount(e *echo.Echo, hub *sse.Hub) {
+	for _, exp := range r.All() {
+		id := exp.Metadata().ID
+		exp.RegisterRoutes(e.Group(exp.Metadata().Path(), r.guard(id)))
+	}
+	hub.RegisterTopics(r.Topics()...)
+}
+
+// Start starts every experiment, stopping at the first failure.
+func (r *Registry) Start(ctx context.Context) error {
+	for _, exp := range r.All() {
+		if err := exp.Start(ctx); err != nil {
+			return fmt.Errorf("starting experiment %q: %w", exp.Metadata().ID, err)
+		}
+	}
+	return nil
+}
+
+// Stop stops every experiment in reverse order and joins any errors.
+func (r *Registry) Stop(ctx context.Context) error {
+	experiments := r.All()
+	var errs []error
+	for i := len(experiments) - 1; i >= 0; i-- {
+		if err := experiments[i].Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping experiment %q: %w", experiments[i].Metadata().ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
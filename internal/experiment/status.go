@@ -0,0 +1,186 @@
+package experiment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"hypermedia-sync/internal/auth"
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/templates/layout"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+type Status string
+
+const (
+	// StatusDraft hides the experiment from the listing and its routes.
+	StatusDraft Status = "draft"
+	// StatusActive is the normal, fully interactive state.
+	StatusActive Status = "active"
+	// StatusReadOnly keeps the experiment viewable but rejects mutations.
+	StatusReadOnly Status = "read-only"
+	// StatusArchived is read-only and marked as retired in the listing.
+	StatusArchived Status = "archived"
+)
+
+func (s Status) Valid() bool {
+	switch s {
+	case StatusDraft, StatusActive, StatusReadOnly, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// Label is the status as shown to users.
+func (s Status) Label() string {
+	switch s {
+	case StatusDraft:
+		return "Draft"
+	case StatusReadOnly:
+		return "Read-only"
+	case StatusArchived:
+		return "Archived"
+	}
+	return "Active"
+}
+
+// Notice explains the status to someone viewing the experiment. Active
+// experiments have no notice.
+func (s Status) Notice() string {
+	switch s {
+	case StatusDraft:
+		return "This experiment has been unpublished and will be unavailable after you leave."
+	case StatusReadOnly:
+		return "This experiment is read-only. You can look around, but changes are disabled."
+	case StatusArchived:
+		return "This experiment has been archived and no longer accepts changes."
+	}
+	return ""
+}
+
+// Writable reports whether the experiment accepts mutations.
+func (s Status) Writable() bool {
+	return s == StatusActive
+}
+
+// ParseStatuses parses "id=status,id=status" as used by EXPERIMENT_STATUS.
+func ParseStatuses(value string) (map[string]Status, error) {
+	statuses := make(map[string]Status)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, status, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid experiment status %q, want id=status", entry)
+		}
+		id, status = strings.TrimSpace(id), strings.TrimSpace(status)
+		if !Status(status).Valid() {
+			return nil, fmt.Errorf("unknown status %q for experiment %q", status, id)
+		}
+		statuses[id] = Status(status)
+	}
+	return statuses, nil
+}
+
+// StatusEvent is the SSE event that carries an experiment's banner.
+func StatusEvent(id string) string {
+	return "experiment-status-" + id
+}
+
+const (
+	idContextKey     = "experiment.id"
+	statusContextKey = "experiment.status"
+	accessContextKey = "experiment.access"
+)
+
+// StatusOf returns the status of the experiment handling the request.
+func StatusOf(c echo.Context) Status {
+	if status, ok := c.Get(statusContextKey).(Status); ok {
+		return status
+	}
+	return StatusActive
+}
+
+// Banner returns the status banner for the given experiment.
+func Banner(id string, status Status) layout.StatusBanner {
+	return layout.StatusBanner{
+		Event:   StatusEvent(id),
+		Label:   status.Label(),
+		Message: status.Notice(),
+	}
+}
+
+// BannerOf returns the status banner for the experiment handling the request.
+// Visitors who can watch but not edit are asked to sign in instead.
+func BannerOf(c echo.Context) layout.StatusBanner {
+	id, _ := c.Get(idContextKey).(string)
+	status := StatusOf(c)
+	access, _ := c.Get(accessContextKey).(Access)
+	if status.Writable() && !access.Allows(session.Get(c).SignedIn(), true) {
+		return layout.StatusBanner{
+			Event:    StatusEvent(id),
+			Message:  "You can watch, but you need to sign in to make changes.",
+			LoginURL: auth.LoginURL(Metadata{ID: id}.Path()),
+		}
+	}
+	return Banner(id, status)
+}
+
+// guard enforces the experiment's current status and access on every route
+// in its group. Drafts are not found, visitors who may not view are sent to
+// sign in, and mutations that aren't allowed are answered with a notice
+// swapped into the page's status banner.
+func (r *Registry) guard(id string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			status, access := r.Status(id), r.Access(id)
+			signedIn := session.Get(c).SignedIn()
+			c.Set(idContextKey, id)
+			c.Set(statusContextKey, status)
+			c.Set(accessContextKey, access)
+
+			method := c.Request().Method
+			if method == http.MethodGet || method == http.MethodHead {
+				if status == StatusDraft {
+					return c.String(404, "Experiment not found")
+				}
+				if !access.Allows(signedIn, false) {
+					return redirectToLogin(c, id)
+				}
+				return next(c)
+			}
+
+			var component templ.Component
+			switch {
+			case !status.Writable():
+				component = layout.ExperimentStatusNotice(status.Label(), status.Notice()+" Your last change was not saved.")
+			case !access.Allows(signedIn, true):
+				if c.Request().Header.Get("HX-Request") != "true" {
+					return c.String(401, "Sign in to make changes")
+				}
+				component = layout.SignInNotice("Sign in to make changes. Your last change was not saved.", auth.LoginURL(Metadata{ID: id}.Path()))
+			default:
+				return next(c)
+			}
+			c.Response().Header().Set("HX-Retarget", "#experiment-banner")
+			c.Response().Header().Set("HX-Reswap", "innerHTML")
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+	}
+}
+
+// redirectToLogin sends a visitor who may not view the experiment to sign
+// in. HTMX requests, such as boosted navigation, get a full-page redirect
+// back to the experiment rather than to the fragment they asked for.
+func redirectToLogin(c echo.Context, id string) error {
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Redirect", auth.LoginURL(Metadata{ID: id}.Path()))
+		return c.NoContent(http.StatusUnauthorized)
+	}
+	return c.Redirect(http.StatusSeeOther, auth.LoginURL(c.Request().URL.RequestURI()))
+}
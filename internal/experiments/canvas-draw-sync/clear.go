@@ -0,0 +1,394 @@
+package canvasdrawsync
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+type ClearPolicy string
+
+const (
+	// ClearInstant lets anyone wipe the canvas immediately.
+	ClearInstant ClearPolicy = "instant"
+	// ClearOwner only lets the canvas owner clear it.
+	ClearOwner ClearPolicy = "owner"
+	// ClearVote clears once enough online users have voted for it.
+	ClearVote ClearPolicy = "vote"
+	// ClearSoft clears immediately but keeps a snapshot that anyone can
+	// restore for a limited time.
+	ClearSoft ClearPolicy = "soft"
+)
+
+func (p ClearPolicy) Valid() bool {
+	switch p {
+	case ClearInstant, ClearOwner, ClearVote, ClearSoft:
+		return true
+	}
+	return false
+}
+
+// clearState tracks ownership and in-flight clear operations. Lock order is
+// clearState.mu before canvasMutex. Events are rendered under the lock but
+// broadcast after it is released, since Broadcast blocks when the hub queue
+// is full.
+var clearState = struct {
+	mu     sync.Mutex
+	policy ClearPolicy
+	// owner is the session ID of whoever claimed the canvas.
+	owner string
+	// votes maps session IDs to when they voted.
+	votes map[string]time.Time
+	// restore holds the elements removed by the last soft clear.
+	restore      []experiments.DrawingElement
+	restoreUntil time.Time
+	restoreTimer *time.Timer
+}{
+	policy: DefaultSettings().ClearPolicy,
+	votes:  make(map[string]time.Time),
+}
+
+// isOwnerLocked must be called with clearState.mu held.
+func isOwnerLocked(c echo.Context) bool {
+	s := session.Get(c)
+	return clearState.owner != "" && !s.New && s.ID == clearState.owner
+}
+
+// claimableLocked reports whether the canvas can be claimed: nobody owns
+// it, or its owner has no canvas page open. It must be called with
+// clearState.mu held.
+func claimableLocked(hub *sse.Hub) bool {
+	return clearState.owner == "" || !hub.SessionSubscribed(clearState.owner, Topic)
+}
+
+// clearControlsLocked must be called with clearState.mu held.
+func clearControlsLocked(hub *sse.Hub, isOwner bool) experiments.ClearControlsData {
+	data := experiments.ClearControlsData{
+		Policy:   string(clearState.policy),
+		Policies: []string{string(ClearInstant), string(ClearOwner), string(ClearVote), string(ClearSoft)},
+		IsOwner:  isOwner,
+		CanClaim: !isOwner && claimableLocked(hub),
+	}
+	if clearState.policy == ClearVote {
+		pruneVotesLocked(time.Now())
+		data.Votes = len(clearState.votes)
+		data.VotesNeeded = votesNeeded(hub)
+	}
+	if time.Now().Before(clearState.restoreUntil) {
+		data.RestoreUntil = clearState.restoreUntil
+	}
+	return data
+}
+
+func clearControls(c echo.Context, hub *sse.Hub) experiments.ClearControlsData {
+	clearState.mu.Lock()
+	defer clearState.mu.Unlock()
+	return clearControlsLocked(hub, isOwnerLocked(c))
+}
+
+// votesNeeded is the quorum of sessions with the canvas open, matching how
+// votes are counted.
+func votesNeeded(hub *sse.Hub) int {
+	needed := int(math.Ceil(float64(hub.TopicSessions(Topic)) * settings.VoteQuorum))
+	return max(needed, 1)
+}
+
+func pruneVotesLocked(now time.Time) {
+	for id, at := range clearState.votes {
+		if now.Sub(at) > settings.VoteWindow {
+			delete(clearState.votes, id)
+		}
+	}
+}
+
+func ClearCanvasHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		ctx := c.Request().Context()
+		s := session.Get(c)
+		var events []sse.Event
+
+		clearState.mu.Lock()
+		switch clearState.policy {
+		case ClearOwner:
+			if !isOwnerLocked(c) {
+				clearState.mu.Unlock()
+				return statusMessage(c, "Only the canvas owner can clear it.")
+			}
+
+		case ClearVote:
+			// Votes count once per session, and only from sessions with
+			// the page open, so made-up IDs can't stuff the ballot.
+			if s.New || !hub.SessionSubscribed(s.ID, Topic) {
+				clearState.mu.Unlock()
+				return statusMessage(c, "Open the canvas in your browser to vote.")
+			}
+			now := time.Now()
+			pruneVotesLocked(now)
+			clearState.votes[s.ID] = now
+			votes, needed := len(clearState.votes), votesNeeded(hub)
+			if votes < needed {
+				clearState.mu.Unlock()
+				var builder strings.Builder
+				err := experiments.ClearVoteTally(votes, needed).Render(ctx, &builder)
+				if err != nil {
+					return c.String(500, "Error generating vote tally HTML")
+				}
+				hub.Broadcast(sse.Event{
+					Name:      "canvas-clear-votes",
+					Data:      builder.String(),
+					ExcludeID: originatorID,
+					Topic:     Topic,
+				})
+				c.Response().Header().Set("HX-Retarget", "#clear-vote-tally")
+				c.Response().Header().Set("HX-Reswap", "innerHTML")
+				return c.HTML(200, builder.String())
+			}
+			clearState.votes = make(map[string]time.Time)
+			events = appendEvent(ctx, events, "canvas-clear-votes", experiments.ClearVoteTally(0, votesNeeded(hub)))
+		}
+
+		removed := clearCanvasLocked()
+		if clearState.policy == ClearSoft && len(removed) > 0 {
+			events = append(events, offerRestoreLocked(ctx, hub, removed)...)
+		}
+		clearState.mu.Unlock()
+
+		for _, event := range events {
+			hub.Broadcast(event)
+		}
+
+		var sseClearBuilder strings.Builder
+		sseClearComponent := experiments.CanvasSVG(emptyCanvas())
+		err := sseClearComponent.Render(ctx, &sseClearBuilder)
+		if err != nil {
+			return c.String(500, "Error generating clear canvas SSE HTML")
+		}
+
+		hub.Broadcast(sse.Event{
+			Name:      "canvas-cleared",
+			Data:      sseClearBuilder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+
+		return c.HTML(200, sseClearBuilder.String())
+	}
+}
+
+// clearCanvasLocked empties the canvas and returns what was removed. It must
+// be called with clearState.mu held.
+func clearCanvasLocked() []experiments.DrawingElement {
+	canvasMutex.Lock()
+	defer canvasMutex.Unlock()
+
+	removed := canvas.Elements
+	canvas.Elements = []experiments.DrawingElement{}
+	canvasVersion++
+	mutations.Inc("clear")
+	recordHistory(historyClear, time.Now())
+	return removed
+}
+
+func emptyCanvas() experiments.CanvasState {
+	return experiments.CanvasState{
+		Elements: []experiments.DrawingElement{},
+		Width:    canvas.Width,
+		Height:   canvas.Height,
+	}
+}
+
+// offerRestoreLocked stores the removed elements and returns the event
+// that shows everyone a restore button until the window closes.
+func offerRestoreLocked(ctx context.Context, hub *sse.Hub, removed []experiments.DrawingElement) []sse.Event {
+	clearState.restore = removed
+	clearState.restoreUntil = time.Now().Add(settings.RestoreWindow)
+	if clearState.restoreTimer != nil {
+		clearState.restoreTimer.Stop()
+	}
+
+	deadline := clearState.restoreUntil
+	clearState.restoreTimer = time.AfterFunc(settings.RestoreWindow, func() {
+		clearState.mu.Lock()
+		if !clearState.restoreUntil.Equal(deadline) {
+			clearState.mu.Unlock()
+			return
+		}
+		clearState.restore = nil
+		clearState.restoreUntil = time.Time{}
+		clearState.mu.Unlock()
+		for _, event := range appendEvent(context.Background(), nil, "canvas-restore-updated", experiments.CanvasRestoreButton(time.Time{})) {
+			hub.Broadcast(event)
+		}
+	})
+
+	return appendEvent(ctx, nil, "canvas-restore-updated", experiments.CanvasRestoreButton(deadline))
+}
+
+// appendEvent renders component as a canvas event and appends it to events.
+// Render failures leave events unchanged.
+func appendEvent(ctx context.Context, events []sse.Event, name string, component templ.Component) []sse.Event {
+	var builder strings.Builder
+	if err := component.Render(ctx, &builder); err != nil {
+		return events
+	}
+	return append(events, sse.Event{Name: name, Data: builder.String(), Topic: Topic})
+}
+
+// RestoreCanvasHandler undoes a soft clear. Anything drawn since the clear
+// stays on top of the restored elements.
+func RestoreCanvasHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		ctx := c.Request().Context()
+
+		clearState.mu.Lock()
+		if clearState.restore == nil || time.Now().After(clearState.restoreUntil) {
+			clearState.mu.Unlock()
+			return statusMessage(c, "The canvas can no longer be restored.")
+		}
+
+		restored := clearState.restore
+		clearState.restore = nil
+		clearState.restoreUntil = time.Time{}
+		if clearState.restoreTimer != nil {
+			clearState.restoreTimer.Stop()
+		}
+
+		canvasMutex.Lock()
+		elements := make([]experiments.DrawingElement, 0, len(restored)+len(canvas.Elements))
+		elements = append(elements, restored...)
+		elements = append(elements, canvas.Elements...)
+		canvas.Elements = elements
+		canvasVersion++
+		mutations.Inc("restore")
+		recordHistory(historyAdd, time.Now(), restored...)
+		state := experiments.CanvasState{
+			Elements: append([]experiments.DrawingElement(nil), canvas.Elements...),
+			Width:    canvas.Width,
+			Height:   canvas.Height,
+		}
+		canvasMutex.Unlock()
+		clearState.mu.Unlock()
+
+		for _, event := range appendEvent(ctx, nil, "canvas-restore-updated", experiments.CanvasRestoreButton(time.Time{})) {
+			hub.Broadcast(event)
+		}
+
+		var builder strings.Builder
+		if err := experiments.CanvasSVG(state).Render(ctx, &builder); err != nil {
+			return c.String(500, "Error generating canvas HTML")
+		}
+
+		hub.Broadcast(sse.Event{
+			Name:      "canvas-restored",
+			Data:      builder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+
+		return c.HTML(200, builder.String())
+	}
+}
+
+// ClearPolicyHandler lets the owner switch the canvas's clear policy.
+func ClearPolicyHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		policy := ClearPolicy(c.FormValue("policy"))
+
+		clearState.mu.Lock()
+		if !isOwnerLocked(c) {
+			clearState.mu.Unlock()
+			return statusMessage(c, "Only the canvas owner can change the clear policy.")
+		}
+		if !policy.Valid() {
+			clearState.mu.Unlock()
+			return c.String(400, "Unknown clear policy")
+		}
+
+		clearState.policy = policy
+		clearState.votes = make(map[string]time.Time)
+		others, owner := clearControlsLocked(hub, false), clearControlsLocked(hub, true)
+		clearState.mu.Unlock()
+
+		return respondClearControls(c, hub, others, experiments.CanvasClearButton(owner))
+	}
+}
+
+// ClaimCanvasHandler makes the visitor the canvas owner, if nobody owns it
+// or its owner has no page open.
+func ClaimCanvasHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s := session.Get(c)
+
+		if s.New {
+			return statusMessage(c, "Open the canvas in your browser to claim it.")
+		}
+
+		clearState.mu.Lock()
+		if !claimableLocked(hub) {
+			clearState.mu.Unlock()
+			return statusMessage(c, "Someone else owns this canvas.")
+		}
+		clearState.owner = s.ID
+		others, owner := clearControlsLocked(hub, false), clearControlsLocked(hub, true)
+		clearState.mu.Unlock()
+
+		return respondClearControls(c, hub, others, experiments.CanvasClearControls(owner))
+	}
+}
+
+// ReleaseCanvasHandler gives up ownership so anyone can claim the canvas.
+func ReleaseCanvasHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		clearState.mu.Lock()
+		if !isOwnerLocked(c) {
+			clearState.mu.Unlock()
+			return statusMessage(c, "Only the canvas owner can release it.")
+		}
+		clearState.owner = ""
+		controls := clearControlsLocked(hub, false)
+		clearState.mu.Unlock()
+
+		return respondClearControls(c, hub, controls, experiments.CanvasClearControls(controls))
+	}
+}
+
+// respondClearControls pushes the non-owner controls to everyone else and
+// answers the request with own.
+func respondClearControls(c echo.Context, hub *sse.Hub, others experiments.ClearControlsData, own templ.Component) error {
+	var builder strings.Builder
+	err := experiments.CanvasClearButton(others).Render(c.Request().Context(), &builder)
+	if err != nil {
+		return c.String(500, "Error generating clear controls HTML")
+	}
+	hub.Broadcast(sse.Event{
+		Name:      "canvas-clear-policy-updated",
+		Data:      builder.String(),
+		ExcludeID: c.Request().Header.Get("X-Originator-ID"),
+		Topic:     Topic,
+	})
+
+	builder.Reset()
+	if err := own.Render(c.Request().Context(), &builder); err != nil {
+		return c.String(500, "Error generating clear controls HTML")
+	}
+	return c.HTML(200, builder.String())
+}
+
+// statusMessage shows a message in the toolbar instead of swapping the
+// canvas, which is what the clear and restore buttons target.
+func statusMessage(c echo.Context, message string) error {
+	c.Response().Header().Set("HX-Retarget", "#status-message")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return experiments.StatusMessage(message).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,161 @@
+package canvasdrawsync
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"log/slog"
+	"strings"
+	"time"
+
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+)
+
+// minCompactBatch avoids rewriting the snapshot for a handful of old strokes.
+const minCompactBatch = 25
+
+// compactNow lets the draw path request compaction as soon as the element
+// cap is exceeded instead of waiting for the next tick.
+var compactNow = make(chan struct{}, 1)
+
+// runCompactor periodically merges old elements into a single flattened
+// snapshot element and enforces MaxElements until ctx is done. When the
+// canvas changes, clients are sent the new snapshot and the IDs of the
+// elements it replaces rather than the whole canvas.
+func runCompactor(ctx context.Context, hub *sse.Hub) {
+	interval := settings.CompactInterval
+	if interval <= 0 {
+		interval = DefaultSettings().CompactInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-compactNow:
+		}
+
+		snapshot, removed, err := compactCanvas(time.Now())
+		if err != nil {
+			slog.Error("compacting canvas", "experiment", "canvas-draw-sync", "err", err)
+			continue
+		}
+		if removed == nil {
+			continue
+		}
+
+		var builder strings.Builder
+		if err := experiments.CanvasCompactedSSE(snapshot, removed).Render(context.Background(), &builder); err != nil {
+			slog.Error("rendering compacted canvas", "experiment", "canvas-draw-sync", "err", err)
+			continue
+		}
+		hub.Broadcast(sse.Event{
+			Name:  "canvas-compacted",
+			Data:  builder.String(),
+			Topic: Topic,
+		})
+	}
+}
+
+// requestCompaction signals the compactor without blocking.
+func requestCompaction() {
+	select {
+	case compactNow <- struct{}{}:
+	default:
+	}
+}
+
+// compactCanvas flattens eligible elements and returns the new snapshot and
+// the IDs of the elements it replaced, or nil IDs if the canvas didn't
+// change. Rasterizing happens outside the lock; if the canvas was cleared
+// or compacted meanwhile the result is discarded.
+func compactCanvas(now time.Time) (experiments.DrawingElement, []string, error) {
+	state, _ := snapshotCanvas()
+	count := eligibleForCompaction(state.Elements, now)
+	if count == 0 {
+		return experiments.DrawingElement{}, nil, nil
+	}
+
+	flattened := state.Elements[:count]
+	snapshot, err := flattenElements(flattened, state.Width, state.Height)
+	if err != nil {
+		return experiments.DrawingElement{}, nil, err
+	}
+
+	canvasMutex.Lock()
+	defer canvasMutex.Unlock()
+
+	if len(canvas.Elements) < count || canvas.Elements[count-1].ID != flattened[count-1].ID {
+		return experiments.DrawingElement{}, nil, nil
+	}
+	removed := make([]string, count)
+	for i, element := range flattened {
+		removed[i] = element.ID
+	}
+	elements := make([]experiments.DrawingElement, 0, len(canvas.Elements)-count+1)
+	elements = append(elements, snapshot)
+	elements = append(elements, canvas.Elements[count:]...)
+	canvas.Elements = elements
+	canvasVersion++
+	mutations.Inc("compact")
+	return snapshot, removed, nil
+}
+
+// eligibleForCompaction returns how many leading elements should be merged.
+// Elements are stored in creation order, so the eligible ones are always a
+// prefix of the slice. An existing snapshot is always re-merged.
+func eligibleForCompaction(elements []experiments.DrawingElement, now time.Time) int {
+	count := 0
+	if settings.CompactAfter > 0 {
+		cutoff := now.Add(-settings.CompactAfter)
+		for count < len(elements) && !elements[count].Created.After(cutoff) {
+			count++
+		}
+	}
+	if settings.MaxElements > 0 && len(elements) > settings.MaxElements {
+		// Leave headroom so we don't compact again on the very next stroke.
+		if overflow := len(elements) - settings.MaxElements/2; overflow > count {
+			count = overflow
+		}
+	}
+
+	merged := count
+	if count > 0 && elements[0].Type == "snapshot" {
+		merged--
+	}
+	if merged < minCompactBatch && !(settings.MaxElements > 0 && len(elements) > settings.MaxElements) {
+		return 0
+	}
+	return count
+}
+
+// flattenElements rasterizes elements into a transparent snapshot. The
+// snapshot takes the creation time of the newest element it contains so it
+// stays ordered before everything that remains.
+func flattenElements(elements []experiments.DrawingElement, width, height int) (experiments.DrawingElement, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	p := &painter{img: img}
+	for _, element := range elements {
+		p.element(element, 1)
+	}
+
+	data, err := encodePNG(img)
+	if err != nil {
+		return experiments.DrawingElement{}, err
+	}
+
+	created := elements[len(elements)-1].Created
+	return experiments.DrawingElement{
+		ID:   fmt.Sprintf("snapshot-%d", time.Now().UnixNano()),
+		Type: "snapshot",
+		Data: fmt.Sprintf(`x="0" y="0" width="%d" height="%d" href="data:image/png;base64,%s"`,
+			width, height, base64.StdEncoding.EncodeToString(data)),
+		User:    "server",
+		Created: created,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package canvasdrawsync
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic canvas updates are broadcast on.
+const Topic = "canvas-draw-sync"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New configures the canvas with s and returns it as an experiment.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "canvas-draw-sync",
+		Name:        "Canvas",
+		Description: "Collaborative real-time canvas where multiple users can draw, sketch, and create together using pure hypermedia",
+		Thumbnail:   "/experiments/canvas-draw-sync/thumbnail.png",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", CanvasDrawSyncHandler(e.hub))
+	g.POST("/draw", DrawHandler(e.hub))
+	g.POST("/clear", ClearCanvasHandler(e.hub))
+	g.POST("/restore", RestoreCanvasHandler(e.hub))
+	g.POST("/clear-policy", ClearPolicyHandler(e.hub))
+	g.POST("/clear/claim", ClaimCanvasHandler(e.hub))
+	g.POST("/clear/release", ReleaseCanvasHandler(e.hub))
+	g.GET("/export.svg", ExportSVGHandler())
+	g.POST("/import", ImportSVGHandler(e.hub))
+	g.GET("/export.png", ExportPNGHandler())
+	g.GET("/thumbnail.png", ThumbnailHandler())
+	g.GET("/og.png", OGImageHandler())
+	g.GET("/replay", CanvasReplayHandler(e.hub))
+	g.GET("/replay/player", ReplayPlayerHandler())
+	g.GET("/replay/stream", ReplayStreamHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the canvas can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, canvasMutex.TryRLock, canvasMutex.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	go runCompactor(ctx, e.hub)
+	return nil
+}
+
+// Stop cancels any pending soft-clear restore window.
+func (e *Experiment) Stop(ctx context.Context) error {
+	clearState.mu.Lock()
+	defer clearState.mu.Unlock()
+	if clearState.restoreTimer != nil {
+		clearState.restoreTimer.Stop()
+	}
+	return nil
+}
@@ -1,14 +1,21 @@
 package canvasdrawsync
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/metrics"
 	"hypermedia-sync/internal/sse"
 	"hypermedia-sync/internal/templates/experiments"
+	"hypermedia-sync/internal/templates/layout"
+	"hypermedia-sync/internal/tracing"
 
 	"github.com/labstack/echo/v4"
 )
@@ -16,14 +23,99 @@ import (
 var (
 	canvas = experiments.CanvasState{
 		Elements: []experiments.DrawingElement{},
-		Width:    1200,
-		Height:   800,
+		Width:    DefaultSettings().Width,
+		Height:   DefaultSettings().Height,
 	}
 	canvasMutex sync.RWMutex
+	// canvasVersion increments on every mutation so rendered images can be cached
+	canvasVersion uint64
+
+	mutations = metrics.NewCounter("canvas_mutations_total", "Canvas changes, by kind.", "kind")
 )
 
+// brushSizePattern matches the toolbar's brush sizes, 1 to 20.
+var brushSizePattern = regexp.MustCompile(`^(?:[1-9]|1[0-9]|20)$`)
+
+// snapshotCanvas returns a copy of the canvas that is safe to render without
+// holding the lock, along with its version.
+func snapshotCanvas() (experiments.CanvasState, uint64) {
+	canvasMutex.RLock()
+	defer canvasMutex.RUnlock()
+
+	return experiments.CanvasState{
+		Elements: append([]experiments.DrawingElement(nil), canvas.Elements...),
+		Width:    canvas.Width,
+		Height:   canvas.Height,
+	}, canvasVersion
+}
+
+func newElement(elementType, data, color, brushSize, user string) experiments.DrawingElement {
+	return experiments.DrawingElement{
+		ID:        fmt.Sprintf("elem-%d-%d", time.Now().UnixNano(), rand.Intn(10000)),
+		Type:      elementType,
+		Data:      data,
+		Color:     color,
+		BrushSize: brushSize,
+		User:      user,
+		Created:   time.Now(),
+	}
+}
+
+// parseDrawing validates a drawing posted from the toolbar and rebuilds it
+// from the parsed values, the same way imported SVG elements are. Snapshots
+// are never accepted here; only the compactor creates them.
+func parseDrawing(elementType, data, color, brushSize, user string) (experiments.DrawingElement, error) {
+	if data == "" {
+		return experiments.DrawingElement{}, errors.New("missing drawing data")
+	}
+	if len(data) > maxPathDataLength {
+		return experiments.DrawingElement{}, errors.New("drawing data is too long")
+	}
+	if sanitizeColor(color) == "" {
+		return experiments.DrawingElement{}, errors.New("invalid color")
+	}
+	if !brushSizePattern.MatchString(brushSize) {
+		return experiments.DrawingElement{}, errors.New("invalid brush size")
+	}
+
+	attrs := map[string]string{"fill": color, "stroke": color, "stroke-width": brushSize}
+	raw := experiments.DrawingElement{Data: data}
+	var element experiments.DrawingElement
+	var ok bool
+	switch elementType {
+	case "path":
+		attrs["d"] = data
+		element, ok = importPath(attrs, user)
+	case "rect":
+		for _, name := range []string{"x", "y", "width", "height"} {
+			attrs[name] = raw.Attr(name)
+		}
+		element, ok = importRect(attrs, user)
+	case "circle":
+		for _, name := range []string{"cx", "cy", "r"} {
+			attrs[name] = raw.Attr(name)
+		}
+		element, ok = importCircle(attrs, user)
+	case "text":
+		if utf8.RuneCountInString(raw.Attr("text")) > maxTextLength {
+			return experiments.DrawingElement{}, fmt.Errorf("text is limited to %d characters", maxTextLength)
+		}
+		text := &svgText{attrs: map[string]string{"x": raw.Attr("x"), "y": raw.Attr("y"), "fill": color}}
+		text.content.WriteString(raw.Attr("text"))
+		element, ok = importText(text, user)
+	default:
+		return experiments.DrawingElement{}, errors.New("unknown drawing type")
+	}
+	if !ok {
+		return experiments.DrawingElement{}, errors.New("invalid drawing data")
+	}
+	return element, nil
+}
+
 func CanvasDrawSyncHandler(hub *sse.Hub) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		clear := clearControls(c, hub)
+
 		canvasMutex.RLock()
 		defer canvasMutex.RUnlock()
 
@@ -35,6 +127,9 @@ func CanvasDrawSyncHandler(hub *sse.Hub) echo.HandlerFunc {
 			Canvas:       canvas,
 			OriginatorID: originatorID,
 			OnlineCount:  onlineCount,
+			OGImage:      fmt.Sprintf("%s/experiments/canvas-draw-sync/og.png?v=%d", layout.SiteURL, canvasVersion),
+			Clear:        clear,
+			Banner:       experiment.BannerOf(c),
 		}
 
 		if c.Request().Header.Get("HX-Request") == "true" {
@@ -49,90 +144,53 @@ func CanvasDrawSyncHandler(hub *sse.Hub) echo.HandlerFunc {
 
 func DrawHandler(hub *sse.Hub) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		elementType := c.FormValue("type")
-		elementData := c.FormValue("data")
-		color := c.FormValue("color")
-		brushSize := c.FormValue("brushSize")
 		originatorID := c.Request().Header.Get("X-Originator-ID")
 
-		if elementType == "" || elementData == "" {
-			return c.String(400, "Missing drawing data")
+		element, err := parseDrawing(c.FormValue("type"), c.FormValue("data"), c.FormValue("color"), c.FormValue("brushSize"), originatorID)
+		if err != nil {
+			return c.String(400, "Drawing not saved: "+err.Error())
 		}
-
-		element := experiments.DrawingElement{
-			ID:        fmt.Sprintf("elem-%d-%d", time.Now().UnixNano(), rand.Intn(10000)),
-			Type:      elementType,
-			Data:      elementData,
-			Color:     color,
-			BrushSize: brushSize,
-			User:      originatorID,
-			Created:   time.Now(),
+		if element.Type == "path" {
+			element.Data = simplifyPathData(element.Data, settings.SimplifyTolerance)
 		}
 
+		ctx, span := tracing.Start(c.Request().Context(), "canvas.draw",
+			tracing.WithAttributes("canvas.element.type", element.Type, "canvas.element.id", element.ID))
+		defer span.End()
+
 		canvasMutex.Lock()
 		canvas.Elements = append(canvas.Elements, element)
+		canvasVersion++
+		mutations.Inc("draw")
+		recordHistory(historyAdd, element.Created, element)
+		overLimit := settings.MaxElements > 0 && len(canvas.Elements) > settings.MaxElements
 		canvasMutex.Unlock()
 
+		if overLimit {
+			requestCompaction()
+		}
+
 		var sseBuilder strings.Builder
 		sseComponent := experiments.DrawingElementSSE(element)
-		err := sseComponent.Render(c.Request().Context(), &sseBuilder)
+		err = tracing.Render(ctx, "DrawingElementSSE", sseComponent, &sseBuilder)
 		if err != nil {
 			return c.String(500, "Error generating SSE HTML")
 		}
 
-		hub.Broadcast(sse.Event{
+		hub.BroadcastContext(ctx, sse.Event{
 			Name:      "canvas-element-added",
 			Data:      sseBuilder.String(),
 			ExcludeID: originatorID,
+			Topic:     Topic,
 		})
 
 		var originatorBuilder strings.Builder
 		originatorComponent := experiments.DrawingElementSSE(element)
-		err = originatorComponent.Render(c.Request().Context(), &originatorBuilder)
+		err = tracing.Render(ctx, "DrawingElementSSE", originatorComponent, &originatorBuilder)
 		if err != nil {
 			return c.String(500, "Error generating originator HTML")
 		}
-		
+
 		return c.HTML(200, originatorBuilder.String())
 	}
 }
-
-func ClearCanvasHandler(hub *sse.Hub) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		originatorID := c.Request().Header.Get("X-Originator-ID")
-
-		canvasMutex.Lock()
-		canvas.Elements = []experiments.DrawingElement{}
-		canvasMutex.Unlock()
-
-		var sseClearBuilder strings.Builder
-		sseClearComponent := experiments.CanvasSVG(canvas)
-		err := sseClearComponent.Render(c.Request().Context(), &sseClearBuilder)
-		if err != nil {
-			return c.String(500, "Error generating clear canvas SSE HTML")
-		}
-
-		hub.Broadcast(sse.Event{
-			Name:      "canvas-cleared",
-			Data:      sseClearBuilder.String(),
-			ExcludeID: originatorID,
-		})
-
-		canvasMutex.RLock()
-		data := experiments.CanvasDrawSyncPageData{
-			Canvas:       canvas,
-			OriginatorID: originatorID,
-			OnlineCount:  0,
-		}
-		canvasMutex.RUnlock()
-
-		var builder strings.Builder
-		component := experiments.CanvasSVG(data.Canvas)
-		err = component.Render(c.Request().Context(), &builder)
-		if err != nil {
-			return c.String(500, "Error generating canvas HTML")
-		}
-
-		return c.HTML(200, builder.String())
-	}
-}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package canvasdrawsync
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"sync"
+
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	thumbnailWidth = 400
+	ogImageWidth   = 1200
+	ogImageHeight  = 630
+	ogImagePadding = 40
+)
+
+// Matches --color-secondary-900 so shared links look like the site.
+var ogBackground = color.RGBA{0x0f, 0x17, 0x2a, 0xff}
+
+// renderCache keeps the encoded images for the current canvas version, by
+// scale, so repeated exports, thumbnail, and OpenGraph fetches don't
+// re-rasterize an unchanged canvas.
+type renderCache struct {
+	mu      sync.Mutex
+	version uint64
+	data    map[float64][]byte
+}
+
+func (rc *renderCache) get(version uint64, scale float64, render func() ([]byte, error)) ([]byte, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if data, ok := rc.data[scale]; ok && rc.version == version {
+		return data, nil
+	}
+	data, err := render()
+	if err != nil {
+		return nil, err
+	}
+	if rc.data == nil || rc.version != version {
+		rc.version = version
+		rc.data = make(map[float64][]byte)
+	}
+	rc.data[scale] = data
+	return data, nil
+}
+
+var (
+	exportCache    renderCache
+	thumbnailCache renderCache
+	ogImageCache   renderCache
+)
+
+func ExportPNGHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		scale := 1.0
+		if value := c.QueryParam("scale"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < minRasterScale || parsed > maxRasterScale {
+				return c.String(400, fmt.Sprintf("Scale must be between %g and %g", minRasterScale, maxRasterScale))
+			}
+			// Rounding to tenths bounds how many sizes get cached.
+			scale = math.Round(parsed*10) / 10
+		}
+
+		state, version := snapshotCanvas()
+		data, err := exportCache.get(version, scale, func() ([]byte, error) {
+			return encodePNG(RasterizeCanvas(state, scale))
+		})
+		if err != nil {
+			return c.String(500, "Error rendering canvas PNG")
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="canvas.png"`)
+		return c.Blob(200, "image/png", data)
+	}
+}
+
+func ThumbnailHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		state, version := snapshotCanvas()
+		data, err := thumbnailCache.get(version, 0, func() ([]byte, error) {
+			return encodePNG(RasterizeCanvas(state, float64(thumbnailWidth)/float64(state.Width)))
+		})
+		if err != nil {
+			return c.String(500, "Error rendering canvas thumbnail")
+		}
+
+		c.Response().Header().Set("Cache-Control", "public, max-age=60")
+		return c.Blob(200, "image/png", data)
+	}
+}
+
+func OGImageHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		state, version := snapshotCanvas()
+		data, err := ogImageCache.get(version, 0, func() ([]byte, error) {
+			return encodePNG(renderOGImage(state))
+		})
+		if err != nil {
+			return c.String(500, "Error rendering canvas preview")
+		}
+
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.Blob(200, "image/png", data)
+	}
+}
+
+// renderOGImage letterboxes the canvas into the 1200x630 frame that
+// OpenGraph and Twitter cards expect.
+func renderOGImage(state experiments.CanvasState) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, ogImageWidth, ogImageHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(ogBackground), image.Point{}, draw.Src)
+
+	scale := math.Min(
+		float64(ogImageWidth-2*ogImagePadding)/float64(state.Width),
+		float64(ogImageHeight-2*ogImagePadding)/float64(state.Height),
+	)
+	canvasImg := RasterizeCanvas(state, scale)
+	size := canvasImg.Bounds().Size()
+	offset := image.Pt((ogImageWidth-size.X)/2, (ogImageHeight-size.Y)/2)
+
+	draw.Draw(img, canvasImg.Bounds().Add(offset), canvasImg, image.Point{}, draw.Src)
+	return img
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
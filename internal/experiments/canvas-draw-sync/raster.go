@@ -0,0 +1,611 @@
+package canvasdrawsync
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+
+	"hypermedia-sync/internal/templates/experiments"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+const (
+	minRasterScale = 0.1
+	maxRasterScale = 2.0
+
+	// Matches the attributes DrawingElementSVG renders with.
+	shapeOpacity = 0.7
+	textFontSize = 16.0
+
+	// Segments used to approximate curves, arcs, and round caps.
+	curveSteps  = 16
+	circleSteps = 48
+)
+
+type point struct {
+	X, Y float64
+}
+
+// RasterizeCanvas renders the canvas to an RGBA image at the given scale,
+// mirroring what DrawingElementSVG produces in the browser: round-capped
+// strokes for paths, translucent fills for rects and circles, text, and
+// flattened snapshots.
+func RasterizeCanvas(state experiments.CanvasState, scale float64) *image.RGBA {
+	scale = clampScale(scale)
+	width := int(math.Ceil(float64(state.Width) * scale))
+	height := int(math.Ceil(float64(state.Height) * scale))
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	p := &painter{img: img}
+	for _, element := range state.Elements {
+		p.element(element, scale)
+	}
+	return img
+}
+
+// painter fills shapes onto img through one rasterizer, which is reset to
+// each shape's bounding box so a small stroke costs a small mask rather
+// than one the size of the image.
+type painter struct {
+	img *image.RGBA
+	r   vector.Rasterizer
+	// clip is the current shape's bounding box in image coordinates, where
+	// the rasterizer's origin lies.
+	clip image.Rectangle
+}
+
+// begin resets the rasterizer to the box around points, grown by pad. It
+// returns false if the box is off the image.
+func (p *painter) begin(points []point, pad float64) bool {
+	if len(points) == 0 {
+		return false
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, pt := range points {
+		minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+		maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+	}
+	box := image.Rect(
+		int(math.Floor(math.Max(minX-pad, -1))), int(math.Floor(math.Max(minY-pad, -1))),
+		int(math.Ceil(math.Min(maxX+pad, float64(p.img.Rect.Max.X)+1))), int(math.Ceil(math.Min(maxY+pad, float64(p.img.Rect.Max.Y)+1))),
+	)
+	p.clip = box.Intersect(p.img.Bounds())
+	if p.clip.Empty() {
+		return false
+	}
+	p.r.Reset(p.clip.Dx(), p.clip.Dy())
+	return true
+}
+
+// fill draws the polygons added since begin.
+func (p *painter) fill(src image.Image) {
+	p.r.Draw(p.img, p.clip, src, image.Point{})
+}
+
+func clampScale(scale float64) float64 {
+	if math.IsNaN(scale) || scale <= 0 {
+		return 1
+	}
+	return math.Max(minRasterScale, math.Min(maxRasterScale, scale))
+}
+
+func (p *painter) element(element experiments.DrawingElement, scale float64) {
+	switch element.Type {
+	case "path":
+		width, err := strconv.ParseFloat(element.BrushSize, 64)
+		if err != nil || width <= 0 {
+			width = 1
+		}
+		src := image.NewUniform(parseColor(element.Color, 1))
+		for _, polyline := range parsePathData(element.Data) {
+			p.stroke(src, scalePoints(polyline, scale), width*scale)
+		}
+
+	case "rect":
+		x, _ := parseNumber(element.Attr("x"))
+		y, _ := parseNumber(element.Attr("y"))
+		w, okW := parseNumber(element.Attr("width"))
+		h, okH := parseNumber(element.Attr("height"))
+		if !okW || !okH || w <= 0 || h <= 0 {
+			return
+		}
+		p.fillPolygon(image.NewUniform(parseColor(element.Color, shapeOpacity)), scalePoints([]point{
+			{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h},
+		}, scale))
+
+	case "circle":
+		cx, _ := parseNumber(element.Attr("cx"))
+		cy, _ := parseNumber(element.Attr("cy"))
+		r, ok := parseNumber(element.Attr("r"))
+		if !ok || r <= 0 {
+			return
+		}
+		p.fillPolygon(image.NewUniform(parseColor(element.Color, shapeOpacity)),
+			circlePoints(point{cx * scale, cy * scale}, r*scale))
+
+	case "text":
+		x, _ := parseNumber(element.Attr("x"))
+		y, _ := parseNumber(element.Attr("y"))
+		drawText(p.img, element.Attr("text"), point{x * scale, y * scale}, textFontSize*scale, parseColor(element.Color, 1))
+
+	case "snapshot":
+		snapshot, err := decodeDataURI(element.Attr("href"))
+		if err != nil {
+			return
+		}
+		x, _ := parseNumber(element.Attr("x"))
+		y, _ := parseNumber(element.Attr("y"))
+		w, _ := parseNumber(element.Attr("width"))
+		h, _ := parseNumber(element.Attr("height"))
+		dst := image.Rect(int(x*scale), int(y*scale), int(math.Ceil((x+w)*scale)), int(math.Ceil((y+h)*scale)))
+		xdraw.ApproxBiLinear.Scale(p.img, dst, snapshot, snapshot.Bounds(), xdraw.Over, nil)
+	}
+}
+
+func decodeDataURI(uri string) (image.Image, error) {
+	const prefix = "data:image/png;base64,"
+	if !strings.HasPrefix(uri, prefix) {
+		return nil, errors.New("unsupported image data")
+	}
+	data, err := base64.StdEncoding.DecodeString(uri[len(prefix):])
+	if err != nil {
+		return nil, err
+	}
+	// Snapshots are canvas-sized; check before decoding so an oversized
+	// image can't be expanded in memory.
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > settings.Width || config.Height > settings.Height {
+		return nil, fmt.Errorf("image is %dx%d, larger than the canvas", config.Width, config.Height)
+	}
+	return png.Decode(bytes.NewReader(data))
+}
+
+func scalePoints(points []point, scale float64) []point {
+	scaled := make([]point, len(points))
+	for i, p := range points {
+		scaled[i] = point{p.X * scale, p.Y * scale}
+	}
+	return scaled
+}
+
+// stroke draws a stroke with round caps and joins by filling a rectangle
+// per segment and a disc per vertex. All polygons are wound the same way so
+// overlapping coverage accumulates instead of cancelling out.
+func (p *painter) stroke(src image.Image, points []point, width float64) {
+	radius := width / 2
+	if !p.begin(points, radius) {
+		return
+	}
+
+	for i, pt := range points {
+		p.addPolygon(circlePoints(pt, radius))
+		if i == 0 {
+			continue
+		}
+		prev := points[i-1]
+		dx, dy := pt.X-prev.X, pt.Y-prev.Y
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -dy/length*radius, dx/length*radius
+		p.addPolygon([]point{
+			{prev.X + nx, prev.Y + ny},
+			{pt.X + nx, pt.Y + ny},
+			{pt.X - nx, pt.Y - ny},
+			{prev.X - nx, prev.Y - ny},
+		})
+	}
+	p.fill(src)
+}
+
+func (p *painter) fillPolygon(src image.Image, points []point) {
+	if !p.begin(points, 0) {
+		return
+	}
+	p.addPolygon(points)
+	p.fill(src)
+}
+
+// addPolygon adds points, in image coordinates, to the current shape.
+func (p *painter) addPolygon(points []point) {
+	if len(points) < 3 {
+		return
+	}
+	// Normalize to a single winding direction.
+	area := 0.0
+	for i, pt := range points {
+		q := points[(i+1)%len(points)]
+		area += pt.X*q.Y - q.X*pt.Y
+	}
+	if area < 0 {
+		reversed := make([]point, len(points))
+		for i, pt := range points {
+			reversed[len(points)-1-i] = pt
+		}
+		points = reversed
+	}
+
+	dx, dy := float64(p.clip.Min.X), float64(p.clip.Min.Y)
+	p.r.MoveTo(float32(points[0].X-dx), float32(points[0].Y-dy))
+	for _, pt := range points[1:] {
+		p.r.LineTo(float32(pt.X-dx), float32(pt.Y-dy))
+	}
+	p.r.ClosePath()
+}
+
+func circlePoints(center point, radius float64) []point {
+	points := make([]point, circleSteps)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / circleSteps
+		points[i] = point{center.X + radius*math.Cos(angle), center.Y + radius*math.Sin(angle)}
+	}
+	return points
+}
+
+// drawText renders with the built-in 7x13 bitmap face and scales the glyph
+// mask to the requested font size, using y as the baseline like SVG does.
+func drawText(img *image.RGBA, text string, baseline point, size float64, c color.Color) {
+	if text == "" || size <= 0 {
+		return
+	}
+	face := basicfont.Face7x13
+	metrics := face.Metrics()
+	ascent, descent := metrics.Ascent.Ceil(), metrics.Descent.Ceil()
+	advance := font.MeasureString(face, text).Ceil()
+
+	mask := image.NewAlpha(image.Rect(0, 0, advance, ascent+descent))
+	drawer := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, ascent)}
+	drawer.DrawString(text)
+
+	ratio := size / float64(face.Height)
+	dst := image.Rect(
+		int(baseline.X),
+		int(baseline.Y-float64(ascent)*ratio),
+		int(baseline.X+float64(advance)*ratio),
+		int(baseline.Y+float64(descent)*ratio),
+	)
+	scaled := image.NewAlpha(dst)
+	xdraw.ApproxBiLinear.Scale(scaled, dst, mask, mask.Bounds(), xdraw.Src, nil)
+	draw.DrawMask(img, dst, image.NewUniform(c), image.Point{}, scaled, dst.Min, draw.Over)
+}
+
+// parsePathData flattens SVG path data into polylines, one per subpath.
+// Curves are sampled into line segments; arcs are approximated by a straight
+// line to their end point.
+func parsePathData(d string) [][]point {
+	tokens := tokenizePath(d)
+	var (
+		polylines [][]point
+		current   []point
+		pos       point
+		start     point
+		lastCtrl  point
+		command   byte
+	)
+
+	flush := func() {
+		if len(current) > 0 {
+			polylines = append(polylines, current)
+		}
+		current = nil
+	}
+	numbers := func(n int) ([]float64, bool) {
+		if len(tokens) < n {
+			return nil, false
+		}
+		values := make([]float64, n)
+		for i := 0; i < n; i++ {
+			v, err := strconv.ParseFloat(tokens[i], 64)
+			if err != nil {
+				return nil, false
+			}
+			values[i] = v
+		}
+		tokens = tokens[n:]
+		return values, true
+	}
+	lineTo := func(p point) {
+		if len(current) == 0 {
+			current = append(current, pos)
+		}
+		current = append(current, p)
+		pos = p
+	}
+
+	for len(tokens) > 0 {
+		if isPathCommand(tokens[0]) {
+			command = tokens[0][0]
+			tokens = tokens[1:]
+		} else if command == 0 {
+			return polylines
+		}
+
+		relative := command >= 'a' && command <= 'z'
+		offset := func(p point) point {
+			if relative {
+				return point{pos.X + p.X, pos.Y + p.Y}
+			}
+			return p
+		}
+
+		switch command | 0x20 {
+		case 'm':
+			v, ok := numbers(2)
+			if !ok {
+				flush()
+				return polylines
+			}
+			flush()
+			pos = offset(point{v[0], v[1]})
+			start = pos
+			current = []point{pos}
+			// Subsequent pairs after a moveto are implicit linetos.
+			if relative {
+				command = 'l'
+			} else {
+				command = 'L'
+			}
+		case 'l':
+			v, ok := numbers(2)
+			if !ok {
+				flush()
+				return polylines
+			}
+			lineTo(offset(point{v[0], v[1]}))
+		case 'h':
+			v, ok := numbers(1)
+			if !ok {
+				flush()
+				return polylines
+			}
+			x := v[0]
+			if relative {
+				x += pos.X
+			}
+			lineTo(point{x, pos.Y})
+		case 'v':
+			v, ok := numbers(1)
+			if !ok {
+				flush()
+				return polylines
+			}
+			y := v[0]
+			if relative {
+				y += pos.Y
+			}
+			lineTo(point{pos.X, y})
+		case 'c', 's':
+			var c1, c2, end point
+			if command|0x20 == 'c' {
+				v, ok := numbers(6)
+				if !ok {
+					flush()
+					return polylines
+				}
+				c1, c2, end = offset(point{v[0], v[1]}), offset(point{v[2], v[3]}), offset(point{v[4], v[5]})
+			} else {
+				v, ok := numbers(4)
+				if !ok {
+					flush()
+					return polylines
+				}
+				c1 = point{2*pos.X - lastCtrl.X, 2*pos.Y - lastCtrl.Y}
+				c2, end = offset(point{v[0], v[1]}), offset(point{v[2], v[3]})
+			}
+			from := pos
+			for i := 1; i <= curveSteps; i++ {
+				t := float64(i) / curveSteps
+				mt := 1 - t
+				lineTo(point{
+					mt*mt*mt*from.X + 3*mt*mt*t*c1.X + 3*mt*t*t*c2.X + t*t*t*end.X,
+					mt*mt*mt*from.Y + 3*mt*mt*t*c1.Y + 3*mt*t*t*c2.Y + t*t*t*end.Y,
+				})
+			}
+			lastCtrl = c2
+			continue
+		case 'q', 't':
+			var ctrl, end point
+			if command|0x20 == 'q' {
+				v, ok := numbers(4)
+				if !ok {
+					flush()
+					return polylines
+				}
+				ctrl, end = offset(point{v[0], v[1]}), offset(point{v[2], v[3]})
+			} else {
+				v, ok := numbers(2)
+				if !ok {
+					flush()
+					return polylines
+				}
+				ctrl = point{2*pos.X - lastCtrl.X, 2*pos.Y - lastCtrl.Y}
+				end = offset(point{v[0], v[1]})
+			}
+			from := pos
+			for i := 1; i <= curveSteps; i++ {
+				t := float64(i) / curveSteps
+				mt := 1 - t
+				lineTo(point{
+					mt*mt*from.X + 2*mt*t*ctrl.X + t*t*end.X,
+					mt*mt*from.Y + 2*mt*t*ctrl.Y + t*t*end.Y,
+				})
+			}
+			lastCtrl = ctrl
+			continue
+		case 'a':
+			v, ok := numbers(7)
+			if !ok {
+				flush()
+				return polylines
+			}
+			lineTo(offset(point{v[5], v[6]}))
+		case 'z':
+			lineTo(start)
+			flush()
+			command = 0
+		default:
+			return polylines
+		}
+		lastCtrl = pos
+	}
+
+	flush()
+	return polylines
+}
+
+func isPathCommand(token string) bool {
+	return len(token) == 1 && strings.ContainsRune("MmLlHhVvCcSsQqTtAaZz", rune(token[0]))
+}
+
+// tokenizePath splits path data into single-letter commands and numbers,
+// handling separators like "M10,20L30-5" and "1.5.5".
+func tokenizePath(d string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		sawDot  bool
+		sawExp  bool
+	)
+	emit := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+		sawDot, sawExp = false, false
+	}
+
+	for i := 0; i < len(d); i++ {
+		ch := d[i]
+		switch {
+		case isPathCommand(string(ch)):
+			emit()
+			tokens = append(tokens, string(ch))
+		case ch == ' ' || ch == ',' || ch == '\t' || ch == '\n' || ch == '\r':
+			emit()
+		case ch == '-' || ch == '+':
+			prev := byte(0)
+			if current.Len() > 0 {
+				prev = d[i-1]
+			}
+			if prev != 'e' && prev != 'E' {
+				emit()
+			}
+			current.WriteByte(ch)
+		case ch == '.':
+			if sawDot || sawExp {
+				emit()
+			}
+			sawDot = true
+			current.WriteByte(ch)
+		case ch == 'e' || ch == 'E':
+			sawExp = true
+			current.WriteByte(ch)
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	emit()
+	return tokens
+}
+
+var namedColors = map[string]color.RGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"lime":    {0, 255, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"orange":  {255, 165, 0, 255},
+	"purple":  {128, 0, 128, 255},
+	"pink":    {255, 192, 203, 255},
+	"brown":   {165, 42, 42, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"cyan":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"navy":    {0, 0, 128, 255},
+	"teal":    {0, 128, 128, 255},
+}
+
+// parseColor understands the color forms the canvas accepts (hex, rgb(),
+// rgba(), and common names) and falls back to black. The opacity multiplies
+// any alpha the color already carries.
+func parseColor(value string, opacity float64) color.NRGBA {
+	value = strings.ToLower(strings.TrimSpace(value))
+	c := color.NRGBA{0, 0, 0, 255}
+
+	switch {
+	case strings.HasPrefix(value, "#"):
+		hex := value[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			var expanded strings.Builder
+			for _, ch := range hex {
+				expanded.WriteRune(ch)
+				expanded.WriteRune(ch)
+			}
+			hex = expanded.String()
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		if n, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 8 {
+			c = color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}
+		}
+	case strings.HasPrefix(value, "rgb"):
+		inner := value[strings.IndexByte(value, '(')+1:]
+		inner = strings.TrimSuffix(inner, ")")
+		parts := strings.Split(inner, ",")
+		if len(parts) >= 3 {
+			channels := [4]float64{0, 0, 0, 1}
+			for i := 0; i < len(parts) && i < 4; i++ {
+				part := strings.TrimSpace(parts[i])
+				v, _ := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+				if strings.HasSuffix(part, "%") {
+					v /= 100
+					if i < 3 {
+						v *= 255
+					}
+				}
+				channels[i] = v
+			}
+			c = color.NRGBA{
+				clampChannel(channels[0]),
+				clampChannel(channels[1]),
+				clampChannel(channels[2]),
+				clampChannel(channels[3] * 255),
+			}
+		}
+	default:
+		if named, ok := namedColors[value]; ok {
+			c = color.NRGBA(named)
+		}
+	}
+
+	c.A = clampChannel(float64(c.A) * opacity)
+	return c
+}
+
+func clampChannel(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
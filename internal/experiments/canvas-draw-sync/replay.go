@@ -0,0 +1,265 @@
+package canvasdrawsync
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxHistoryEvents bounds memory; the oldest events are dropped first.
+	maxHistoryEvents = 50000
+	// historySlack is how far history may grow past maxHistoryEvents before
+	// it is trimmed, so the copy happens once per batch rather than on
+	// every draw.
+	historySlack = maxHistoryEvents / 10
+	// maxReplayGap keeps idle stretches from stalling playback.
+	maxReplayGap = 1500 * time.Millisecond
+)
+
+var replaySpeeds = []float64{0.5, 1, 2, 5, 10, 25, 100}
+
+type historyKind int
+
+const (
+	historyAdd historyKind = iota
+	historyClear
+)
+
+// historyEvent is an append-only record of a canvas mutation. Unlike
+// canvas.Elements it is never cleared or compacted, so replays can show
+// everything that happened, including what was wiped by "Clear Canvas".
+type historyEvent struct {
+	Kind    historyKind
+	Element experiments.DrawingElement
+	At      time.Time
+}
+
+// history is guarded by canvasMutex so it stays in the same order as the
+// mutations applied to the canvas.
+var history []historyEvent
+
+// recordHistory must be called with canvasMutex held for writing. Events are
+// stamped with at rather than the element's creation time so that restored
+// elements replay when they reappeared.
+func recordHistory(kind historyKind, at time.Time, elements ...experiments.DrawingElement) {
+	if kind == historyClear {
+		history = append(history, historyEvent{Kind: historyClear, At: at})
+	}
+	for _, element := range elements {
+		history = append(history, historyEvent{Kind: kind, Element: element, At: at})
+	}
+	if overflow := len(history) - maxHistoryEvents; overflow > historySlack {
+		history = append([]historyEvent(nil), history[overflow:]...)
+	}
+}
+
+func snapshotHistory() []historyEvent {
+	canvasMutex.RLock()
+	defer canvasMutex.RUnlock()
+	return append([]historyEvent(nil), history...)
+}
+
+func historyDuration(events []historyEvent) time.Duration {
+	if len(events) < 2 {
+		return 0
+	}
+	return events[len(events)-1].At.Sub(events[0].At)
+}
+
+// replayState folds all events up to offset into the canvas elements that
+// were visible at that moment, returning them with the remaining events.
+func replayState(events []historyEvent, offset time.Duration) ([]experiments.DrawingElement, []historyEvent) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	cutoff := events[0].At.Add(offset)
+	var elements []experiments.DrawingElement
+	for i, event := range events {
+		if event.At.After(cutoff) {
+			return elements, events[i:]
+		}
+		switch event.Kind {
+		case historyAdd:
+			elements = append(elements, event.Element)
+		case historyClear:
+			elements = nil
+		}
+	}
+	return elements, nil
+}
+
+func replayPlayerData(c echo.Context, events []historyEvent) experiments.CanvasReplayPlayerData {
+	speed := 1.0
+	if value, err := strconv.ParseFloat(c.QueryParam("speed"), 64); err == nil {
+		for _, allowed := range replaySpeeds {
+			if value == allowed {
+				speed = value
+			}
+		}
+	}
+
+	duration := historyDuration(events)
+	from := time.Duration(0)
+	if value, err := strconv.ParseInt(c.QueryParam("from"), 10, 64); err == nil && value > 0 {
+		from = min(time.Duration(value)*time.Millisecond, duration)
+	}
+
+	return experiments.CanvasReplayPlayerData{
+		Width:    canvas.Width,
+		Height:   canvas.Height,
+		Speeds:   replaySpeeds,
+		Speed:    speed,
+		From:     from,
+		Duration: duration,
+		Events:   len(events),
+	}
+}
+
+func CanvasReplayHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		data := replayPlayerData(c, snapshotHistory())
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.CanvasReplayPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.CanvasReplayPageFull(experiments.CanvasReplayPageData{
+			Player:       data,
+			OriginatorID: fmt.Sprintf("replay-%d-%d", time.Now().UnixNano(), rand.Intn(1000000)),
+			OnlineCount:  hub.GetOnlineCount(),
+		})
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// ReplayPlayerHandler re-renders the player with new speed or position.
+// Swapping it in replaces the sse-connect element, which closes the previous
+// stream and opens one that starts from the new point.
+func ReplayPlayerHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		data := replayPlayerData(c, snapshotHistory())
+		return experiments.CanvasReplayPlayer(data).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// ReplayStreamHandler streams history to a single viewer over its own SSE
+// connection. Every viewer has an independent playhead, so events are
+// written by this handler rather than broadcast, but the stream is still
+// registered with the hub so it counts against the connection limits.
+func ReplayStreamHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		events := snapshotHistory()
+		data := replayPlayerData(c, events)
+		ctx := c.Request().Context()
+		w := c.Response()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		id := fmt.Sprintf("replay-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+		conn := &sse.Connection{
+			ID:      id,
+			Writer:  w,
+			Done:    make(chan struct{}),
+			Topics:  make(map[string]bool),
+			Logger:  logging.FromContext(ctx).With("conn_id", id),
+			IP:      c.RealIP(),
+			Session: session.Get(c).ID,
+		}
+		if err := hub.Register(conn); err != nil {
+			conn.Close()
+			return sse.WriteRejection(w, err)
+		}
+		defer func() {
+			hub.Unregister(conn)
+			conn.Close()
+		}()
+
+		elements, remaining := replayState(events, data.From)
+		state := experiments.CanvasState{Elements: elements, Width: data.Width, Height: data.Height}
+
+		var builder strings.Builder
+		if err := experiments.ReplayCanvasSVG(state).Render(ctx, &builder); err != nil {
+			return err
+		}
+		if err := conn.Write("replay-reset", builder.String()); err != nil {
+			return nil
+		}
+
+		position := data.From
+		var start time.Time
+		if len(events) > 0 {
+			start = events[0].At
+		}
+		last := start.Add(position)
+
+		for _, event := range remaining {
+			gap := time.Duration(float64(event.At.Sub(last)) / data.Speed)
+			last = event.At
+			if gap > maxReplayGap {
+				gap = maxReplayGap
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-conn.Evicted():
+				return nil
+			case <-time.After(gap):
+			}
+
+			builder.Reset()
+			name := "replay-element"
+			var err error
+			switch event.Kind {
+			case historyAdd:
+				err = experiments.ReplayElementSVG(event.Element, state).Render(ctx, &builder)
+			case historyClear:
+				name = "replay-reset"
+				err = experiments.ReplayCanvasSVG(experiments.CanvasState{Width: data.Width, Height: data.Height}).Render(ctx, &builder)
+			}
+			if err != nil {
+				return err
+			}
+			if err := conn.Write(name, builder.String()); err != nil {
+				return nil
+			}
+
+			position = event.At.Sub(start)
+			builder.Reset()
+			if err := experiments.ReplayProgress(position, data.Duration).Render(ctx, &builder); err != nil {
+				return err
+			}
+			if err := conn.Write("replay-progress", builder.String()); err != nil {
+				return nil
+			}
+		}
+
+		if err := conn.Write("replay-done", "Replay complete"); err != nil {
+			return nil
+		}
+
+		// Hold the connection open; closing it would make EventSource
+		// reconnect and start the replay over.
+		select {
+		case <-ctx.Done():
+		case <-conn.Evicted():
+		}
+		return nil
+	}
+}
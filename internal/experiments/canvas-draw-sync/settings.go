@@ -0,0 +1,63 @@
+package canvasdrawsync
+
+import "time"
+
+// Settings controls the canvas size, stroke simplification, background
+// compaction, and how the canvas may be cleared.
+type Settings struct {
+	// Width and Height are the canvas dimensions in pixels.
+	Width  int
+	Height int
+
+	// SimplifyTolerance is the Ramer–Douglas–Peucker tolerance in canvas
+	// pixels applied to incoming pen strokes. Zero disables simplification.
+	SimplifyTolerance float64
+	// MaxElements caps how many elements a canvas keeps before the oldest
+	// are flattened into the snapshot. Zero disables the cap.
+	MaxElements int
+	// CompactAfter is how old an element must be before it is eligible for
+	// flattening. Zero disables age-based compaction.
+	CompactAfter time.Duration
+	// CompactInterval is how often the compactor checks the canvas.
+	CompactInterval time.Duration
+
+	// ClearPolicy is the policy a canvas starts with; its owner can change
+	// it at runtime.
+	ClearPolicy ClearPolicy
+	// VoteQuorum is the fraction of online users whose votes clear the
+	// canvas under the vote policy.
+	VoteQuorum float64
+	// VoteWindow is how long a vote to clear stays valid.
+	VoteWindow time.Duration
+	// RestoreWindow is how long a soft clear can be undone.
+	RestoreWindow time.Duration
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Width:             1200,
+		Height:            800,
+		SimplifyTolerance: 1.0,
+		MaxElements:       500,
+		CompactAfter:      10 * time.Minute,
+		CompactInterval:   30 * time.Second,
+		ClearPolicy:       ClearSoft,
+		VoteQuorum:        0.5,
+		VoteWindow:        time.Minute,
+		RestoreWindow:     30 * time.Second,
+	}
+}
+
+var settings = DefaultSettings()
+
+// configure resizes the canvas and sets its starting clear policy along
+// with the compaction and voting settings.
+func configure(s Settings) {
+	settings = s
+	canvasMutex.Lock()
+	canvas.Width, canvas.Height = s.Width, s.Height
+	canvasMutex.Unlock()
+	if s.ClearPolicy.Valid() {
+		clearState.policy = s.ClearPolicy
+	}
+}
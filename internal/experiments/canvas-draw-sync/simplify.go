@@ -0,0 +1,87 @@
+package canvasdrawsync
+
+import (
+	"math"
+	"strings"
+)
+
+// simplifyPathData reduces the number of points in freehand strokes using
+// Ramer–Douglas–Peucker. Only straight-line paths (the pen tool's output) are
+// touched; anything with curves, arcs, or closepaths is returned unchanged so
+// imported artwork keeps its exact shape.
+func simplifyPathData(d string, tolerance float64) string {
+	if tolerance <= 0 || strings.ContainsAny(d, "CcSsQqTtAaZz") {
+		return d
+	}
+
+	var b strings.Builder
+	for _, polyline := range parsePathData(d) {
+		points := simplifyPoints(polyline, tolerance)
+		for i, p := range points {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			if i == 0 {
+				b.WriteByte('M')
+			} else {
+				b.WriteByte('L')
+			}
+			b.WriteString(formatCoordinate(p.X))
+			b.WriteByte(',')
+			b.WriteString(formatCoordinate(p.Y))
+		}
+	}
+	if b.Len() == 0 {
+		return d
+	}
+	return b.String()
+}
+
+func simplifyPoints(points []point, tolerance float64) []point {
+	if len(points) < 3 {
+		return points
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	// Iterative to avoid deep recursion on very long strokes.
+	stack := [][2]int{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		span := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		first, last := span[0], span[1]
+
+		maxDistance, index := 0.0, -1
+		for i := first + 1; i < last; i++ {
+			if distance := perpendicularDistance(points[i], points[first], points[last]); distance > maxDistance {
+				maxDistance, index = distance, i
+			}
+		}
+		if index >= 0 && maxDistance > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+
+	simplified := make([]point, 0, len(points))
+	for i, p := range points {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
+
+func perpendicularDistance(p, a, b point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	return math.Abs(dy*p.X-dx*p.Y+b.X*a.Y-b.Y*a.X) / length
+}
+
+func formatCoordinate(value float64) string {
+	return formatNumber(math.Round(value*100) / 100)
+}
@@ -0,0 +1,322 @@
+package canvasdrawsync
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxImportBytes    = 1 << 20 // 1 MiB
+	maxImportElements = 2000
+	maxPathDataLength = 20000
+	maxTextLength     = 200
+)
+
+var (
+	pathDataPattern = regexp.MustCompile(`^[MmLlHhVvCcSsQqTtAaZz0-9eE.,+\-\s]+$`)
+	hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	rgbColorPattern = regexp.MustCompile(`^rgba?\(\s*[0-9.%]+\s*,\s*[0-9.%]+\s*,\s*[0-9.%]+\s*(?:,\s*[0-9.%]+\s*)?\)$`)
+	namedColor      = regexp.MustCompile(`^[a-zA-Z]{3,20}$`)
+)
+
+func ExportSVGHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		snapshot, _ := snapshotCanvas()
+
+		c.Response().Header().Set(echo.HeaderContentType, "image/svg+xml; charset=utf-8")
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="canvas.svg"`)
+		c.Response().WriteHeader(200)
+
+		if _, err := io.WriteString(c.Response().Writer, xml.Header); err != nil {
+			return err
+		}
+		return experiments.CanvasSVGDocument(snapshot).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func ImportSVGHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.String(400, "Missing SVG file")
+		}
+		if fileHeader.Size > maxImportBytes {
+			return c.String(413, "SVG file too large")
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.String(400, "Unable to read SVG file")
+		}
+		defer file.Close()
+
+		elements, err := parseSVGElements(io.LimitReader(file, maxImportBytes), originatorID)
+		if err != nil {
+			return c.String(400, fmt.Sprintf("Invalid SVG: %v", err))
+		}
+		if len(elements) == 0 {
+			return c.String(400, "SVG contains no supported elements")
+		}
+
+		canvasMutex.Lock()
+		canvas.Elements = append(canvas.Elements, elements...)
+		canvasVersion++
+		mutations.Inc("import")
+		recordHistory(historyAdd, time.Now(), elements...)
+		overLimit := settings.MaxElements > 0 && len(canvas.Elements) > settings.MaxElements
+		canvasMutex.Unlock()
+
+		if overLimit {
+			requestCompaction()
+		}
+
+		var sseBuilder strings.Builder
+		err = experiments.DrawingElementsSSE(elements).Render(c.Request().Context(), &sseBuilder)
+		if err != nil {
+			return c.String(500, "Error generating SSE HTML")
+		}
+
+		hub.Broadcast(sse.Event{
+			Name:      "canvas-element-added",
+			Data:      sseBuilder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+
+		canvasMutex.RLock()
+		var builder strings.Builder
+		err = experiments.CanvasSVG(canvas).Render(c.Request().Context(), &builder)
+		canvasMutex.RUnlock()
+		if err != nil {
+			return c.String(500, "Error generating canvas HTML")
+		}
+
+		return c.HTML(200, builder.String())
+	}
+}
+
+// parseSVGElements walks an SVG document and converts the supported shapes
+// into drawing elements. Anything else (scripts, styles, foreign objects,
+// event handlers) is dropped, and every kept attribute is re-serialized from
+// parsed values so nothing from the upload reaches the page verbatim.
+// Transforms are not applied.
+func parseSVGElements(r io.Reader, user string) ([]experiments.DrawingElement, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		elements []experiments.DrawingElement
+		sawRoot  bool
+		text     *svgText
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if !sawRoot {
+				if t.Name.Local != "svg" {
+					return nil, errors.New("root element is not <svg>")
+				}
+				sawRoot = true
+				continue
+			}
+			if len(elements) >= maxImportElements {
+				return nil, fmt.Errorf("more than %d elements", maxImportElements)
+			}
+
+			attrs := svgAttributes(t.Attr)
+			switch t.Name.Local {
+			case "path":
+				if element, ok := importPath(attrs, user); ok {
+					elements = append(elements, element)
+				}
+			case "rect":
+				if element, ok := importRect(attrs, user); ok {
+					elements = append(elements, element)
+				}
+			case "circle":
+				if element, ok := importCircle(attrs, user); ok {
+					elements = append(elements, element)
+				}
+			case "text":
+				text = &svgText{attrs: attrs}
+			}
+
+		case xml.CharData:
+			if text != nil {
+				text.content.Write(t)
+			}
+
+		case xml.EndElement:
+			if t.Name.Local == "text" && text != nil {
+				if element, ok := importText(text, user); ok {
+					elements = append(elements, element)
+				}
+				text = nil
+			}
+		}
+	}
+
+	if !sawRoot {
+		return nil, errors.New("no <svg> element found")
+	}
+	return elements, nil
+}
+
+type svgText struct {
+	attrs   map[string]string
+	content strings.Builder
+}
+
+// svgAttributes flattens XML attributes and inline style declarations into a
+// single lookup, with style taking precedence like it does in browsers.
+func svgAttributes(attrs []xml.Attr) map[string]string {
+	values := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		values[attr.Name.Local] = strings.TrimSpace(attr.Value)
+	}
+	if style, ok := values["style"]; ok {
+		for _, decl := range strings.Split(style, ";") {
+			name, value, found := strings.Cut(decl, ":")
+			if !found {
+				continue
+			}
+			values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+func importPath(attrs map[string]string, user string) (experiments.DrawingElement, bool) {
+	d := strings.Join(strings.Fields(attrs["d"]), " ")
+	if d == "" || len(d) > maxPathDataLength || !pathDataPattern.MatchString(d) {
+		return experiments.DrawingElement{}, false
+	}
+
+	color := sanitizeColor(attrs["stroke"])
+	if color == "" {
+		color = sanitizeColor(attrs["fill"])
+	}
+	if color == "" {
+		color = "#000000"
+	}
+
+	brushSize := "3"
+	if width, ok := parseNumber(attrs["stroke-width"]); ok && width > 0 {
+		brushSize = formatNumber(width)
+	}
+
+	return newElement("path", d, color, brushSize, user), true
+}
+
+func importRect(attrs map[string]string, user string) (experiments.DrawingElement, bool) {
+	x, _ := parseNumber(attrs["x"])
+	y, _ := parseNumber(attrs["y"])
+	width, okW := parseNumber(attrs["width"])
+	height, okH := parseNumber(attrs["height"])
+	if !okW || !okH || width <= 0 || height <= 0 {
+		return experiments.DrawingElement{}, false
+	}
+
+	data := fmt.Sprintf(`x="%s" y="%s" width="%s" height="%s"`,
+		formatNumber(x), formatNumber(y), formatNumber(width), formatNumber(height))
+	return newElement("rect", data, fillColor(attrs), "", user), true
+}
+
+func importCircle(attrs map[string]string, user string) (experiments.DrawingElement, bool) {
+	cx, _ := parseNumber(attrs["cx"])
+	cy, _ := parseNumber(attrs["cy"])
+	r, ok := parseNumber(attrs["r"])
+	if !ok || r <= 0 {
+		return experiments.DrawingElement{}, false
+	}
+
+	data := fmt.Sprintf(`cx="%s" cy="%s" r="%s"`, formatNumber(cx), formatNumber(cy), formatNumber(r))
+	return newElement("circle", data, fillColor(attrs), "", user), true
+}
+
+func importText(text *svgText, user string) (experiments.DrawingElement, bool) {
+	content := sanitizeText(text.content.String())
+	if content == "" {
+		return experiments.DrawingElement{}, false
+	}
+
+	x, _ := parseNumber(text.attrs["x"])
+	y, _ := parseNumber(text.attrs["y"])
+	data := fmt.Sprintf(`x="%s" y="%s" text="%s"`, formatNumber(x), formatNumber(y), content)
+	return newElement("text", data, fillColor(text.attrs), "", user), true
+}
+
+func fillColor(attrs map[string]string) string {
+	if color := sanitizeColor(attrs["fill"]); color != "" {
+		return color
+	}
+	return "#000000"
+}
+
+func sanitizeColor(value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "", strings.EqualFold(value, "none"):
+		return ""
+	case hexColorPattern.MatchString(value), rgbColorPattern.MatchString(value), namedColor.MatchString(value):
+		return value
+	}
+	return ""
+}
+
+// sanitizeText collapses whitespace and strips quotes, which would otherwise
+// terminate the encoded text attribute early.
+func sanitizeText(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == '"' || r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, value)
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > maxTextLength {
+		value = string(runes[:maxTextLength])
+	}
+	return value
+}
+
+// parseNumber accepts plain numbers and lengths with a "px" suffix.
+func parseNumber(value string) (float64, bool) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	if value == "" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,65 @@
+package chat
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic chat messages and typing indicators are broadcast
+// on. Rooms are told apart by event name.
+const Topic = "chat"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New caps the rooms and their history from s and returns chat as an
+// experiment.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "chat",
+		Name:        "Chat",
+		Description: "Real-time chat rooms with typing indicators and history, where every message is a server-rendered fragment",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", ChatHandler())
+	g.POST("/rooms", CreateRoomHandler())
+	g.GET("/rooms/:room", RoomHandler(e.hub))
+	g.GET("/rooms/:room/history", HistoryHandler())
+	g.POST("/rooms/:room/messages", PostMessageHandler(e.hub))
+	g.POST("/rooms/:room/typing", TypingHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the rooms can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, mu.TryRLock, mu.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop clears every room and its history.
+func (e *Experiment) Stop(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	resetRooms()
+	return nil
+}
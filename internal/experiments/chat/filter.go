@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxMessageLength = 500
+	maxNameLength    = 32
+)
+
+var (
+	errEmptyMessage   = errors.New("message is empty")
+	errMessageTooLong = errors.New("messages are limited to 500 characters")
+)
+
+// blockedWords are masked rather than rejected so the conversation keeps
+// flowing. Matching is case-insensitive and includes common suffixes.
+var blockedWords = regexp.MustCompile(`(?i)\b(fuck|shit|bitch|bastard|asshole|dick|cunt|piss|crap)\w*`)
+
+// filterMessage trims and validates a message, masking blocked words.
+func filterMessage(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		return "", errMessageTooLong
+	}
+	return maskBlocked(text), nil
+}
+
+// filterName trims and truncates a display name, masking blocked words.
+func filterName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if utf8.RuneCountInString(name) > maxNameLength {
+		name = string([]rune(name)[:maxNameLength])
+	}
+	return maskBlocked(name)
+}
+
+func maskBlocked(text string) string {
+	return blockedWords.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+}
@@ -0,0 +1,388 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultRoom = "general"
+	// pageSize is how many messages a page load or "load older" returns.
+	pageSize = 50
+	// typingTimeout is how long a typing indicator lasts without input.
+	typingTimeout = 4 * time.Second
+	// idleTimeout is how long a visitor-created room may go without a
+	// message before it is swept.
+	idleTimeout = time.Hour
+)
+
+var defaultRooms = []string{defaultRoom, "random", "help"}
+
+var roomName = regexp.MustCompile(`^[a-z0-9-]{1,24}$`)
+
+var authorColors = []string{"#f97316", "#22c55e", "#3b82f6", "#a855f7", "#ec4899", "#eab308", "#14b8a6", "#ef4444"}
+
+type room struct {
+	messages []experiments.ChatMessage
+	nextID   int
+	// typing maps display names to when their indicator expires.
+	typing      map[string]time.Time
+	typingTimer *time.Timer
+	// active is when the room was created or last had a message.
+	active time.Time
+}
+
+var (
+	rooms = make(map[string]*room)
+	mu    sync.RWMutex
+)
+
+func init() {
+	resetRooms()
+}
+
+// resetRooms must be called with mu held or before the experiment starts.
+func resetRooms() {
+	for _, r := range rooms {
+		if r.typingTimer != nil {
+			r.typingTimer.Stop()
+		}
+	}
+	rooms = make(map[string]*room)
+	for _, name := range defaultRooms {
+		rooms[name] = newRoom()
+	}
+}
+
+func newRoom() *room {
+	return &room{nextID: 1, typing: make(map[string]time.Time), active: time.Now()}
+}
+
+// sweep drops visitor-created rooms that have gone quiet. Call it with mu
+// held.
+func sweep(now time.Time) {
+	for name, r := range rooms {
+		if slices.Contains(defaultRooms, name) || now.Sub(r.active) <= idleTimeout {
+			continue
+		}
+		if r.typingTimer != nil {
+			r.typingTimer.Stop()
+		}
+		delete(rooms, name)
+	}
+}
+
+func roomNames() []string {
+	names := make([]string, 0, len(rooms))
+	for name := range rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func messageEvent(room string) string {
+	return "chat-" + room + "-message"
+}
+
+func typingEvent(room string) string {
+	return "chat-" + room + "-typing"
+}
+
+func authorColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return authorColors[h.Sum32()%uint32(len(authorColors))]
+}
+
+// olderThan returns up to pageSize messages before the given ID and whether
+// there are more beyond them. A beforeID of 0 means the latest messages.
+func (r *room) olderThan(beforeID int) ([]experiments.ChatMessage, bool) {
+	end := len(r.messages)
+	if beforeID > 0 {
+		end = sort.Search(len(r.messages), func(i int) bool { return r.messages[i].ID >= beforeID })
+	}
+	start := max(end-pageSize, 0)
+	return append([]experiments.ChatMessage(nil), r.messages[start:end]...), start > 0
+}
+
+// typingNames prunes expired indicators and returns who is still typing.
+func (r *room) typingNames(now time.Time) []string {
+	var names []string
+	for name, until := range r.typing {
+		if now.After(until) {
+			delete(r.typing, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChatHandler sends visitors to the default room.
+func ChatHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.Redirect(303, "/experiments/chat/rooms/"+defaultRoom)
+	}
+}
+
+// CreateRoomHandler opens the room named by the new room form, creating it
+// if needed, after sweeping rooms that have gone quiet.
+func CreateRoomHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.FormValue("room")
+		if !roomName.MatchString(name) {
+			return c.String(400, "Room names use lowercase letters, numbers, and dashes")
+		}
+
+		mu.Lock()
+		if _, exists := rooms[name]; !exists {
+			sweep(time.Now())
+			if len(rooms) >= settings.MaxRooms {
+				mu.Unlock()
+				return c.String(400, "Too many rooms")
+			}
+			rooms[name] = newRoom()
+		}
+		mu.Unlock()
+
+		return c.Redirect(303, "/experiments/chat/rooms/"+name)
+	}
+}
+
+func RoomHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("room")
+		if !roomName.MatchString(name) {
+			return c.String(400, "Invalid room name")
+		}
+
+		mu.RLock()
+		r, exists := rooms[name]
+		if !exists {
+			mu.RUnlock()
+			return c.String(404, "Room not found")
+		}
+		messages, hasOlder := r.olderThan(0)
+		names := roomNames()
+		mu.RUnlock()
+
+		originatorID := fmt.Sprintf("chat-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+
+		data := experiments.ChatPageData{
+			Room:         name,
+			Rooms:        names,
+			Messages:     messages,
+			HasOlder:     hasOlder,
+			Name:         session.Get(c).Name,
+			OriginatorID: originatorID,
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.ChatPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.ChatPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// HistoryHandler returns the page of messages before the "before" ID.
+func HistoryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("room")
+		beforeID, err := strconv.Atoi(c.QueryParam("before"))
+		if err != nil || beforeID < 1 {
+			return c.String(400, "Invalid message ID")
+		}
+
+		mu.RLock()
+		r, exists := rooms[name]
+		if !exists {
+			mu.RUnlock()
+			return c.String(404, "Room not found")
+		}
+		messages, hasOlder := r.olderThan(beforeID)
+		mu.RUnlock()
+
+		return experiments.ChatHistory(name, messages, hasOlder).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func PostMessageHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("room")
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		text, err := filterMessage(c.FormValue("text"))
+		if err != nil {
+			return chatError(c, "Message not sent: "+err.Error()+".")
+		}
+		s := session.Get(c)
+		author := filterName(s.Name)
+		if author == "" {
+			author = "Guest"
+		}
+		color := s.Color
+		if color == "" {
+			color = authorColor(author)
+		}
+
+		mu.Lock()
+		r, exists := rooms[name]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Room not found")
+		}
+		msg := experiments.ChatMessage{
+			ID:     r.nextID,
+			Room:   name,
+			Author: author,
+			Color:  color,
+			Text:   text,
+			Sent:   time.Now(),
+		}
+		r.nextID++
+		r.active = msg.Sent
+		r.messages = append(r.messages, msg)
+		if overflow := len(r.messages) - settings.MaxRoomHistory; overflow > 0 {
+			r.messages = append([]experiments.ChatMessage(nil), r.messages[overflow:]...)
+		}
+		_, wasTyping := r.typing[author]
+		delete(r.typing, author)
+		typing := r.typingNames(msg.Sent)
+		mu.Unlock()
+
+		// Broadcast the message to everyone else in the room
+		var sseBuilder strings.Builder
+		if err := experiments.ChatMessageItem(msg).Render(c.Request().Context(), &sseBuilder); err != nil {
+			return c.String(500, "Error generating SSE HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name:      messageEvent(name),
+			Data:      sseBuilder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+
+		if wasTyping {
+			broadcastTyping(c, hub, name, typing)
+		}
+
+		// Return the message to the originator for immediate feedback
+		var originatorBuilder strings.Builder
+		if err := experiments.ChatPosted(msg).Render(c.Request().Context(), &originatorBuilder); err != nil {
+			return c.String(500, "Error generating originator HTML")
+		}
+		return c.HTML(200, originatorBuilder.String())
+	}
+}
+
+// TypingHandler shows the sender as typing to everyone else in the room
+// until they post or stop typing for typingTimeout.
+func TypingHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("room")
+		author := filterName(session.Get(c).Name)
+		if author == "" {
+			return c.NoContent(204)
+		}
+
+		mu.Lock()
+		r, exists := rooms[name]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Room not found")
+		}
+		now := time.Now()
+		_, already := r.typing[author]
+		r.typing[author] = now.Add(typingTimeout)
+		typing := r.typingNames(now)
+		scheduleTypingExpiry(hub, name, r)
+		mu.Unlock()
+
+		if !already {
+			broadcastTyping(c, hub, name, typing)
+		}
+		return c.NoContent(204)
+	}
+}
+
+// scheduleTypingExpiry re-broadcasts the indicator once the next entry
+// expires. It must be called with mu held.
+func scheduleTypingExpiry(hub *sse.Hub, name string, r *room) {
+	var next time.Time
+	for _, until := range r.typing {
+		if next.IsZero() || until.Before(next) {
+			next = until
+		}
+	}
+	if r.typingTimer != nil {
+		r.typingTimer.Stop()
+		r.typingTimer = nil
+	}
+	if next.IsZero() {
+		return
+	}
+	r.typingTimer = time.AfterFunc(time.Until(next)+10*time.Millisecond, func() {
+		mu.Lock()
+		if rooms[name] != r {
+			mu.Unlock()
+			return
+		}
+		typing := r.typingNames(time.Now())
+		scheduleTypingExpiry(hub, name, r)
+		mu.Unlock()
+
+		var builder strings.Builder
+		if err := experiments.ChatTyping(typing).Render(context.Background(), &builder); err != nil {
+			return
+		}
+		hub.Broadcast(sse.Event{
+			Name:  typingEvent(name),
+			Data:  builder.String(),
+			Topic: Topic,
+		})
+	})
+}
+
+func broadcastTyping(c echo.Context, hub *sse.Hub, name string, typing []string) {
+	var builder strings.Builder
+	if err := experiments.ChatTyping(typing).Render(c.Request().Context(), &builder); err != nil {
+		return
+	}
+	hub.Broadcast(sse.Event{
+		Name:      typingEvent(name),
+		Data:      builder.String(),
+		ExcludeID: c.Request().Header.Get("X-Originator-ID"),
+		Topic:     Topic,
+	})
+}
+
+// chatError shows a message next to the form instead of appending it to the
+// conversation, which is what the form targets.
+func chatError(c echo.Context, message string) error {
+	c.Response().Header().Set("HX-Retarget", "#chat-error")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return experiments.ChatError(message).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,25 @@
+package chat
+
+// Settings controls how many rooms there can be and how much each keeps.
+type Settings struct {
+	// MaxRooms caps how many rooms there can be, including the default
+	// ones, before visitors can't create more.
+	MaxRooms int
+	// MaxRoomHistory is how many messages each room keeps.
+	MaxRoomHistory int
+}
+
+func DefaultSettings() Settings {
+	return Settings{MaxRooms: 20, MaxRoomHistory: 1000}
+}
+
+var settings = DefaultSettings()
+
+// configure sets the room cap and how much history each room keeps. It runs
+// from New, before any route is served, since handlers read settings
+// without taking mu.
+func configure(s Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+}
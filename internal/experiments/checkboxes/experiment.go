@@ -0,0 +1,61 @@
+package checkboxes
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic checkbox and counter updates are broadcast on.
+const Topic = "checkboxes"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New sizes the board from s and returns it as an experiment.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "checkboxes",
+		Name:        "10,000 Checkboxes",
+		Description: "Real-time synchronized checkboxes demonstrating hypermedia-driven state management with SSE",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", CheckboxesHandler(e.hub))
+	g.POST("/toggle/:id", ToggleHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the checkboxes can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, mu.TryRLock, mu.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop resets every checkbox so a restarted experiment begins empty.
+func (e *Experiment) Stop(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	for id := range checkboxes {
+		checkboxes[id] = false
+	}
+	return nil
+}
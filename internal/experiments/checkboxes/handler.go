@@ -8,8 +8,11 @@ import (
 	"sync"
 	"time"
 
-	"hypermedia-sync/internal/sse" 
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/metrics"
+	"hypermedia-sync/internal/sse"
 	"hypermedia-sync/internal/templates/experiments"
+	"hypermedia-sync/internal/tracing"
 
 	"github.com/labstack/echo/v4"
 )
@@ -17,13 +20,9 @@ import (
 var (
 	checkboxes = make(map[int]bool)
 	mu         sync.RWMutex
-)
 
-func init() {
-	for i := 1; i <= 10000; i++ {
-		checkboxes[i] = false
-	}
-}
+	toggles = metrics.NewCounter("checkbox_toggles_total", "Checkbox toggles.")
+)
 
 func CheckboxesHandler(hub *sse.Hub) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -32,7 +31,7 @@ func CheckboxesHandler(hub *sse.Hub) echo.HandlerFunc {
 
 		var cbData []experiments.CheckboxData
 
-		for i := 1; i <= 10000; i++ {
+		for i := 1; i <= settings.Count; i++ {
 			checked := checkboxes[i]
 			cbData = append(cbData, experiments.CheckboxData{ID: i, Checked: checked})
 		}
@@ -47,6 +46,7 @@ func CheckboxesHandler(hub *sse.Hub) echo.HandlerFunc {
 			Checkboxes:   cbData,
 			OriginatorID: originatorID,
 			OnlineCount:  onlineCount,
+			Banner:       experiment.BannerOf(c),
 		}
 
 		// Dual response pattern - check if it's an HTMX request
@@ -70,35 +70,41 @@ func ToggleHandler(hub *sse.Hub) echo.HandlerFunc {
 			return c.String(400, "Invalid checkbox ID")
 		}
 
-		if id < 1 || id > 10000 {
+		if id < 1 || id > settings.Count {
 			return c.String(400, "Checkbox ID out of range")
 		}
 
 		// Get originator ID
 		originatorID := c.Request().Header.Get("X-Originator-ID")
 
+		ctx, span := tracing.Start(c.Request().Context(), "checkboxes.toggle", tracing.WithAttributes("checkbox.id", id))
+		defer span.End()
+
 		// Toggle checkbox state
 		mu.Lock()
 		checkboxes[id] = !checkboxes[id]
 		newState := checkboxes[id]
 		mu.Unlock()
+		toggles.Inc()
+		span.SetAttributes("checkbox.checked", newState)
 
 		// Generate HTML for this checkbox
 		cb := experiments.CheckboxData{ID: id, Checked: newState}
-		
+
 		// Generate HTML for SSE broadcast (excluding originator)
 		var sseBuilder strings.Builder
 		sseComponent := experiments.CheckboxItemSSEComplete(cb)
-		err = sseComponent.Render(c.Request().Context(), &sseBuilder)
+		err = tracing.Render(ctx, "CheckboxItemSSEComplete", sseComponent, &sseBuilder)
 		if err != nil {
 			return c.String(500, "Error generating SSE HTML")
 		}
 
 		// Broadcast only the affected checkbox (excluding originator)
-		hub.Broadcast(sse.Event{
+		hub.BroadcastContext(ctx, sse.Event{
 			Name:      fmt.Sprintf("checkbox-%d-updated", id),
 			Data:      sseBuilder.String(),
 			ExcludeID: originatorID,
+			Topic:     Topic,
 		})
 
 		// Calculate new total count
@@ -112,19 +118,20 @@ func ToggleHandler(hub *sse.Hub) echo.HandlerFunc {
 		mu.RUnlock()
 
 		// Broadcast counter update to all clients (including originator)
-		hub.Broadcast(sse.Event{
-			Name: "counter-updated",
-			Data: fmt.Sprintf("%d checked", totalChecked),
+		hub.BroadcastContext(ctx, sse.Event{
+			Name:  "counter-updated",
+			Data:  fmt.Sprintf("%d checked", totalChecked),
+			Topic: Topic,
 		})
 
 		// Return updated HTML to originator for immediate feedback
 		var originatorBuilder strings.Builder
 		originatorComponent := experiments.CheckboxItemSSEComplete(cb)
-		err = originatorComponent.Render(c.Request().Context(), &originatorBuilder)
+		err = tracing.Render(ctx, "CheckboxItemSSEComplete", originatorComponent, &originatorBuilder)
 		if err != nil {
 			return c.String(500, "Error generating originator HTML")
 		}
-		
+
 		return c.HTML(200, originatorBuilder.String())
 	}
 }
@@ -0,0 +1,21 @@
+package checkboxes
+
+// Settings controls the size of the board.
+type Settings struct {
+	// Count is how many checkboxes the board has, numbered from 1.
+	Count int
+}
+
+func DefaultSettings() Settings {
+	return Settings{Count: 10000}
+}
+
+var settings = DefaultSettings()
+
+// configure resizes the board, which means clearing it.
+func configure(s Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+	checkboxes = make(map[int]bool)
+}
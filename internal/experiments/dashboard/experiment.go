@@ -0,0 +1,66 @@
+package dashboard
+
+import (
+	"context"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic dashboard samples are broadcast on.
+const Topic = "dashboard"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New returns the dashboard, sampling every s.SampleInterval once started.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "dashboard",
+		Name:        "Server Dashboard",
+		Description: "This server's own live stats, from SSE connections and broadcast latency to goroutines and memory, sampled every " + settings.SampleInterval.String(),
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", DashboardHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the sample history can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, history.TryRLock, history.RUnlock)
+}
+
+// Start takes a first sample and then samples every SampleInterval until
+// ctx is canceled.
+func (e *Experiment) Start(ctx context.Context) error {
+	history.Lock()
+	history.started = time.Now()
+	history.Unlock()
+	record(e.hub)
+	go run(ctx, e.hub)
+	return nil
+}
+
+// Stop discards the sample history.
+func (e *Experiment) Stop(ctx context.Context) error {
+	history.Lock()
+	defer history.Unlock()
+	history.samples = nil
+	return nil
+}
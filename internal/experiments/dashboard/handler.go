@@ -0,0 +1,34 @@
+package dashboard
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+func DashboardHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := fmt.Sprintf("dashboard-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+
+		data := experiments.DashboardPageData{
+			Stats:        view(),
+			OriginatorID: originatorID,
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.DashboardPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.DashboardPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
@@ -0,0 +1,216 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+)
+
+const (
+	// historyLength is how many samples the sparklines span.
+	historyLength = 60
+
+	sparklineWidth  = 120
+	sparklineHeight = 32
+)
+
+const statsEvent = "dashboard-stats"
+
+// sample is one reading of the hub and runtime. Rates are over the interval
+// since the previous sample.
+type sample struct {
+	connections   int
+	eventsPerSec  float64
+	deliveriesSec float64
+	latency       time.Duration
+	writeErrors   uint64
+	goroutines    int
+	heapBytes     uint64
+	sysBytes      uint64
+	numGC         uint32
+	subscribers   map[string]int
+}
+
+var history = struct {
+	sync.RWMutex
+	samples []sample
+	last    sse.Stats
+	lastAt  time.Time
+	started time.Time
+}{}
+
+// record reads the hub and runtime and appends a sample.
+func record(hub *sse.Hub) {
+	stats := hub.Stats()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	now := time.Now()
+
+	history.Lock()
+	defer history.Unlock()
+
+	s := sample{
+		connections: stats.Connections,
+		writeErrors: stats.WriteErrors,
+		goroutines:  runtime.NumGoroutine(),
+		heapBytes:   mem.HeapAlloc,
+		sysBytes:    mem.Sys,
+		numGC:       mem.NumGC,
+		subscribers: stats.Subscribers,
+	}
+	if !history.lastAt.IsZero() {
+		elapsed := now.Sub(history.lastAt).Seconds()
+		s.eventsPerSec = float64(stats.Events-history.last.Events) / elapsed
+		s.deliveriesSec = float64(stats.Deliveries-history.last.Deliveries) / elapsed
+		if n := stats.LatencySamples - history.last.LatencySamples; n > 0 {
+			s.latency = (stats.Latency - history.last.Latency) / time.Duration(n)
+		}
+	}
+	history.last = stats
+	history.lastAt = now
+
+	history.samples = append(history.samples, s)
+	if overflow := len(history.samples) - historyLength; overflow > 0 {
+		history.samples = append([]sample(nil), history.samples[overflow:]...)
+	}
+}
+
+// run samples on a ticker until ctx is done, pushing the dashboard while
+// anyone is watching. Sampling continues regardless so the sparklines are
+// full when someone opens the page.
+func run(ctx context.Context, hub *sse.Hub) {
+	ticker := time.NewTicker(settings.SampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record(hub)
+			if !hub.HasSubscribers(Topic) {
+				continue
+			}
+			var builder strings.Builder
+			if err := experiments.DashboardStats(view()).Render(ctx, &builder); err != nil {
+				slog.Error("rendering dashboard", "experiment", "dashboard", "err", err)
+				continue
+			}
+			hub.Broadcast(sse.Event{Name: statsEvent, Data: builder.String(), Topic: Topic})
+		}
+	}
+}
+
+// view renders the history into cards with sparklines.
+func view() experiments.DashboardData {
+	history.RLock()
+	defer history.RUnlock()
+
+	data := experiments.DashboardData{
+		Uptime:   time.Since(history.started).Truncate(time.Second).String(),
+		Interval: settings.SampleInterval.String(),
+		Window:   (historyLength * settings.SampleInterval).String(),
+	}
+	if len(history.samples) == 0 {
+		return data
+	}
+	latest := history.samples[len(history.samples)-1]
+
+	series := func(value func(sample) float64) string {
+		values := make([]float64, len(history.samples))
+		for i, s := range history.samples {
+			values[i] = value(s)
+		}
+		return sparkline(values)
+	}
+
+	data.Cards = []experiments.DashboardCard{
+		{
+			Label:     "SSE connections",
+			Value:     fmt.Sprintf("%d", latest.connections),
+			Sparkline: series(func(s sample) float64 { return float64(s.connections) }),
+		},
+		{
+			Label:     "Events / sec",
+			Value:     fmt.Sprintf("%.1f", latest.eventsPerSec),
+			Detail:    fmt.Sprintf("%.1f deliveries/sec • %d write errors", latest.deliveriesSec, latest.writeErrors),
+			Sparkline: series(func(s sample) float64 { return s.eventsPerSec }),
+		},
+		{
+			Label:     "Broadcast latency",
+			Value:     formatDuration(latest.latency),
+			Detail:    "Average, from Broadcast to flushed write",
+			Sparkline: series(func(s sample) float64 { return float64(s.latency) }),
+		},
+		{
+			Label:     "Goroutines",
+			Value:     fmt.Sprintf("%d", latest.goroutines),
+			Sparkline: series(func(s sample) float64 { return float64(s.goroutines) }),
+		},
+		{
+			Label:     "Heap in use",
+			Value:     formatBytes(latest.heapBytes),
+			Detail:    fmt.Sprintf("%s from the OS • %d GC cycles", formatBytes(latest.sysBytes), latest.numGC),
+			Sparkline: series(func(s sample) float64 { return float64(s.heapBytes) }),
+		},
+	}
+
+	topics := make([]string, 0, len(latest.subscribers))
+	for topic := range latest.subscribers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		name := topic
+		if name == "" {
+			name = "(no topic)"
+		}
+		data.Topics = append(data.Topics, experiments.DashboardTopic{Name: name, Subscribers: latest.subscribers[topic]})
+	}
+	return data
+}
+
+// sparkline returns SVG polyline points scaled to the sparkline's box, with
+// zero at the bottom.
+func sparkline(values []float64) string {
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	step := 0.0
+	if len(values) > 1 {
+		step = float64(sparklineWidth) / float64(historyLength-1)
+	}
+	offset := float64(sparklineWidth) - step*float64(len(values)-1)
+
+	points := make([]string, len(values))
+	for i, v := range values {
+		y := float64(sparklineHeight)
+		if peak > 0 {
+			y -= v / peak * (sparklineHeight - 2)
+		}
+		points[i] = fmt.Sprintf("%.1f,%.1f", offset+step*float64(i), y)
+	}
+	return strings.Join(points, " ")
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "–"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%d µs", d.Microseconds())
+	}
+	return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+}
+
+func formatBytes(n uint64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
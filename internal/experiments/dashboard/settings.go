@@ -0,0 +1,22 @@
+package dashboard
+
+import "time"
+
+// Settings controls how often the dashboard samples.
+type Settings struct {
+	// SampleInterval is the time between samples, and so between pushes
+	// while anyone is watching. The sparklines span sixty samples.
+	SampleInterval time.Duration
+}
+
+func DefaultSettings() Settings {
+	return Settings{SampleInterval: time.Second}
+}
+
+var settings = DefaultSettings()
+
+// configure sets the sample interval, which run reads once when its ticker
+// starts.
+func configure(s Settings) {
+	settings = s
+}
@@ -0,0 +1,63 @@
+package editor
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic document operations are broadcast on.
+const Topic = "editor"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New returns the shared editor, limiting the document to
+// s.MaxDocumentLength.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "editor",
+		Name:        "Shared Text Editor",
+		Description: "A plain-text document edited by everyone at once, with concurrent keystrokes merged on the server using operational transform",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", EditorHandler(e.hub))
+	g.GET("/snapshot", SnapshotHandler())
+	g.POST("/ops", OpsHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the document can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, document.TryRLock, document.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop clears the document and its history.
+func (e *Experiment) Stop(ctx context.Context) error {
+	document.Lock()
+	defer document.Unlock()
+	document.text = nil
+	document.revision = 0
+	document.history = nil
+	return nil
+}
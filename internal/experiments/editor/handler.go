@@ -0,0 +1,165 @@
+package editor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxHistory bounds how far behind a client's base revision may be.
+	maxHistory = 1000
+	// maxOpsPerRequest bounds the work done transforming one request.
+	maxOpsPerRequest = 50
+)
+
+const opEvent = "editor-op"
+
+var (
+	errStale   = errors.New("revision is too old")
+	errTooLong = errors.New("document is too long")
+)
+
+// document is the server's copy of the text. Every accepted request becomes
+// one revision; history[i] holds the ops that produced revision base+i+1.
+var document = struct {
+	sync.RWMutex
+	text     []uint16
+	revision int
+	history  [][]op
+}{}
+
+// snapshot returns the text and revision for late joiners.
+func snapshot() experiments.EditorSnapshot {
+	document.RLock()
+	defer document.RUnlock()
+	return experiments.EditorSnapshot{
+		Text:     string(utf16.Decode(document.text)),
+		Revision: document.revision,
+	}
+}
+
+// submit transforms ops made against base over everything applied since,
+// applies them, and returns the transformed ops and the new revision.
+func submit(base int, ops []op) ([]op, int, error) {
+	document.Lock()
+	defer document.Unlock()
+
+	oldest := document.revision - len(document.history)
+	if base < oldest || base > document.revision {
+		return nil, 0, errStale
+	}
+	for _, applied := range document.history[base-oldest:] {
+		ops, _ = transformList(ops, applied)
+	}
+
+	text, err := apply(document.text, ops)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(text) > settings.MaxDocumentLength {
+		return nil, 0, errTooLong
+	}
+
+	document.text = text
+	document.revision++
+	document.history = append(document.history, ops)
+	if overflow := len(document.history) - maxHistory; overflow > 0 {
+		document.history = append([][]op(nil), document.history[overflow:]...)
+	}
+	return ops, document.revision, nil
+}
+
+func EditorHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := fmt.Sprintf("editor-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+
+		data := experiments.EditorPageData{
+			Snapshot:     snapshot(),
+			OriginatorID: originatorID,
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.EditorPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.EditorPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// SnapshotHandler re-renders the editor so clients that fell too far behind
+// can start over from the current text.
+func SnapshotHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return experiments.EditorTextarea(snapshot()).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// OpsHandler accepts a batch of ops made against revision "rev". The sender
+// gets an acknowledgement with the new revision; everyone else gets the
+// transformed ops over SSE.
+func OpsHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		base, err := strconv.Atoi(c.FormValue("rev"))
+		if err != nil {
+			return c.String(400, "Invalid revision")
+		}
+		var ops []op
+		if err := json.Unmarshal([]byte(c.FormValue("ops")), &ops); err != nil {
+			return c.String(400, "Invalid operations")
+		}
+		if len(ops) == 0 || len(ops) > maxOpsPerRequest {
+			return c.String(400, "Invalid number of operations")
+		}
+
+		applied, revision, err := submit(base, ops)
+		if errors.Is(err, errStale) {
+			return c.String(409, "Revision too old, reload the document")
+		}
+		if err != nil {
+			return c.String(400, "Operation rejected: "+err.Error())
+		}
+
+		if applied == nil {
+			// Everything was deleted by concurrent edits; the revision
+			// still counts so clients stay in step.
+			applied = []op{}
+		}
+		encoded, err := json.Marshal(applied)
+		if err != nil {
+			return c.String(500, "Error encoding operations")
+		}
+		operation := experiments.EditorOperation{Revision: revision, Ops: string(encoded)}
+
+		var sseBuilder strings.Builder
+		if err := experiments.EditorOp(operation).Render(c.Request().Context(), &sseBuilder); err != nil {
+			return c.String(500, "Error generating SSE HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name:      opEvent,
+			Data:      sseBuilder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+
+		return experiments.EditorAck(revision).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
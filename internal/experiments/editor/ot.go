@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"errors"
+	"unicode/utf16"
+)
+
+// Positions and lengths are in UTF-16 code units, which is how browsers
+// index textarea contents.
+
+type op struct {
+	// Kind is "i" for insert or "d" for delete.
+	Kind string `json:"k"`
+	Pos  int    `json:"p"`
+	Text string `json:"t,omitempty"`
+	Len  int    `json:"n,omitempty"`
+}
+
+func insertOp(pos int, text string) op {
+	return op{Kind: "i", Pos: pos, Text: text}
+}
+
+func deleteOp(pos, n int) op {
+	return op{Kind: "d", Pos: pos, Len: n}
+}
+
+func textLen(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// size is how many code units the op inserts or removes.
+func (o op) size() int {
+	if o.Kind == "i" {
+		return textLen(o.Text)
+	}
+	return o.Len
+}
+
+func (o op) validate(docLen int) error {
+	switch o.Kind {
+	case "i":
+		if o.Text == "" {
+			return errors.New("empty insert")
+		}
+		if o.Pos < 0 || o.Pos > docLen {
+			return errors.New("insert out of range")
+		}
+	case "d":
+		if o.Len <= 0 || o.Pos < 0 || o.Pos+o.Len > docLen {
+			return errors.New("delete out of range")
+		}
+	default:
+		return errors.New("unknown operation")
+	}
+	return nil
+}
+
+// apply validates and applies ops in order.
+func apply(doc []uint16, ops []op) ([]uint16, error) {
+	for _, o := range ops {
+		if err := o.validate(len(doc)); err != nil {
+			return nil, err
+		}
+		switch o.Kind {
+		case "i":
+			text := utf16.Encode([]rune(o.Text))
+			next := make([]uint16, 0, len(doc)+len(text))
+			next = append(next, doc[:o.Pos]...)
+			next = append(next, text...)
+			doc = append(next, doc[o.Pos:]...)
+		case "d":
+			doc = append(doc[:o.Pos:o.Pos], doc[o.Pos+o.Len:]...)
+		}
+	}
+	return doc, nil
+}
+
+// transform takes two ops made against the same document and returns a'
+// (a rewritten to apply after b) and b' (b rewritten to apply after a). b
+// wins ties: when both insert at the same position, b's text comes first.
+// Deletes can split in two around an insert, so results are lists.
+func transform(a, b op) ([]op, []op) {
+	switch {
+	case a.Kind == "i" && b.Kind == "i":
+		if a.Pos < b.Pos {
+			return []op{a}, []op{insertOp(b.Pos+a.size(), b.Text)}
+		}
+		return []op{insertOp(a.Pos+b.size(), a.Text)}, []op{b}
+
+	case a.Kind == "i" && b.Kind == "d":
+		return transformInsertDelete(a, b)
+
+	case a.Kind == "d" && b.Kind == "i":
+		bt, at := transformInsertDelete(b, a)
+		return at, bt
+	}
+
+	// Both delete: each loses the part the other already removed.
+	overlap := max(0, min(a.Pos+a.Len, b.Pos+b.Len)-max(a.Pos, b.Pos))
+	var at, bt []op
+	if n := a.Len - overlap; n > 0 {
+		at = []op{deleteOp(a.Pos-min(b.Len, max(0, a.Pos-b.Pos)), n)}
+	}
+	if n := b.Len - overlap; n > 0 {
+		bt = []op{deleteOp(b.Pos-min(a.Len, max(0, b.Pos-a.Pos)), n)}
+	}
+	return at, bt
+}
+
+// transformInsertDelete transforms insert i and delete d against each other.
+// Text inserted inside a deleted range survives, splitting the delete.
+func transformInsertDelete(i, d op) ([]op, []op) {
+	switch {
+	case i.Pos <= d.Pos:
+		return []op{i}, []op{deleteOp(d.Pos+i.size(), d.Len)}
+	case i.Pos >= d.Pos+d.Len:
+		return []op{insertOp(i.Pos-d.Len, i.Text)}, []op{d}
+	}
+	before := i.Pos - d.Pos
+	return []op{insertOp(d.Pos, i.Text)}, []op{
+		deleteOp(d.Pos, before),
+		deleteOp(d.Pos+i.size(), d.Len-before),
+	}
+}
+
+// transformList transforms two sequences of ops against each other, with b
+// winning ties as in transform.
+func transformList(a, b []op) ([]op, []op) {
+	if len(a) == 0 || len(b) == 0 {
+		return a, b
+	}
+	if len(a) > 1 {
+		first, b1 := transformList(a[:1], b)
+		rest, b2 := transformList(a[1:], b1)
+		return append(first, rest...), b2
+	}
+	if len(b) > 1 {
+		a1, first := transformList(a, b[:1])
+		a2, rest := transformList(a1, b[1:])
+		return a2, append(first, rest...)
+	}
+	return transform(a[0], b[0])
+}
@@ -0,0 +1,71 @@
+package editor
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+func TestTransformConverges(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		a, b op
+		want string
+	}{
+		{"inserts at different positions", "hello", insertOp(0, "<"), insertOp(5, ">"), "<hello>"},
+		{"inserts at the same position", "ac", insertOp(1, "x"), insertOp(1, "y"), "ayxc"},
+		{"insert before delete", "hello world", insertOp(0, "oh, "), deleteOp(5, 6), "oh, hello"},
+		{"insert after delete", "hello world", insertOp(11, "!"), deleteOp(0, 6), "world!"},
+		{"insert at delete start", "abcdef", insertOp(2, "X"), deleteOp(2, 2), "abXef"},
+		{"insert at delete end", "abcdef", insertOp(4, "X"), deleteOp(2, 2), "abXef"},
+		{"insert inside delete", "abcdef", insertOp(3, "X"), deleteOp(1, 4), "aXf"},
+		{"delete around insert", "abcdef", deleteOp(1, 4), insertOp(3, "X"), "aXf"},
+		{"disjoint deletes", "abcdef", deleteOp(0, 2), deleteOp(4, 2), "cd"},
+		{"overlapping deletes", "abcdef", deleteOp(1, 3), deleteOp(2, 3), "af"},
+		{"same delete", "abcdef", deleteOp(1, 2), deleteOp(1, 2), "adef"},
+		{"nested deletes", "abcdef", deleteOp(0, 6), deleteOp(2, 2), ""},
+		{"surrogate pairs", "a😀b", insertOp(4, "c"), deleteOp(1, 2), "abc"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := utf16.Encode([]rune(tc.doc))
+			at, bt := transform(tc.a, tc.b)
+			ab, err := apply(doc, append([]op{tc.a}, bt...))
+			if err != nil {
+				t.Fatalf("a then b': %v", err)
+			}
+			ba, err := apply(append([]uint16(nil), doc...), append([]op{tc.b}, at...))
+			if err != nil {
+				t.Fatalf("b then a': %v", err)
+			}
+			if got := string(utf16.Decode(ab)); got != tc.want {
+				t.Errorf("a then b' = %q, want %q", got, tc.want)
+			}
+			if got := string(utf16.Decode(ba)); got != tc.want {
+				t.Errorf("b then a' = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTransformListConverges(t *testing.T) {
+	doc := utf16.Encode([]rune("the quick fox"))
+	a := []op{deleteOp(4, 6), insertOp(4, "slow ")}
+	b := []op{insertOp(10, "brown "), deleteOp(0, 4)}
+	// Both inserts land at 4 once the deletes apply; b's text comes first.
+	at, bt := transformList(a, b)
+	ab, err := apply(append([]uint16(nil), doc...), append(append([]op(nil), a...), bt...))
+	if err != nil {
+		t.Fatalf("a then b': %v", err)
+	}
+	ba, err := apply(append([]uint16(nil), doc...), append(append([]op(nil), b...), at...))
+	if err != nil {
+		t.Fatalf("b then a': %v", err)
+	}
+	if string(utf16.Decode(ab)) != string(utf16.Decode(ba)) {
+		t.Fatalf("diverged: %q and %q", string(utf16.Decode(ab)), string(utf16.Decode(ba)))
+	}
+	if got, want := string(utf16.Decode(ab)), "brown slow fox"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
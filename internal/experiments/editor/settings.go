@@ -0,0 +1,22 @@
+package editor
+
+// Settings controls the size of the shared document.
+type Settings struct {
+	// MaxDocumentLength is in UTF-16 code units, matching how the browser
+	// measures the textarea.
+	MaxDocumentLength int
+}
+
+func DefaultSettings() Settings {
+	return Settings{MaxDocumentLength: 100000}
+}
+
+var settings = DefaultSettings()
+
+// configure sets the document length limit. Lowering it doesn't shorten a
+// document that is already longer; it only refuses edits that would grow it.
+func configure(s Settings) {
+	document.Lock()
+	defer document.Unlock()
+	settings = s
+}
@@ -0,0 +1,64 @@
+package games
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic boards and the room list are broadcast on.
+const Topic = "games"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New returns the game rooms as an experiment, capped at s.MaxRooms.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "games",
+		Name:        "Turn-Based Games",
+		Description: "Tic-tac-toe and connect four with matchmaking, server-enforced turns, rematches, and live spectators",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", GamesHandler(e.hub))
+	g.POST("/play", PlayHandler(e.hub))
+	g.GET("/rooms/:id", RoomHandler(e.hub))
+	g.POST("/rooms/:id/join", JoinHandler(e.hub))
+	g.POST("/rooms/:id/move", MoveHandler(e.hub))
+	g.POST("/rooms/:id/rematch", RematchHandler(e.hub))
+	g.POST("/rooms/:id/leave", LeaveHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the rooms can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, mu.TryRLock, mu.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop closes every room.
+func (e *Experiment) Stop(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	rooms = make(map[string]*room)
+	return nil
+}
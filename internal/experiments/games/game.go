@@ -0,0 +1,139 @@
+package games
+
+import "errors"
+
+var (
+	errGameOver    = errors.New("the game is over")
+	errNotYourTurn = errors.New("it's not your turn")
+	errOccupied    = errors.New("that square is taken")
+	errColumnFull  = errors.New("that column is full")
+	errOffBoard    = errors.New("that move is off the board")
+)
+
+// variant describes one of the games a room can play. Gravity games take a
+// column and drop the piece to the lowest free row.
+type variant struct {
+	ID      string
+	Name    string
+	Rows    int
+	Cols    int
+	Need    int
+	Gravity bool
+	Marks   [2]string
+}
+
+var variants = []variant{
+	{ID: "tictactoe", Name: "Tic-Tac-Toe", Rows: 3, Cols: 3, Need: 3, Marks: [2]string{"X", "O"}},
+	{ID: "connect4", Name: "Connect Four", Rows: 6, Cols: 7, Need: 4, Gravity: true, Marks: [2]string{"Red", "Yellow"}},
+}
+
+func lookupVariant(id string) (variant, bool) {
+	for _, v := range variants {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return variant{}, false
+}
+
+const empty = -1
+
+// game is one round on a board. Cells hold the seat (0 or 1) that played
+// there, or empty.
+type game struct {
+	variant   variant
+	cells     []int
+	starter   int
+	turn      int
+	moves     int
+	over      bool
+	winner    int
+	forfeited bool
+	line      []int
+}
+
+func newGame(v variant, starter int) *game {
+	cells := make([]int, v.Rows*v.Cols)
+	for i := range cells {
+		cells[i] = empty
+	}
+	return &game{variant: v, cells: cells, starter: starter, turn: starter, winner: empty}
+}
+
+func (g *game) at(row, col int) int {
+	return g.cells[row*g.variant.Cols+col]
+}
+
+// move plays for seat. Gravity games ignore row.
+func (g *game) move(seat, row, col int) error {
+	if g.over {
+		return errGameOver
+	}
+	if seat != g.turn {
+		return errNotYourTurn
+	}
+	if col < 0 || col >= g.variant.Cols {
+		return errOffBoard
+	}
+	if g.variant.Gravity {
+		row = -1
+		for r := g.variant.Rows - 1; r >= 0; r-- {
+			if g.at(r, col) == empty {
+				row = r
+				break
+			}
+		}
+		if row < 0 {
+			return errColumnFull
+		}
+	} else if row < 0 || row >= g.variant.Rows {
+		return errOffBoard
+	} else if g.at(row, col) != empty {
+		return errOccupied
+	}
+
+	g.cells[row*g.variant.Cols+col] = seat
+	g.moves++
+	if line := g.lineThrough(row, col); line != nil {
+		g.over = true
+		g.winner = seat
+		g.line = line
+		return nil
+	}
+	if g.moves == len(g.cells) {
+		g.over = true
+		return nil
+	}
+	g.turn = 1 - seat
+	return nil
+}
+
+// lineThrough returns the cells of a winning line through row, col, if the
+// piece there completed one.
+func (g *game) lineThrough(row, col int) []int {
+	seat := g.at(row, col)
+	for _, dir := range [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+		line := []int{row*g.variant.Cols + col}
+		for _, sign := range []int{1, -1} {
+			r, c := row+sign*dir[0], col+sign*dir[1]
+			for r >= 0 && r < g.variant.Rows && c >= 0 && c < g.variant.Cols && g.at(r, c) == seat {
+				line = append(line, r*g.variant.Cols+c)
+				r, c = r+sign*dir[0], c+sign*dir[1]
+			}
+		}
+		if len(line) >= g.variant.Need {
+			return line
+		}
+	}
+	return nil
+}
+
+// forfeit ends the game in the other seat's favor.
+func (g *game) forfeit(seat int) {
+	if g.over {
+		return
+	}
+	g.over = true
+	g.winner = 1 - seat
+	g.forfeited = true
+}
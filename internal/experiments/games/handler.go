@@ -0,0 +1,329 @@
+package games
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const roomsEvent = "games-rooms"
+
+var (
+	rooms = make(map[string]*room)
+	mu    sync.RWMutex
+)
+
+func boardEvent(id string) string {
+	return "game-" + id
+}
+
+func newOriginatorID() string {
+	return fmt.Sprintf("games-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+}
+
+// gone reports whether a player has closed the page they joined from.
+func gone(hub *sse.Hub) func(string) bool {
+	return func(originatorID string) bool {
+		return originatorID != "" && !hub.Connected(originatorID)
+	}
+}
+
+func variantList() []experiments.GameVariant {
+	list := make([]experiments.GameVariant, 0, len(variants))
+	for _, v := range variants {
+		list = append(list, experiments.GameVariant{ID: v.ID, Name: v.Name})
+	}
+	return list
+}
+
+func GamesHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		data := experiments.GamesPageData{
+			Variants:     variantList(),
+			Rooms:        summaries(),
+			OriginatorID: newOriginatorID(),
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.GamesPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.GamesPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// RoomHandler shows a room. Visitors arriving by link have a fresh
+// originator ID, so they start out spectating.
+func RoomHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		r, exists := rooms[c.Param("id")]
+		if !exists {
+			return c.String(404, "Room not found")
+		}
+
+		data := experiments.GameRoomPageData{
+			Room:         roomData(r, c.Request().Header.Get("X-Originator-ID")),
+			OriginatorID: newOriginatorID(),
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.GameRoomPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.GameRoomPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// PlayHandler seats the caller opposite the longest-waiting player of the
+// chosen game, or opens a new room for them to wait in. The room replaces
+// the lobby in place so the page keeps its originator ID.
+func PlayHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		if originatorID == "" {
+			return c.String(400, "Missing originator ID")
+		}
+		v, ok := lookupVariant(c.FormValue("variant"))
+		if !ok {
+			return c.String(400, "Unknown game")
+		}
+
+		mu.Lock()
+		r := findMatch(v, originatorID, gone(hub))
+		if r == nil {
+			sweep(gone(hub))
+			if len(rooms) >= settings.MaxRooms {
+				mu.Unlock()
+				return notice(c, "No room opened: "+errTooManyRooms.Error()+".")
+			}
+			id, err := newRoomID()
+			if err != nil {
+				mu.Unlock()
+				return c.String(500, "Error creating room")
+			}
+			r = newRoom(id, v)
+			rooms[id] = r
+		}
+		if _, err := r.join(originatorID, gone(hub)); err != nil {
+			mu.Unlock()
+			return notice(c, "Couldn't join: "+err.Error()+".")
+		}
+		data := roomData(r, originatorID)
+		list := summaries()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if err := broadcastBoard(ctx, hub, originatorID, data.Board); err != nil {
+			return c.String(500, "Error generating board HTML")
+		}
+		if err := broadcastRooms(ctx, hub, list); err != nil {
+			return c.String(500, "Error generating room list HTML")
+		}
+
+		c.Response().Header().Set("HX-Push-Url", "/experiments/games/rooms/"+r.ID)
+		return experiments.GameRoom(data).Render(ctx, c.Response().Writer)
+	}
+}
+
+// JoinHandler takes an open seat, or one whose player has left the page.
+func JoinHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		if originatorID == "" {
+			return c.String(400, "Missing originator ID")
+		}
+
+		mu.Lock()
+		r, exists := rooms[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Room not found")
+		}
+		if _, err := r.join(originatorID, gone(hub)); err != nil {
+			mu.Unlock()
+			return notice(c, "Couldn't join: "+err.Error()+".")
+		}
+		data := roomData(r, originatorID)
+		list := summaries()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if err := broadcastBoard(ctx, hub, originatorID, data.Board); err != nil {
+			return c.String(500, "Error generating board HTML")
+		}
+		if err := broadcastRooms(ctx, hub, list); err != nil {
+			return c.String(500, "Error generating room list HTML")
+		}
+		return experiments.GameRoom(data).Render(ctx, c.Response().Writer)
+	}
+}
+
+// MoveHandler plays for the caller's seat. The server decides whose turn it
+// is and whether the move is legal; the mover gets the new board and
+// everyone else in the room gets it over SSE.
+func MoveHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		row, err := strconv.Atoi(c.FormValue("row"))
+		if err != nil {
+			return c.String(400, "Invalid row")
+		}
+		col, err := strconv.Atoi(c.FormValue("col"))
+		if err != nil {
+			return c.String(400, "Invalid column")
+		}
+
+		mu.Lock()
+		r, exists := rooms[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Room not found")
+		}
+		if err := r.move(r.seatOf(originatorID), row, col); err != nil {
+			mu.Unlock()
+			return notice(c, "Move not played: "+err.Error()+".")
+		}
+		board := r.board()
+		over := r.game.over
+		list := summaries()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if err := broadcastBoard(ctx, hub, originatorID, board); err != nil {
+			return c.String(500, "Error generating board HTML")
+		}
+		if over {
+			if err := broadcastRooms(ctx, hub, list); err != nil {
+				return c.String(500, "Error generating room list HTML")
+			}
+		}
+		return experiments.GameMoved(board).Render(ctx, c.Response().Writer)
+	}
+}
+
+// RematchHandler records that the caller wants another round. The round
+// starts once both players have asked, with the other player going first.
+func RematchHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		mu.Lock()
+		r, exists := rooms[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Room not found")
+		}
+		if err := r.requestRematch(r.seatOf(originatorID)); err != nil {
+			mu.Unlock()
+			return notice(c, "No rematch: "+err.Error()+".")
+		}
+		board := r.board()
+		list := summaries()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if err := broadcastBoard(ctx, hub, originatorID, board); err != nil {
+			return c.String(500, "Error generating board HTML")
+		}
+		if err := broadcastRooms(ctx, hub, list); err != nil {
+			return c.String(500, "Error generating room list HTML")
+		}
+		return experiments.GameMoved(board).Render(ctx, c.Response().Writer)
+	}
+}
+
+// LeaveHandler gives up the caller's seat. Leaving mid-game forfeits it.
+func LeaveHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		mu.Lock()
+		r, exists := rooms[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Room not found")
+		}
+		seat := r.seatOf(originatorID)
+		if seat == empty {
+			mu.Unlock()
+			return notice(c, "You're not playing in this room.")
+		}
+		r.leave(seat)
+		board := r.board()
+		viewer := r.seat(originatorID)
+		list := summaries()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if err := broadcastBoard(ctx, hub, "", board); err != nil {
+			return c.String(500, "Error generating board HTML")
+		}
+		if err := broadcastRooms(ctx, hub, list); err != nil {
+			return c.String(500, "Error generating room list HTML")
+		}
+		return experiments.GameSeat(viewer).Render(ctx, c.Response().Writer)
+	}
+}
+
+func roomData(r *room, originatorID string) experiments.GameRoomData {
+	return experiments.GameRoomData{
+		Name:  r.variant.Name,
+		Board: r.board(),
+		Seat:  r.seat(originatorID),
+	}
+}
+
+func broadcastBoard(ctx context.Context, hub *sse.Hub, originatorID string, board experiments.GameBoardData) error {
+	var builder strings.Builder
+	if err := experiments.GameBoard(board).Render(ctx, &builder); err != nil {
+		return err
+	}
+	hub.Broadcast(sse.Event{
+		Name:      boardEvent(board.RoomID),
+		Data:      builder.String(),
+		ExcludeID: originatorID,
+		Topic:     Topic,
+	})
+	return nil
+}
+
+func broadcastRooms(ctx context.Context, hub *sse.Hub, list []experiments.GameRoomSummary) error {
+	var builder strings.Builder
+	if err := experiments.GameRoomList(list).Render(ctx, &builder); err != nil {
+		return err
+	}
+	hub.Broadcast(sse.Event{Name: roomsEvent, Data: builder.String(), Topic: Topic})
+	return nil
+}
+
+// notice shows a message above the board instead of swapping the element
+// the request targets.
+func notice(c echo.Context, message string) error {
+	c.Response().Header().Set("HX-Retarget", "#game-notice")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return experiments.GameNotice(message).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,278 @@
+package games
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	"hypermedia-sync/internal/templates/experiments"
+)
+
+const (
+	// idleTimeout is how long a room may go without a move or join before
+	// it is swept.
+	idleTimeout = time.Hour
+)
+
+var (
+	errWaiting      = errors.New("waiting for an opponent")
+	errSpectator    = errors.New("spectators can't play")
+	errSeatsTaken   = errors.New("both seats are taken")
+	errNotOver      = errors.New("the game isn't over yet")
+	errTooManyRooms = errors.New("there are too many rooms")
+)
+
+// room seats two players, identified by the originator ID of the page they
+// joined from, and keeps score across rematches.
+type room struct {
+	ID      string
+	variant variant
+	seats   [2]string
+	game    *game
+	scores  [2]int
+	draws   int
+	rematch [2]bool
+	created time.Time
+	updated time.Time
+}
+
+func newRoomID() (string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func newRoom(id string, v variant) *room {
+	now := time.Now()
+	return &room{ID: id, variant: v, game: newGame(v, 0), created: now, updated: now}
+}
+
+// seatOf returns the seat held by originatorID, or empty.
+func (r *room) seatOf(originatorID string) int {
+	for seat, holder := range r.seats {
+		if originatorID != "" && holder == originatorID {
+			return seat
+		}
+	}
+	return empty
+}
+
+func (r *room) full() bool {
+	return r.seats[0] != "" && r.seats[1] != ""
+}
+
+// join seats originatorID in a free seat. A seat whose holder has gone away
+// counts as free; if they were mid-game, they forfeit it.
+func (r *room) join(originatorID string, gone func(string) bool) (int, error) {
+	if seat := r.seatOf(originatorID); seat != empty {
+		return seat, nil
+	}
+	seat := empty
+	for s, holder := range r.seats {
+		if holder == "" {
+			seat = s
+			break
+		}
+	}
+	if seat == empty {
+		for s, holder := range r.seats {
+			if gone(holder) {
+				r.leave(s)
+				seat = s
+				break
+			}
+		}
+	}
+	if seat == empty {
+		return empty, errSeatsTaken
+	}
+
+	r.seats[seat] = originatorID
+	r.updated = time.Now()
+	if r.full() && r.game.over {
+		r.newRound()
+	}
+	return seat, nil
+}
+
+// leave empties a seat, forfeiting a game in progress.
+func (r *room) leave(seat int) {
+	if r.full() && !r.game.over && r.game.moves > 0 {
+		r.game.forfeit(seat)
+		r.record()
+	}
+	r.seats[seat] = ""
+	r.rematch = [2]bool{}
+	r.updated = time.Now()
+}
+
+func (r *room) move(seat, row, col int) error {
+	if seat == empty {
+		return errSpectator
+	}
+	if !r.full() {
+		return errWaiting
+	}
+	if err := r.game.move(seat, row, col); err != nil {
+		return err
+	}
+	r.updated = time.Now()
+	if r.game.over {
+		r.record()
+	}
+	return nil
+}
+
+// requestRematch starts a new round once both players have asked for one.
+func (r *room) requestRematch(seat int) error {
+	if seat == empty {
+		return errSpectator
+	}
+	if !r.game.over {
+		return errNotOver
+	}
+	if !r.full() {
+		return errWaiting
+	}
+	r.rematch[seat] = true
+	r.updated = time.Now()
+	if r.rematch[0] && r.rematch[1] {
+		r.newRound()
+	}
+	return nil
+}
+
+// newRound alternates who moves first.
+func (r *room) newRound() {
+	r.game = newGame(r.variant, 1-r.game.starter)
+	r.rematch = [2]bool{}
+}
+
+func (r *room) record() {
+	if r.game.winner == empty {
+		r.draws++
+	} else {
+		r.scores[r.game.winner]++
+	}
+}
+
+func (r *room) status() string {
+	g := r.game
+	switch {
+	case g.over && g.winner == empty:
+		return "Draw"
+	case g.over && g.forfeited:
+		return r.variant.Marks[g.winner] + " wins by forfeit"
+	case g.over:
+		return r.variant.Marks[g.winner] + " wins"
+	case !r.full():
+		return "Waiting for an opponent"
+	}
+	return r.variant.Marks[g.turn] + " to move"
+}
+
+func (r *room) summary() experiments.GameRoomSummary {
+	return experiments.GameRoomSummary{ID: r.ID, Name: r.variant.Name, Status: r.status()}
+}
+
+func (r *room) board() experiments.GameBoardData {
+	g := r.game
+	data := experiments.GameBoardData{
+		RoomID:  r.ID,
+		Variant: r.variant.ID,
+		Gravity: r.variant.Gravity,
+		Status:  r.status(),
+		Playing: r.full() && !g.over,
+		Over:    g.over,
+		Open:    !r.full(),
+		Draws:   r.draws,
+	}
+	for seat := range data.Players {
+		data.Players[seat] = experiments.GamePlayer{
+			Mark:    r.variant.Marks[seat],
+			Seated:  r.seats[seat] != "",
+			Score:   r.scores[seat],
+			ToMove:  data.Playing && g.turn == seat,
+			Rematch: r.rematch[seat],
+		}
+	}
+	winning := make(map[int]bool, len(g.line))
+	for _, i := range g.line {
+		winning[i] = true
+	}
+	data.Cells = make([][]experiments.GameCell, r.variant.Rows)
+	for row := range data.Cells {
+		data.Cells[row] = make([]experiments.GameCell, r.variant.Cols)
+		for col := range data.Cells[row] {
+			i := row*r.variant.Cols + col
+			data.Cells[row][col] = experiments.GameCell{Row: row, Col: col, Seat: g.cells[i], Winning: winning[i]}
+		}
+	}
+	return data
+}
+
+func (r *room) seat(originatorID string) experiments.GameSeatData {
+	seat := r.seatOf(originatorID)
+	data := experiments.GameSeatData{RoomID: r.ID, Seat: seat}
+	if seat != empty {
+		data.Mark = r.variant.Marks[seat]
+	}
+	return data
+}
+
+// findMatch returns a room of the given variant waiting for a second
+// player, skipping rooms whose only player has gone away.
+func findMatch(v variant, originatorID string, gone func(string) bool) *room {
+	var match *room
+	for _, r := range rooms {
+		if r.variant.ID != v.ID {
+			continue
+		}
+		if r.seatOf(originatorID) != empty && !r.full() {
+			return r
+		}
+		if r.full() || r.seatOf(originatorID) != empty {
+			continue
+		}
+		waiting := r.seats[0]
+		if waiting == "" {
+			waiting = r.seats[1]
+		}
+		if waiting == "" || gone(waiting) {
+			continue
+		}
+		if match == nil || r.created.Before(match.created) {
+			match = r
+		}
+	}
+	return match
+}
+
+// sweep drops rooms that have been idle too long or that nobody is seated
+// in any more.
+func sweep(gone func(string) bool) {
+	for id, r := range rooms {
+		abandoned := (r.seats[0] == "" || gone(r.seats[0])) && (r.seats[1] == "" || gone(r.seats[1]))
+		if abandoned || time.Since(r.updated) > idleTimeout {
+			delete(rooms, id)
+		}
+	}
+}
+
+func summaries() []experiments.GameRoomSummary {
+	list := make([]*room, 0, len(rooms))
+	for _, r := range rooms {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].created.After(list[j].created) })
+
+	result := make([]experiments.GameRoomSummary, 0, len(list))
+	for _, r := range list {
+		result = append(result, r.summary())
+	}
+	return result
+}
@@ -0,0 +1,20 @@
+package games
+
+// Settings controls how many rooms can be open at once.
+type Settings struct {
+	// MaxRooms caps how many rooms exist before creating more is refused.
+	MaxRooms int
+}
+
+func DefaultSettings() Settings {
+	return Settings{MaxRooms: 100}
+}
+
+var settings = DefaultSettings()
+
+// configure sets how many rooms may be open before new ones are refused.
+func configure(s Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+}
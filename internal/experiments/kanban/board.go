@@ -0,0 +1,177 @@
+package kanban
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"hypermedia-sync/internal/templates/experiments"
+)
+
+const (
+	maxTitleLength       = 120
+	maxDescriptionLength = 1000
+)
+
+var (
+	errNotFound = errors.New("card not found")
+	// errConflict means the client's version of a card is stale.
+	errConflict = errors.New("card was changed by someone else")
+)
+
+type card struct {
+	ID          int
+	Title       string
+	Description string
+	Column      string
+	// Version increments on every edit or move and is checked against the
+	// version the client last saw.
+	Version int
+}
+
+type column struct {
+	ID    string
+	Title string
+	Cards []int
+}
+
+type board struct {
+	columns []*column
+	cards   map[int]*card
+	nextID  int
+}
+
+func newBoard() *board {
+	return &board{
+		columns: []*column{
+			{ID: "todo", Title: "To Do"},
+			{ID: "doing", Title: "In Progress"},
+			{ID: "done", Title: "Done"},
+		},
+		cards:  make(map[int]*card),
+		nextID: 1,
+	}
+}
+
+func (b *board) column(id string) *column {
+	for _, col := range b.columns {
+		if col.ID == id {
+			return col
+		}
+	}
+	return nil
+}
+
+func validateCard(title, description string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+	if title == "" {
+		return "", "", errors.New("cards need a title")
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", "", fmt.Errorf("titles are limited to %d characters", maxTitleLength)
+	}
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return "", "", fmt.Errorf("descriptions are limited to %d characters", maxDescriptionLength)
+	}
+	return title, description, nil
+}
+
+func (b *board) create(columnID, title, description string) (*card, error) {
+	col := b.column(columnID)
+	if col == nil {
+		return nil, errors.New("unknown column")
+	}
+	if len(b.cards) >= settings.MaxCards {
+		return nil, errors.New("the board is full")
+	}
+	title, description, err := validateCard(title, description)
+	if err != nil {
+		return nil, err
+	}
+	c := &card{ID: b.nextID, Title: title, Description: description, Column: col.ID, Version: 1}
+	b.nextID++
+	b.cards[c.ID] = c
+	col.Cards = append(col.Cards, c.ID)
+	return c, nil
+}
+
+func (b *board) edit(id, version int, title, description string) (*card, error) {
+	c, ok := b.cards[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	if c.Version != version {
+		return c, errConflict
+	}
+	title, description, err := validateCard(title, description)
+	if err != nil {
+		return c, err
+	}
+	c.Title = title
+	c.Description = description
+	c.Version++
+	return c, nil
+}
+
+// move places a card at position in the destination column, clamping the
+// position to the column's length. It returns the card and the IDs of the
+// columns that changed.
+func (b *board) move(id, version int, columnID string, position int) (*card, []string, error) {
+	c, ok := b.cards[id]
+	if !ok {
+		return nil, nil, errNotFound
+	}
+	dest := b.column(columnID)
+	if dest == nil {
+		return c, nil, errors.New("unknown column")
+	}
+	if c.Version != version {
+		return c, nil, errConflict
+	}
+
+	src := b.column(c.Column)
+	for i, cardID := range src.Cards {
+		if cardID == id {
+			src.Cards = append(src.Cards[:i:i], src.Cards[i+1:]...)
+			break
+		}
+	}
+	position = min(max(position, 0), len(dest.Cards))
+	dest.Cards = append(dest.Cards[:position:position], append([]int{id}, dest.Cards[position:]...)...)
+	c.Column = dest.ID
+	c.Version++
+
+	if src == dest {
+		return c, []string{dest.ID}, nil
+	}
+	return c, []string{src.ID, dest.ID}, nil
+}
+
+func (c *card) view() experiments.KanbanCard {
+	return experiments.KanbanCard{
+		ID:          c.ID,
+		Title:       c.Title,
+		Description: c.Description,
+		Column:      c.Column,
+		Version:     c.Version,
+	}
+}
+
+func (b *board) columnView(id string) experiments.KanbanColumn {
+	col := b.column(id)
+	view := experiments.KanbanColumn{ID: col.ID, Title: col.Title}
+	for _, cardID := range col.Cards {
+		view.Cards = append(view.Cards, b.cards[cardID].view())
+	}
+	return view
+}
+
+func (b *board) view() []experiments.KanbanColumn {
+	views := make([]experiments.KanbanColumn, 0, len(b.columns))
+	for _, col := range b.columns {
+		views = append(views, b.columnView(col.ID))
+	}
+	return views
+}
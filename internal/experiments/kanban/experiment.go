@@ -0,0 +1,63 @@
+package kanban
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic card and column updates are broadcast on.
+const Topic = "kanban"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New returns a board holding up to s.MaxCards cards.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "kanban",
+		Name:        "Kanban Board",
+		Description: "A shared kanban board where the server owns every card, and stale drags are rejected using per-card versions",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", BoardHandler(e.hub))
+	g.POST("/cards", CreateCardHandler(e.hub))
+	g.GET("/cards/:id", CardHandler())
+	g.GET("/cards/:id/edit", EditCardHandler())
+	g.POST("/cards/:id", UpdateCardHandler(e.hub))
+	g.POST("/cards/:id/move", MoveCardHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the board can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, mu.TryRLock, mu.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop empties the board.
+func (e *Experiment) Stop(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	kanban = newBoard()
+	return nil
+}
@@ -0,0 +1,245 @@
+package kanban
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	kanban = newBoard()
+	mu     sync.RWMutex
+)
+
+func columnEvent(id string) string {
+	return "kanban-column-" + id
+}
+
+func cardEvent(id int) string {
+	return fmt.Sprintf("kanban-card-%d", id)
+}
+
+func BoardHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		originatorID := fmt.Sprintf("kanban-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+
+		data := experiments.KanbanPageData{
+			Columns:      kanban.view(),
+			OriginatorID: originatorID,
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.KanbanPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.KanbanPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// CreateCardHandler adds a card to the bottom of a column and re-renders
+// only that column.
+func CreateCardHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		mu.Lock()
+		created, err := kanban.create(c.FormValue("column"), c.FormValue("title"), c.FormValue("description"))
+		if err != nil {
+			mu.Unlock()
+			return notice(c, "Card not created: "+err.Error()+".")
+		}
+		col := kanban.columnView(created.Column)
+		mu.Unlock()
+
+		html, err := broadcastColumns(c.Request().Context(), hub, originatorID, col)
+		if err != nil {
+			return c.String(500, "Error generating column HTML")
+		}
+		return c.HTML(200, html)
+	}
+}
+
+func CardHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "Invalid card ID")
+		}
+		view, ok := lookupCard(id)
+		if !ok {
+			return c.String(404, "Card not found")
+		}
+		return experiments.KanbanCardView(view).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func EditCardHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "Invalid card ID")
+		}
+		view, ok := lookupCard(id)
+		if !ok {
+			return c.String(404, "Card not found")
+		}
+		return experiments.KanbanCardEdit(view).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+func lookupCard(id int) (experiments.KanbanCard, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	found, ok := kanban.cards[id]
+	if !ok {
+		return experiments.KanbanCard{}, false
+	}
+	return found.view(), true
+}
+
+// UpdateCardHandler saves an edit if the client saw the latest version of
+// the card. On a conflict the editor gets the current card instead.
+func UpdateCardHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "Invalid card ID")
+		}
+		version, err := strconv.Atoi(c.FormValue("version"))
+		if err != nil {
+			return c.String(400, "Invalid card version")
+		}
+
+		mu.Lock()
+		updated, err := kanban.edit(id, version, c.FormValue("title"), c.FormValue("description"))
+		var view experiments.KanbanCard
+		if updated != nil {
+			view = updated.view()
+		}
+		mu.Unlock()
+
+		switch {
+		case errors.Is(err, errNotFound):
+			return c.String(404, "Card not found")
+		case errors.Is(err, errConflict):
+			view.Notice = "Someone else changed this card first. This is the latest version."
+			return experiments.KanbanCardView(view).Render(c.Request().Context(), c.Response().Writer)
+		case err != nil:
+			view.Notice = "Not saved: " + err.Error() + "."
+			return experiments.KanbanCardEdit(view).Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		var sseBuilder strings.Builder
+		if err := experiments.KanbanCardView(view).Render(c.Request().Context(), &sseBuilder); err != nil {
+			return c.String(500, "Error generating SSE HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name:      cardEvent(id),
+			Data:      sseBuilder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+		return c.HTML(200, sseBuilder.String())
+	}
+}
+
+// MoveCardHandler moves a card if the client saw its latest version and
+// re-renders the affected columns. A stale drag gets the current state of
+// the columns involved instead, so the mover sees where the card really is.
+func MoveCardHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "Invalid card ID")
+		}
+		version, err := strconv.Atoi(c.FormValue("version"))
+		if err != nil {
+			return c.String(400, "Invalid card version")
+		}
+		position, err := strconv.Atoi(c.FormValue("position"))
+		if err != nil {
+			return c.String(400, "Invalid position")
+		}
+		dest := c.FormValue("column")
+
+		mu.Lock()
+		moved, changed, err := kanban.move(id, version, dest, position)
+		if err != nil {
+			var cols []experiments.KanbanColumn
+			if moved != nil {
+				cols = append(cols, kanban.columnView(moved.Column))
+				if kanban.column(dest) != nil && dest != moved.Column {
+					cols = append(cols, kanban.columnView(dest))
+				}
+			}
+			mu.Unlock()
+
+			if errors.Is(err, errNotFound) {
+				return c.String(404, "Card not found")
+			}
+			message := "Card not moved: " + err.Error() + "."
+			if errors.Is(err, errConflict) {
+				message = "Someone else moved or changed that card first. The board shows where it is now."
+			}
+			return experiments.KanbanColumnsOOB(cols, message).Render(c.Request().Context(), c.Response().Writer)
+		}
+		cols := make([]experiments.KanbanColumn, 0, len(changed))
+		for _, colID := range changed {
+			cols = append(cols, kanban.columnView(colID))
+		}
+		mu.Unlock()
+
+		if _, err := broadcastColumns(c.Request().Context(), hub, originatorID, cols...); err != nil {
+			return c.String(500, "Error generating column HTML")
+		}
+		return experiments.KanbanColumnsOOB(cols, "").Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// broadcastColumns sends each column to everyone but the originator and
+// returns the HTML of the last one.
+func broadcastColumns(ctx context.Context, hub *sse.Hub, originatorID string, cols ...experiments.KanbanColumn) (string, error) {
+	var html string
+	for _, col := range cols {
+		var builder strings.Builder
+		if err := experiments.KanbanColumnView(col).Render(ctx, &builder); err != nil {
+			return "", err
+		}
+		html = builder.String()
+		hub.Broadcast(sse.Event{
+			Name:      columnEvent(col.ID),
+			Data:      html,
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+	}
+	return html, nil
+}
+
+// notice shows a message above the board instead of swapping the element
+// the request targets.
+func notice(c echo.Context, message string) error {
+	c.Response().Header().Set("HX-Retarget", "#kanban-notice")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return experiments.KanbanNotice(message).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,20 @@
+package kanban
+
+// Settings controls the size of the board.
+type Settings struct {
+	// MaxCards caps how many cards the board holds.
+	MaxCards int
+}
+
+func DefaultSettings() Settings {
+	return Settings{MaxCards: 500}
+}
+
+var settings = DefaultSettings()
+
+// configure sets the card limit checked when cards are added.
+func configure(s Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+}
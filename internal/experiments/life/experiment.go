@@ -0,0 +1,67 @@
+package life
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic generations and control changes are broadcast on.
+const Topic = "life"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New returns the Game of Life board, starting at s.Speed.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "life",
+		Name:        "Game of Life",
+		Description: "A shared Conway's Game of Life board advanced by a server-side ticker, with pattern stamping and speed controls",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", LifeHandler(e.hub))
+	g.GET("/grid", GridHandler())
+	g.POST("/cells", CellsHandler(e.hub))
+	g.POST("/speed", SpeedHandler(e.hub))
+	g.POST("/:action", ControlHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the board can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, sim.TryLock, sim.Unlock)
+}
+
+// Start runs the ticker until ctx is canceled.
+func (e *Experiment) Start(ctx context.Context) error {
+	go run(ctx, e.hub)
+	return nil
+}
+
+// Stop puts the board back to its starting pattern.
+func (e *Experiment) Stop(ctx context.Context) error {
+	sim.Lock()
+	defer sim.Unlock()
+	sim.cells = seed()
+	sim.generation = 0
+	sim.running = true
+	sim.speed = settings.Speed
+	return nil
+}
@@ -0,0 +1,145 @@
+package life
+
+const (
+	cols = 48
+	rows = 32
+)
+
+// grid is a torus: cells past an edge wrap around to the other side.
+type grid []bool
+
+func index(row, col int) int {
+	row = (row%rows + rows) % rows
+	col = (col%cols + cols) % cols
+	return row*cols + col
+}
+
+func (g grid) next() grid {
+	out := make(grid, len(g))
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			neighbors := 0
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					if (dr != 0 || dc != 0) && g[index(row+dr, col+dc)] {
+						neighbors++
+					}
+				}
+			}
+			alive := g[index(row, col)]
+			out[index(row, col)] = neighbors == 3 || (alive && neighbors == 2)
+		}
+	}
+	return out
+}
+
+// diff returns the indexes of cells that differ between g and other.
+func (g grid) diff(other grid) []int {
+	var changed []int
+	for i := range g {
+		if g[i] != other[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+func (g grid) population() int {
+	n := 0
+	for _, alive := range g {
+		if alive {
+			n++
+		}
+	}
+	return n
+}
+
+// pattern is drawn with O for live cells, one string per row.
+type pattern struct {
+	ID    string
+	Name  string
+	Shape []string
+}
+
+var patterns = []pattern{
+	{ID: "cell", Name: "Single cell (toggle)", Shape: []string{"O"}},
+	{ID: "glider", Name: "Glider", Shape: []string{
+		".O.",
+		"..O",
+		"OOO",
+	}},
+	{ID: "lwss", Name: "Lightweight spaceship", Shape: []string{
+		".O..O",
+		"O....",
+		"O...O",
+		"OOOO.",
+	}},
+	{ID: "rpentomino", Name: "R-pentomino", Shape: []string{
+		".OO",
+		"OO.",
+		".O.",
+	}},
+	{ID: "pulsar", Name: "Pulsar", Shape: []string{
+		"..OOO...OOO..",
+		".............",
+		"O....O.O....O",
+		"O....O.O....O",
+		"O....O.O....O",
+		"..OOO...OOO..",
+		".............",
+		"..OOO...OOO..",
+		"O....O.O....O",
+		"O....O.O....O",
+		"O....O.O....O",
+		".............",
+		"..OOO...OOO..",
+	}},
+	{ID: "gun", Name: "Gosper glider gun", Shape: []string{
+		"........................O...........",
+		"......................O.O...........",
+		"............OO......OO............OO",
+		"...........O...O....OO............OO",
+		"OO........O.....O...OO..............",
+		"OO........O...O.OO....O.O...........",
+		"..........O.....O.......O...........",
+		"...........O...O....................",
+		"............OO......................",
+	}},
+}
+
+func lookupPattern(id string) (pattern, bool) {
+	for _, p := range patterns {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return pattern{}, false
+}
+
+// stamp brings the pattern's live cells to life, centered on row, col, and
+// returns the cells that changed.
+func (g grid) stamp(p pattern, row, col int) []int {
+	top := row - len(p.Shape)/2
+	left := col - len(p.Shape[0])/2
+	var changed []int
+	for r, line := range p.Shape {
+		for c, ch := range line {
+			i := index(top+r, left+c)
+			if ch == 'O' && !g[i] {
+				g[i] = true
+				changed = append(changed, i)
+			}
+		}
+	}
+	return changed
+}
+
+func seed() grid {
+	g := make(grid, rows*cols)
+	pulsar, _ := lookupPattern("pulsar")
+	glider, _ := lookupPattern("glider")
+	g.stamp(pulsar, rows/2, cols/2)
+	g.stamp(glider, 3, 3)
+	g.stamp(glider, 3, cols-12)
+	return g
+}
@@ -0,0 +1,302 @@
+package life
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	frameEvent    = "life-frame"
+	controlsEvent = "life-controls"
+)
+
+// speeds are the generations per second users can pick from.
+var speeds = []int{1, 2, 5, 10}
+
+// sim is the shared board. Every change, whether a generation or an edit,
+// is published as a numbered frame holding only the cells that changed, so
+// clients can apply frames in order and notice when they've missed one.
+var sim = struct {
+	sync.Mutex
+	cells      grid
+	generation int
+	frame      int
+	running    bool
+	speed      int
+}{cells: seed(), running: true, speed: settings.Speed}
+
+// wake tells the ticker the speed or running state changed.
+var wake = make(chan struct{}, 1)
+
+// errorLog keeps a failing tick from logging every generation.
+var errorLog = logging.NewSampler(10 * time.Second)
+
+func notifyTicker() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func interval() time.Duration {
+	sim.Lock()
+	defer sim.Unlock()
+	return time.Second / time.Duration(sim.speed)
+}
+
+// run advances the simulation until ctx is done.
+func run(ctx context.Context, hub *sse.Hub) {
+	ticker := time.NewTicker(interval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+			ticker.Reset(interval())
+		case <-ticker.C:
+			var event *sse.Event
+			var err error
+			sim.Lock()
+			if sim.running {
+				event, err = step(ctx, hub)
+			}
+			sim.Unlock()
+			if err != nil {
+				errorLog.Log(ctx, slog.Default(), slog.LevelError, "step", "publishing life generation", "experiment", "life", "err", err)
+			}
+			broadcast(hub, event)
+		}
+	}
+}
+
+// step advances one generation. The caller holds sim's lock.
+func step(ctx context.Context, hub *sse.Hub) (*sse.Event, error) {
+	next := sim.cells.next()
+	changed := sim.cells.diff(next)
+	sim.cells = next
+	sim.generation++
+	return publish(ctx, hub, changed)
+}
+
+// publish numbers the changed cells as the next frame and renders it. The
+// caller holds sim's lock and broadcasts the event after releasing it;
+// clients put frames back in order by number. With nobody subscribed the
+// frame is numbered but not rendered, and the event is nil, so a page that
+// loaded before then sees the gap and reloads the grid.
+func publish(ctx context.Context, hub *sse.Hub, changed []int) (*sse.Event, error) {
+	sim.frame++
+	if !hub.HasSubscribers(Topic) {
+		return nil, nil
+	}
+	frame := experiments.LifeFrame{
+		Frame: sim.frame,
+		Cells: make([]experiments.LifeCell, 0, len(changed)),
+		Stats: stats(),
+	}
+	for _, i := range changed {
+		frame.Cells = append(frame.Cells, experiments.LifeCell{Index: i, Alive: sim.cells[i]})
+	}
+
+	var builder strings.Builder
+	if err := experiments.LifeFrameUpdate(frame).Render(ctx, &builder); err != nil {
+		return nil, err
+	}
+	return &sse.Event{Name: frameEvent, Data: builder.String(), Topic: Topic}, nil
+}
+
+func broadcast(hub *sse.Hub, event *sse.Event) {
+	if event != nil {
+		hub.Broadcast(*event)
+	}
+}
+
+func stats() experiments.LifeStats {
+	return experiments.LifeStats{Generation: sim.generation, Population: sim.cells.population()}
+}
+
+func gridData() experiments.LifeGridData {
+	data := experiments.LifeGridData{
+		Cols:  cols,
+		Frame: sim.frame,
+		Cells: make([]experiments.LifeCell, len(sim.cells)),
+	}
+	for i, alive := range sim.cells {
+		data.Cells[i] = experiments.LifeCell{Index: i, Alive: alive}
+	}
+	return data
+}
+
+// speedChoices is speeds plus the configured speed if it isn't one of them.
+func speedChoices() []int {
+	if slices.Contains(speeds, settings.Speed) {
+		return speeds
+	}
+	choices := append(slices.Clone(speeds), settings.Speed)
+	slices.Sort(choices)
+	return choices
+}
+
+func controls() experiments.LifeControls {
+	return experiments.LifeControls{Running: sim.running, Speed: sim.speed, Speeds: speedChoices()}
+}
+
+func LifeHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		originatorID := fmt.Sprintf("life-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+
+		sim.Lock()
+		data := experiments.LifePageData{
+			Grid:         gridData(),
+			Stats:        stats(),
+			Controls:     controls(),
+			OriginatorID: originatorID,
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+		sim.Unlock()
+		for _, p := range patterns {
+			data.Patterns = append(data.Patterns, experiments.LifePattern{ID: p.ID, Name: p.Name})
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.LifePageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.LifePageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// GridHandler re-renders the whole grid for clients that missed a frame.
+func GridHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sim.Lock()
+		data := gridData()
+		sim.Unlock()
+		return experiments.LifeGrid(data).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// CellsHandler toggles a cell or stamps a pattern centered on it. The change
+// reaches everyone, the sender included, as the next frame.
+func CellsHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		i, err := strconv.Atoi(c.FormValue("cell"))
+		if err != nil || i < 0 || i >= rows*cols {
+			return c.String(400, "Invalid cell")
+		}
+		p, ok := lookupPattern(c.FormValue("pattern"))
+		if !ok {
+			p, _ = lookupPattern("cell")
+		}
+
+		var event *sse.Event
+		sim.Lock()
+		changed := []int{i}
+		if p.ID == "cell" {
+			sim.cells[i] = !sim.cells[i]
+		} else {
+			changed = sim.cells.stamp(p, i/cols, i%cols)
+		}
+		if len(changed) > 0 {
+			event, err = publish(c.Request().Context(), hub, changed)
+		}
+		sim.Unlock()
+		if err != nil {
+			return c.String(500, "Error generating frame HTML")
+		}
+		broadcast(hub, event)
+		return c.NoContent(204)
+	}
+}
+
+// ControlHandler pauses, resumes, steps, or clears the simulation.
+func ControlHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		var event *sse.Event
+		var err error
+
+		sim.Lock()
+		switch c.Param("action") {
+		case "pause":
+			sim.running = false
+		case "resume":
+			sim.running = true
+		case "step":
+			if !sim.running {
+				event, err = step(ctx, hub)
+			}
+		case "clear":
+			var changed []int
+			for i, alive := range sim.cells {
+				if alive {
+					sim.cells[i] = false
+					changed = append(changed, i)
+				}
+			}
+			event, err = publish(ctx, hub, changed)
+		default:
+			sim.Unlock()
+			return c.String(404, "Unknown action")
+		}
+		state := controls()
+		sim.Unlock()
+
+		if err != nil {
+			return c.String(500, "Error generating frame HTML")
+		}
+		broadcast(hub, event)
+		notifyTicker()
+		return broadcastControls(c, hub, state)
+	}
+}
+
+func SpeedHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		speed, err := strconv.Atoi(c.FormValue("speed"))
+		if err != nil || !slices.Contains(speedChoices(), speed) {
+			return c.String(400, "Invalid speed")
+		}
+
+		sim.Lock()
+		sim.speed = speed
+		state := controls()
+		sim.Unlock()
+
+		notifyTicker()
+		return broadcastControls(c, hub, state)
+	}
+}
+
+// broadcastControls sends the controls to everyone else and returns them to
+// the sender.
+func broadcastControls(c echo.Context, hub *sse.Hub, state experiments.LifeControls) error {
+	var builder strings.Builder
+	if err := experiments.LifeControlsView(state).Render(c.Request().Context(), &builder); err != nil {
+		return c.String(500, "Error generating controls HTML")
+	}
+	hub.Broadcast(sse.Event{
+		Name:      controlsEvent,
+		Data:      builder.String(),
+		ExcludeID: c.Request().Header.Get("X-Originator-ID"),
+		Topic:     Topic,
+	})
+	return c.HTML(200, builder.String())
+}
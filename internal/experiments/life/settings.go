@@ -0,0 +1,23 @@
+package life
+
+// Settings controls how fast the board runs.
+type Settings struct {
+	// Speed is the generations per second the board starts at and returns
+	// to when the experiment is reset. If it isn't one of the built-in
+	// choices it is offered alongside them.
+	Speed int
+}
+
+func DefaultSettings() Settings {
+	return Settings{Speed: 5}
+}
+
+var settings = DefaultSettings()
+
+// configure sets the starting speed and puts the board at it.
+func configure(s Settings) {
+	sim.Lock()
+	defer sim.Unlock()
+	settings = s
+	sim.speed = s.Speed
+}
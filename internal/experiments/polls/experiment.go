@@ -0,0 +1,63 @@
+package polls
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic poll results and updates are broadcast on.
+const Topic = "polls"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+// New returns the polls experiment, keeping at most s.MaxPolls polls.
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "polls",
+		Name:        "Live Polls",
+		Description: "Host single, multiple, or ranked choice polls and watch the result bars move as votes arrive",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", PollsHandler(e.hub))
+	g.POST("", CreatePollHandler(e.hub))
+	g.GET("/:id", PollHandler(e.hub))
+	g.POST("/:id/vote", VoteHandler(e.hub))
+	g.POST("/:id/close", ClosePollHandler(e.hub))
+	g.GET("/:id/results.csv", ExportCSVHandler())
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the polls can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, mu.TryRLock, mu.RUnlock)
+}
+
+func (e *Experiment) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop discards every poll.
+func (e *Experiment) Stop(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	polls = make(map[string]*poll)
+	return nil
+}
@@ -0,0 +1,85 @@
+package polls
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ExportCSVHandler downloads the current results. Ranked polls include
+// first-choice and final-round votes from the instant-runoff count and the
+// round each option was eliminated in; other polls include vote counts and
+// the share of voters who picked each option.
+func ExportCSVHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mu.RLock()
+		p, exists := polls[c.Param("id")]
+		if !exists {
+			mu.RUnlock()
+			return c.String(404, "Poll not found")
+		}
+		question, kind := p.Question, p.Kind
+		options := append([]string(nil), p.Options...)
+		var counts []int
+		var r runoff
+		if kind == KindRanked {
+			r = p.runoff()
+		} else {
+			counts = p.tally()
+		}
+		voters := len(p.ballots)
+		mu.RUnlock()
+
+		c.Response().Header().Set("Content-Type", "text/csv; charset=utf-8")
+		c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="poll-%s.csv"`, c.Param("id")))
+
+		w := csv.NewWriter(c.Response())
+		records := [][]string{
+			{"question", csvText(question)},
+			{"type", string(kind)},
+			{"voters", strconv.Itoa(voters)},
+		}
+		if kind == KindRanked {
+			winner := ""
+			if r.winner >= 0 {
+				winner = csvText(options[r.winner])
+			}
+			records = append(records,
+				[]string{"rounds", strconv.Itoa(r.rounds)},
+				[]string{"winner", winner},
+				[]string{},
+				[]string{"option", "first_choice_votes", "final_round_votes", "eliminated_in_round"},
+			)
+			for i, option := range options {
+				eliminated := ""
+				if r.eliminated[i] != 0 {
+					eliminated = strconv.Itoa(r.eliminated[i])
+				}
+				records = append(records, []string{csvText(option), strconv.Itoa(r.first[i]), strconv.Itoa(r.final[i]), eliminated})
+			}
+			return w.WriteAll(records)
+		}
+
+		records = append(records, []string{}, []string{"option", "votes", "percent_of_voters"})
+		for i, option := range options {
+			percent := 0.0
+			if voters > 0 {
+				percent = float64(counts[i]) / float64(voters) * 100
+			}
+			records = append(records, []string{csvText(option), strconv.Itoa(counts[i]), strconv.FormatFloat(percent, 'f', 1, 64)})
+		}
+		return w.WriteAll(records)
+	}
+}
+
+// csvText quotes user text that a spreadsheet would otherwise run as a
+// formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,278 @@
+package polls
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	polls = make(map[string]*poll)
+	mu    sync.RWMutex
+)
+
+func resultsEvent(id string) string {
+	return "poll-" + id + "-results"
+}
+
+func ballotEvent(id string) string {
+	return "poll-" + id + "-ballot"
+}
+
+func newOriginatorID() string {
+	return fmt.Sprintf("polls-%d-%d", time.Now().UnixNano(), mathrand.Intn(1000000))
+}
+
+// sweep drops polls nobody has voted in for a while. Call it with mu held.
+func sweep(now time.Time) {
+	for id, p := range polls {
+		if now.Sub(p.updated) > pollTTL {
+			delete(polls, id)
+		}
+	}
+}
+
+func summaries() []experiments.PollSummary {
+	list := make([]*poll, 0, len(polls))
+	for _, p := range polls {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.After(list[j].Created) })
+
+	result := make([]experiments.PollSummary, 0, len(list))
+	for _, p := range list {
+		result = append(result, p.summary())
+	}
+	return result
+}
+
+func PollsHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		data := experiments.PollsPageData{
+			Polls:        summaries(),
+			OriginatorID: newOriginatorID(),
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.PollsPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.PollsPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// CreatePollHandler creates a poll hosted by the caller's session and sends
+// them to it.
+func CreatePollHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		host := session.Get(c).ID
+		if host == "" {
+			return c.String(500, "Error creating session")
+		}
+
+		p, err := parsePoll(c.FormValue("question"), c.FormValue("kind"), c.FormValue("options"))
+		if err != nil {
+			return pollError(c, "#poll-create-error", "Poll not created: "+err.Error()+".")
+		}
+		id, err := newPollID()
+		if err != nil {
+			return c.String(500, "Error creating poll")
+		}
+		p.ID = id
+		p.Host = host
+
+		mu.Lock()
+		sweep(time.Now())
+		if len(polls) >= settings.MaxPolls {
+			mu.Unlock()
+			return pollError(c, "#poll-create-error", "Poll not created: there are too many polls.")
+		}
+		polls[id] = p
+		list := summaries()
+		mu.Unlock()
+
+		var builder strings.Builder
+		if err := experiments.PollList(list).Render(c.Request().Context(), &builder); err != nil {
+			return c.String(500, "Error generating poll list HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name:      "polls-updated",
+			Data:      builder.String(),
+			ExcludeID: c.Request().Header.Get("X-Originator-ID"),
+			Topic:     Topic,
+		})
+
+		path := "/experiments/polls/" + id
+		if c.Request().Header.Get("HX-Request") == "true" {
+			c.Response().Header().Set("HX-Redirect", path)
+			return c.NoContent(200)
+		}
+		return c.Redirect(303, path)
+	}
+}
+
+func PollHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		viewer := session.Get(c).ID
+
+		mu.RLock()
+		defer mu.RUnlock()
+
+		p, exists := polls[c.Param("id")]
+		if !exists {
+			return c.String(404, "Poll not found")
+		}
+
+		data := experiments.PollPageData{
+			Poll:         p.summary(),
+			Ballot:       p.ballot(viewer),
+			Results:      p.results(),
+			OriginatorID: newOriginatorID(),
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.PollPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.PollPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// VoteHandler records one ballot per session. The voter gets their updated
+// ballot with the results swapped out of band; everyone else gets the
+// results over SSE.
+func VoteHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		voter := session.Get(c).ID
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		form, err := c.FormParams()
+		if err != nil {
+			return c.String(400, "Invalid form")
+		}
+
+		mu.Lock()
+		p, exists := polls[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Poll not found")
+		}
+		if p.Closed {
+			mu.Unlock()
+			return pollError(c, "#poll-vote-error", "This poll is closed.")
+		}
+		if _, voted := p.ballots[voter]; voted || voter == "" {
+			mu.Unlock()
+			return pollError(c, "#poll-vote-error", "You've already voted in this poll.")
+		}
+		choices, err := p.parseBallot(form)
+		if err != nil {
+			mu.Unlock()
+			return pollError(c, "#poll-vote-error", "Vote not counted: "+err.Error()+".")
+		}
+		p.ballots[voter] = choices
+		p.updated = time.Now()
+		results := p.results()
+		ballot := p.ballot(voter)
+		mu.Unlock()
+
+		var sseBuilder strings.Builder
+		if err := experiments.PollResults(results).Render(c.Request().Context(), &sseBuilder); err != nil {
+			return c.String(500, "Error generating SSE HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name:      resultsEvent(p.ID),
+			Data:      sseBuilder.String(),
+			ExcludeID: originatorID,
+			Topic:     Topic,
+		})
+
+		var originatorBuilder strings.Builder
+		if err := experiments.PollVoted(ballot, results).Render(c.Request().Context(), &originatorBuilder); err != nil {
+			return c.String(500, "Error generating originator HTML")
+		}
+		return c.HTML(200, originatorBuilder.String())
+	}
+}
+
+// ClosePollHandler lets the host stop voting. Everyone's ballot is replaced
+// with a closed notice and the final results.
+func ClosePollHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		viewer := session.Get(c).ID
+		originatorID := c.Request().Header.Get("X-Originator-ID")
+
+		mu.Lock()
+		p, exists := polls[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Poll not found")
+		}
+		if viewer == "" || viewer != p.Host {
+			mu.Unlock()
+			return pollError(c, "#poll-vote-error", "Only the host can close this poll.")
+		}
+		p.Closed = true
+		p.updated = time.Now()
+		results := p.results()
+		// Viewers other than the host share the same closed ballot.
+		others := p.ballot("")
+		hostBallot := p.ballot(viewer)
+		list := summaries()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		var builder strings.Builder
+		if err := experiments.PollBallot(others).Render(ctx, &builder); err != nil {
+			return c.String(500, "Error generating ballot HTML")
+		}
+		hub.Broadcast(sse.Event{Name: ballotEvent(p.ID), Data: builder.String(), ExcludeID: originatorID, Topic: Topic})
+
+		builder.Reset()
+		if err := experiments.PollResults(results).Render(ctx, &builder); err != nil {
+			return c.String(500, "Error generating results HTML")
+		}
+		hub.Broadcast(sse.Event{Name: resultsEvent(p.ID), Data: builder.String(), ExcludeID: originatorID, Topic: Topic})
+
+		builder.Reset()
+		if err := experiments.PollList(list).Render(ctx, &builder); err != nil {
+			return c.String(500, "Error generating poll list HTML")
+		}
+		hub.Broadcast(sse.Event{Name: "polls-updated", Data: builder.String(), Topic: Topic})
+
+		builder.Reset()
+		if err := experiments.PollVoted(hostBallot, results).Render(ctx, &builder); err != nil {
+			return c.String(500, "Error generating originator HTML")
+		}
+		return c.HTML(200, builder.String())
+	}
+}
+
+// pollError shows a message in the given slot instead of swapping the
+// element the form targets.
+func pollError(c echo.Context, target, message string) error {
+	c.Response().Header().Set("HX-Retarget", target)
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return experiments.PollError(message).Render(c.Request().Context(), c.Response().Writer)
+}
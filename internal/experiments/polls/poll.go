@@ -0,0 +1,317 @@
+package polls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hypermedia-sync/internal/templates/experiments"
+)
+
+type Kind string
+
+const (
+	// KindSingle lets each voter pick one option.
+	KindSingle Kind = "single"
+	// KindMulti lets each voter pick any number of options.
+	KindMulti Kind = "multi"
+	// KindRanked has voters rank options; results are an instant-runoff
+	// count.
+	KindRanked Kind = "ranked"
+)
+
+func (k Kind) Valid() bool {
+	switch k {
+	case KindSingle, KindMulti, KindRanked:
+		return true
+	}
+	return false
+}
+
+const (
+	minOptions        = 2
+	maxOptions        = 10
+	maxQuestionLength = 200
+	maxOptionLength   = 100
+	// pollTTL is how long a poll may go without a vote before it is swept.
+	pollTTL = 2 * time.Hour
+)
+
+type poll struct {
+	ID       string
+	Question string
+	Kind     Kind
+	Options  []string
+	// Host is the session that created the poll and may close it.
+	Host    string
+	Created time.Time
+	// updated is when the poll was created or last voted in or closed.
+	updated time.Time
+	Closed  bool
+	// ballots maps sessions to chosen option indexes. Ranked ballots are in
+	// order of preference.
+	ballots map[string][]int
+}
+
+func newPollID() (string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// parsePoll validates the create form. Options come one per line.
+func parsePoll(question, kind, options string) (*poll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, errors.New("a question is required")
+	}
+	if utf8.RuneCountInString(question) > maxQuestionLength {
+		return nil, fmt.Errorf("questions are limited to %d characters", maxQuestionLength)
+	}
+	if !Kind(kind).Valid() {
+		return nil, errors.New("unknown poll type")
+	}
+
+	var opts []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(options, "\n") {
+		option := strings.TrimSpace(line)
+		if option == "" || seen[strings.ToLower(option)] {
+			continue
+		}
+		if utf8.RuneCountInString(option) > maxOptionLength {
+			return nil, fmt.Errorf("options are limited to %d characters", maxOptionLength)
+		}
+		seen[strings.ToLower(option)] = true
+		opts = append(opts, option)
+	}
+	if len(opts) < minOptions || len(opts) > maxOptions {
+		return nil, fmt.Errorf("polls need between %d and %d different options", minOptions, maxOptions)
+	}
+
+	now := time.Now()
+	return &poll{
+		Question: question,
+		Kind:     Kind(kind),
+		Options:  opts,
+		Created:  now,
+		updated:  now,
+		ballots:  make(map[string][]int),
+	}, nil
+}
+
+// parseBallot reads a vote from the form. Single and multi ballots submit
+// "choice" values; ranked ballots submit "rank-<option>" values from 1 to n.
+func (p *poll) parseBallot(form url.Values) ([]int, error) {
+	switch p.Kind {
+	case KindSingle, KindMulti:
+		var choices []int
+		seen := make(map[int]bool)
+		for _, value := range form["choice"] {
+			choice, err := strconv.Atoi(value)
+			if err != nil || choice < 0 || choice >= len(p.Options) {
+				return nil, errors.New("unknown option")
+			}
+			if !seen[choice] {
+				seen[choice] = true
+				choices = append(choices, choice)
+			}
+		}
+		if len(choices) == 0 {
+			return nil, errors.New("pick an option")
+		}
+		if p.Kind == KindSingle && len(choices) > 1 {
+			return nil, errors.New("pick only one option")
+		}
+		sort.Ints(choices)
+		return choices, nil
+
+	case KindRanked:
+		ranks := make(map[int]int)
+		for option := range p.Options {
+			value := form.Get(fmt.Sprintf("rank-%d", option))
+			if value == "" {
+				continue
+			}
+			rank, err := strconv.Atoi(value)
+			if err != nil || rank < 1 || rank > len(p.Options) {
+				return nil, errors.New("invalid rank")
+			}
+			if _, taken := ranks[rank]; taken {
+				return nil, errors.New("each rank can only be used once")
+			}
+			ranks[rank] = option
+		}
+		if len(ranks) == 0 {
+			return nil, errors.New("rank at least one option")
+		}
+		order := make([]int, 0, len(ranks))
+		for rank := 1; rank <= len(p.Options); rank++ {
+			if option, ok := ranks[rank]; ok {
+				order = append(order, option)
+			}
+		}
+		return order, nil
+	}
+	return nil, errors.New("unknown poll type")
+}
+
+// tally returns per-option vote counts for single and multi polls.
+func (p *poll) tally() []int {
+	counts := make([]int, len(p.Options))
+	for _, ballot := range p.ballots {
+		for _, option := range ballot {
+			counts[option]++
+		}
+	}
+	return counts
+}
+
+// runoff is an instant-runoff count of a ranked poll.
+type runoff struct {
+	// first and final are per-option votes in the first and last rounds.
+	first, final []int
+	// eliminated is the round each option was knocked out in, or 0.
+	eliminated []int
+	rounds     int
+	// winner is the option with a majority in the last round, or -1 when
+	// there are no ballots or the remaining options are tied.
+	winner int
+}
+
+// runoff counts each ballot for its highest-ranked option still standing.
+// If no option has a majority of those ballots, the options with the fewest
+// votes are eliminated together and the ballots recounted. Ballots ranking
+// no remaining option are exhausted and drop out of the count.
+func (p *poll) runoff() runoff {
+	n := len(p.Options)
+	r := runoff{eliminated: make([]int, n), winner: -1}
+	standing := n
+	for round := 1; ; round++ {
+		votes := make([]int, n)
+		active := 0
+		for _, ballot := range p.ballots {
+			for _, option := range ballot {
+				if r.eliminated[option] == 0 {
+					votes[option]++
+					active++
+					break
+				}
+			}
+		}
+		if round == 1 {
+			r.first = votes
+		}
+		r.final, r.rounds = votes, round
+		if active == 0 {
+			return r
+		}
+
+		fewest := active
+		for option, v := range votes {
+			if r.eliminated[option] != 0 {
+				continue
+			}
+			if 2*v > active {
+				r.winner = option
+				return r
+			}
+			fewest = min(fewest, v)
+		}
+		var lowest []int
+		for option, v := range votes {
+			if r.eliminated[option] == 0 && v == fewest {
+				lowest = append(lowest, option)
+			}
+		}
+		if len(lowest) == standing {
+			return r
+		}
+		for _, option := range lowest {
+			r.eliminated[option] = round
+		}
+		standing -= len(lowest)
+	}
+}
+
+func (p *poll) results() experiments.PollResultsData {
+	voters := len(p.ballots)
+	data := experiments.PollResultsData{
+		PollID: p.ID,
+		Kind:   string(p.Kind),
+		Voters: voters,
+		Closed: p.Closed,
+	}
+	if p.Kind == KindRanked {
+		return p.rankedResults(data)
+	}
+	counts := p.tally()
+	for i, option := range p.Options {
+		row := experiments.PollResultRow{Option: option, Value: counts[i]}
+		if voters > 0 {
+			row.Percent = float64(counts[i]) / float64(voters) * 100
+		}
+		row.Label = fmt.Sprintf("%d (%.0f%%)", counts[i], row.Percent)
+		data.Rows = append(data.Rows, row)
+	}
+	return data
+}
+
+// rankedResults shows each option's share of the last round's votes, or the
+// round it was eliminated in.
+func (p *poll) rankedResults(data experiments.PollResultsData) experiments.PollResultsData {
+	r := p.runoff()
+	active := 0
+	for _, v := range r.final {
+		active += v
+	}
+	data.Rounds = r.rounds
+	if r.winner >= 0 {
+		data.Winner = p.Options[r.winner]
+	}
+	for i, option := range p.Options {
+		row := experiments.PollResultRow{Option: option}
+		if round := r.eliminated[i]; round != 0 {
+			row.Label = fmt.Sprintf("out in round %d • %d first", round, r.first[i])
+		} else {
+			row.Value = r.final[i]
+			if active > 0 {
+				row.Percent = float64(r.final[i]) / float64(active) * 100
+			}
+			row.Label = fmt.Sprintf("%d (%.0f%%) • %d first", r.final[i], row.Percent, r.first[i])
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data
+}
+
+func (p *poll) summary() experiments.PollSummary {
+	return experiments.PollSummary{
+		ID:       p.ID,
+		Question: p.Question,
+		Kind:     string(p.Kind),
+		Closed:   p.Closed,
+		Voters:   len(p.ballots),
+	}
+}
+
+func (p *poll) ballot(session string) experiments.PollBallotData {
+	_, voted := p.ballots[session]
+	return experiments.PollBallotData{
+		PollID:  p.ID,
+		Kind:    string(p.Kind),
+		Options: p.Options,
+		Voted:   voted,
+		Closed:  p.Closed,
+		IsHost:  session != "" && session == p.Host,
+	}
+}
@@ -0,0 +1,43 @@
+package polls
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRunoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		ballots    [][]int
+		winner     int
+		rounds     int
+		eliminated []int
+	}{
+		{"no ballots", nil, -1, 1, []int{0, 0, 0}},
+		{"first round majority", [][]int{{0}, {0}, {1}}, 0, 1, []int{0, 0, 0}},
+		{"all tied", [][]int{{0}, {1}, {2}}, -1, 1, []int{0, 0, 0}},
+		{"transfer wins", [][]int{{0}, {0}, {0}, {1}, {1}, {1}, {2, 1}}, 1, 2, []int{0, 0, 1}},
+		{"exhausted ballot leaves a tie", [][]int{{0}, {0}, {0}, {1}, {1}, {1}, {2}}, -1, 2, []int{0, 0, 1}},
+		{"tied lowest eliminated together", [][]int{{0}, {0}, {1}, {2, 0}}, 0, 2, []int{0, 1, 1}},
+		{"majority of unexhausted ballots", [][]int{{0}, {0}, {0}, {1}, {1}, {2}, {2}}, 0, 2, []int{0, 1, 1}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &poll{Kind: KindRanked, Options: []string{"a", "b", "c"}, ballots: make(map[string][]int)}
+			for i, ballot := range tc.ballots {
+				p.ballots[fmt.Sprint(i)] = ballot
+			}
+			r := p.runoff()
+			if r.winner != tc.winner {
+				t.Errorf("winner = %d, want %d", r.winner, tc.winner)
+			}
+			if r.rounds != tc.rounds {
+				t.Errorf("rounds = %d, want %d", r.rounds, tc.rounds)
+			}
+			if !slices.Equal(r.eliminated, tc.eliminated) {
+				t.Errorf("eliminated = %v, want %v", r.eliminated, tc.eliminated)
+			}
+		})
+	}
+}
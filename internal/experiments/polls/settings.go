@@ -0,0 +1,20 @@
+package polls
+
+// Settings controls how many polls can be open at once.
+type Settings struct {
+	// MaxPolls caps how many polls exist before creating more is refused.
+	MaxPolls int
+}
+
+func DefaultSettings() Settings {
+	return Settings{MaxPolls: 100}
+}
+
+var settings = DefaultSettings()
+
+// configure sets the poll cap that CreatePollHandler enforces.
+func configure(s Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+}
@@ -0,0 +1,77 @@
+package quiz
+
+import (
+	"context"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic is the SSE topic quiz stages, progress, and leaderboards are
+// broadcast on.
+const Topic = "quiz"
+
+type Experiment struct {
+	hub *sse.Hub
+}
+
+var _ experiment.Experiment = (*Experiment)(nil)
+
+func New(hub *sse.Hub, s Settings) *Experiment {
+	configure(s)
+	return &Experiment{hub: hub}
+}
+
+func (e *Experiment) Metadata() experiment.Metadata {
+	return experiment.Metadata{
+		ID:          "quiz",
+		Name:        "Live Quiz",
+		Description: "Host a timed trivia game from a JSON or YAML question set while players race to answer and the leaderboard updates after every round",
+	}
+}
+
+func (e *Experiment) RegisterRoutes(g *echo.Group) {
+	g.GET("", QuizHandler(e.hub))
+	g.POST("", CreateGameHandler(e.hub))
+	g.GET("/:id", GameHandler(e.hub))
+	g.POST("/:id/join", JoinHandler(e.hub))
+	g.POST("/:id/advance", AdvanceHandler(e.hub))
+	g.POST("/:id/answer", AnswerHandler(e.hub))
+}
+
+func (e *Experiment) Topics() []string {
+	return []string{Topic}
+}
+
+// Check reports whether the games can be read.
+func (e *Experiment) Check(ctx context.Context) error {
+	return experiment.CheckLock(ctx, mu.TryRLock, mu.RUnlock)
+}
+
+// Start loads the question sets, so a broken file stops the server at boot
+// rather than when someone picks it.
+func (e *Experiment) Start(ctx context.Context) error {
+	loaded, err := loadSets()
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sets = loaded
+	return nil
+}
+
+// Stop ends every game.
+func (e *Experiment) Stop(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, g := range games {
+		if g.timer != nil {
+			g.timer.Stop()
+		}
+	}
+	games = make(map[string]*game)
+	return nil
+}
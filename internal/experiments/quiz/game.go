@@ -0,0 +1,282 @@
+package quiz
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/templates/experiments"
+)
+
+const (
+	maxGames      = 50
+	maxPlayers    = 200
+	maxNameLength = session.MaxNameLength
+	// gameTTL is how long a game may go untouched before it is swept.
+	gameTTL = 2 * time.Hour
+	// leaderboardSize is how many players the leaderboard lists.
+	leaderboardSize = 10
+)
+
+type phase string
+
+const (
+	phaseLobby    phase = "lobby"
+	phaseQuestion phase = "question"
+	phaseReveal   phase = "reveal"
+	phaseFinished phase = "finished"
+)
+
+var (
+	errNotPlaying    = errors.New("join the game to answer")
+	errNotOpen       = errors.New("there's no question open")
+	errTimeUp        = errors.New("time's up")
+	errAnswered      = errors.New("you've already answered")
+	errInvalidChoice = errors.New("that isn't one of the choices")
+	errNameRequired  = errors.New("a name is required")
+	errNameTooLong   = errors.New("that name is too long")
+	errNameTaken     = errors.New("that name is taken")
+	errGameFull      = errors.New("the game is full")
+	errFinished      = errors.New("the quiz is over")
+)
+
+type player struct {
+	Name   string
+	Score  int
+	joined time.Time
+}
+
+// answer is a player's pick for the open question. Points are worked out
+// when the answer arrives but only added to the score at the reveal.
+type answer struct {
+	choice int
+	points int
+}
+
+type game struct {
+	ID       string
+	Host     string
+	set      *questionSet
+	phase    phase
+	round    int
+	deadline time.Time
+	timer    *time.Timer
+	players  map[string]*player
+	answers  map[string]answer
+	created  time.Time
+	updated  time.Time
+}
+
+func newGame(id, host string, set *questionSet) *game {
+	now := time.Now()
+	return &game{
+		ID:      id,
+		Host:    host,
+		set:     set,
+		phase:   phaseLobby,
+		players: make(map[string]*player),
+		answers: make(map[string]answer),
+		created: now,
+		updated: now,
+	}
+}
+
+func (g *game) question() question {
+	return g.set.Questions[g.round]
+}
+
+// join adds a player, or renames one who has already joined.
+func (g *game) join(session, name string) error {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return errNameRequired
+	case utf8.RuneCountInString(name) > maxNameLength:
+		return errNameTooLong
+	}
+	for id, p := range g.players {
+		if id != session && strings.EqualFold(p.Name, name) {
+			return errNameTaken
+		}
+	}
+	if p, ok := g.players[session]; ok {
+		p.Name = name
+		return nil
+	}
+	if len(g.players) >= maxPlayers {
+		return errGameFull
+	}
+	g.players[session] = &player{Name: name, joined: time.Now()}
+	g.updated = time.Now()
+	return nil
+}
+
+// advance moves the game to its next phase: the first question, the reveal
+// of an open question, the next question, or the final results.
+func (g *game) advance(now time.Time) error {
+	switch g.phase {
+	case phaseLobby:
+		g.open(0, now)
+	case phaseQuestion:
+		g.reveal()
+	case phaseReveal:
+		if g.round+1 < len(g.set.Questions) {
+			g.open(g.round+1, now)
+		} else {
+			g.phase = phaseFinished
+		}
+	case phaseFinished:
+		return errFinished
+	}
+	g.updated = now
+	return nil
+}
+
+func (g *game) open(round int, now time.Time) {
+	g.phase = phaseQuestion
+	g.round = round
+	g.deadline = now.Add(g.question().limit())
+	g.answers = make(map[string]answer)
+}
+
+// reveal closes the open question and scores it.
+func (g *game) reveal() {
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	g.phase = phaseReveal
+	for session, a := range g.answers {
+		if p, ok := g.players[session]; ok {
+			p.Score += a.points
+		}
+	}
+}
+
+// answer records a player's choice. Correct answers are worth between half
+// and all of the question's points, depending on how fast they came in.
+func (g *game) answer(session string, choice int, now time.Time) error {
+	if _, ok := g.players[session]; !ok {
+		return errNotPlaying
+	}
+	if g.phase != phaseQuestion {
+		return errNotOpen
+	}
+	if now.After(g.deadline) {
+		return errTimeUp
+	}
+	if _, ok := g.answers[session]; ok {
+		return errAnswered
+	}
+	q := g.question()
+	if choice < 0 || choice >= len(q.Choices) {
+		return errInvalidChoice
+	}
+
+	points := 0
+	if choice == q.Answer {
+		remaining := g.deadline.Sub(now).Seconds() / q.limit().Seconds()
+		points = int(math.Round(float64(q.Points) * (0.5 + remaining/2)))
+	}
+	g.answers[session] = answer{choice: choice, points: points}
+	g.updated = now
+	return nil
+}
+
+func (g *game) allAnswered() bool {
+	return len(g.players) > 0 && len(g.answers) == len(g.players)
+}
+
+func (g *game) stage(now time.Time) experiments.QuizStage {
+	stage := experiments.QuizStage{
+		GameID: g.ID,
+		Phase:  string(g.phase),
+		Title:  g.set.Title,
+		Round:  g.round + 1,
+		Total:  len(g.set.Questions),
+	}
+	switch g.phase {
+	case phaseQuestion, phaseReveal:
+		q := g.question()
+		stage.Question = q.Text
+		stage.Choices = q.Choices
+		stage.Points = q.Points
+		if g.phase == phaseQuestion {
+			stage.RemainingMS = max(0, g.deadline.Sub(now).Milliseconds())
+		} else {
+			stage.Correct = q.Answer
+			stage.Counts = make([]int, len(q.Choices))
+			for _, a := range g.answers {
+				stage.Counts[a.choice]++
+			}
+		}
+		stage.Last = g.round+1 == len(g.set.Questions)
+	case phaseFinished:
+		stage.Podium = g.scores(3)
+	}
+	return stage
+}
+
+func (g *game) progress() experiments.QuizProgress {
+	return experiments.QuizProgress{
+		Open:     g.phase == phaseQuestion,
+		Answered: len(g.answers),
+		Players:  len(g.players),
+	}
+}
+
+// scores ranks players by score, earliest to join first on ties. Tied
+// scores share a rank.
+func (g *game) scores(limit int) []experiments.QuizScore {
+	list := make([]*player, 0, len(g.players))
+	for _, p := range g.players {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		return list[i].joined.Before(list[j].joined)
+	})
+
+	result := make([]experiments.QuizScore, 0, min(limit, len(list)))
+	for i, p := range list {
+		if i == limit {
+			break
+		}
+		rank := i + 1
+		if i > 0 && p.Score == list[i-1].Score {
+			rank = result[i-1].Rank
+		}
+		result = append(result, experiments.QuizScore{Rank: rank, Name: p.Name, Score: p.Score})
+	}
+	return result
+}
+
+func (g *game) leaderboard() experiments.QuizLeaderboard {
+	return experiments.QuizLeaderboard{Scores: g.scores(leaderboardSize), Players: len(g.players)}
+}
+
+func (g *game) me(session string) experiments.QuizMe {
+	me := experiments.QuizMe{GameID: g.ID, IsHost: session != "" && session == g.Host}
+	if p, ok := g.players[session]; ok {
+		me.Joined = true
+		me.Name = p.Name
+	}
+	return me
+}
+
+func (g *game) summary() experiments.QuizSummary {
+	status := "Waiting to start"
+	switch g.phase {
+	case phaseQuestion, phaseReveal:
+		status = "In progress"
+	case phaseFinished:
+		status = "Finished"
+	}
+	return experiments.QuizSummary{ID: g.ID, Title: g.set.Title, Status: status, Players: len(g.players)}
+}
@@ -0,0 +1,376 @@
+package quiz
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/experiments"
+
+	"github.com/labstack/echo/v4"
+)
+
+const gamesEvent = "quiz-games"
+
+var (
+	games = make(map[string]*game)
+	mu    sync.RWMutex
+)
+
+func stageEvent(id string) string {
+	return "quiz-" + id + "-stage"
+}
+
+func progressEvent(id string) string {
+	return "quiz-" + id + "-progress"
+}
+
+func leaderboardEvent(id string) string {
+	return "quiz-" + id + "-leaderboard"
+}
+
+func newOriginatorID() string {
+	return fmt.Sprintf("quiz-%d-%d", time.Now().UnixNano(), mathrand.Intn(1000000))
+}
+
+func newGameID() (string, error) {
+	id := make([]byte, 3)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func summaries() []experiments.QuizSummary {
+	list := make([]*game, 0, len(games))
+	for _, g := range games {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].created.After(list[j].created) })
+
+	result := make([]experiments.QuizSummary, 0, len(list))
+	for _, g := range list {
+		result = append(result, g.summary())
+	}
+	return result
+}
+
+func setOptions() []experiments.QuizSetOption {
+	options := make([]experiments.QuizSetOption, 0, len(sets))
+	for _, set := range sets {
+		options = append(options, experiments.QuizSetOption{ID: set.ID, Title: set.Title, Questions: len(set.Questions)})
+	}
+	return options
+}
+
+// sweep drops games nobody has touched in a while.
+func sweep(now time.Time) {
+	for id, g := range games {
+		if now.Sub(g.updated) > gameTTL {
+			if g.timer != nil {
+				g.timer.Stop()
+			}
+			delete(games, id)
+		}
+	}
+}
+
+func QuizHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		data := experiments.QuizListPageData{
+			Sets:         setOptions(),
+			Games:        summaries(),
+			OriginatorID: newOriginatorID(),
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.QuizListPageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.QuizListPageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// CreateGameHandler opens a game hosted by the caller's session and sends
+// them to it.
+func CreateGameHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		host := session.Get(c).ID
+		if host == "" {
+			return c.String(500, "Error creating session")
+		}
+		set := lookupSet(c.FormValue("set"))
+		if set == nil {
+			return quizError(c, "#quiz-create-error", "Game not created: pick a question set.")
+		}
+		id, err := newGameID()
+		if err != nil {
+			return c.String(500, "Error creating game")
+		}
+
+		mu.Lock()
+		sweep(time.Now())
+		if len(games) >= maxGames {
+			mu.Unlock()
+			return quizError(c, "#quiz-create-error", "Game not created: there are too many games.")
+		}
+		games[id] = newGame(id, host, set)
+		list := summaries()
+		mu.Unlock()
+
+		if err := broadcastGames(c.Request().Context(), hub, list); err != nil {
+			return c.String(500, "Error generating game list HTML")
+		}
+
+		path := "/experiments/quiz/" + id
+		if c.Request().Header.Get("HX-Request") == "true" {
+			c.Response().Header().Set("HX-Redirect", path)
+			return c.NoContent(200)
+		}
+		return c.Redirect(303, path)
+	}
+}
+
+func GameHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		viewer := session.Get(c).ID
+
+		mu.RLock()
+		defer mu.RUnlock()
+
+		g, exists := games[c.Param("id")]
+		if !exists {
+			return c.String(404, "Game not found")
+		}
+
+		data := experiments.QuizGamePageData{
+			Stage:        g.stage(time.Now()),
+			Progress:     g.progress(),
+			Leaderboard:  g.leaderboard(),
+			Me:           g.me(viewer),
+			OriginatorID: newOriginatorID(),
+			OnlineCount:  hub.GetOnlineCount(),
+			Banner:       experiment.BannerOf(c),
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := experiments.QuizGamePageContent(data)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		component := experiments.QuizGamePageFull(data)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// JoinHandler adds the caller as a player under the given name. Players can
+// join at any point and score from the next question they answer.
+func JoinHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		viewer := session.Get(c).ID
+
+		mu.Lock()
+		g, exists := games[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Game not found")
+		}
+		if err := g.join(viewer, c.FormValue("name")); err != nil {
+			mu.Unlock()
+			return quizError(c, "#quiz-join-error", "Couldn't join: "+err.Error()+".")
+		}
+		me := g.me(viewer)
+		progress := g.progress()
+		leaderboard := g.leaderboard()
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if err := broadcastProgress(ctx, hub, g.ID, progress); err != nil {
+			return c.String(500, "Error generating progress HTML")
+		}
+		if err := broadcastLeaderboard(ctx, hub, g.ID, leaderboard); err != nil {
+			return c.String(500, "Error generating leaderboard HTML")
+		}
+		return experiments.QuizMeView(me).Render(ctx, c.Response().Writer)
+	}
+}
+
+// AdvanceHandler lets the host start the quiz, reveal the open question
+// early, or move on. Everyone, the host included, gets the new stage over
+// SSE.
+func AdvanceHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		viewer := session.Get(c).ID
+
+		mu.Lock()
+		g, exists := games[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Game not found")
+		}
+		if viewer == "" || viewer != g.Host {
+			mu.Unlock()
+			return quizError(c, "#quiz-host-error", "Only the host can move the quiz on.")
+		}
+		now := time.Now()
+		if err := g.advance(now); err != nil {
+			mu.Unlock()
+			return quizError(c, "#quiz-host-error", "Couldn't move on: "+err.Error()+".")
+		}
+		if g.phase == phaseQuestion {
+			round := g.round
+			g.timer = time.AfterFunc(g.deadline.Sub(now), func() { expire(hub, g.ID, round) })
+		}
+		update := snapshot(g, now)
+		list := summaries()
+		mu.Unlock()
+
+		if err := update.broadcast(c.Request().Context(), hub); err != nil {
+			return c.String(500, "Error generating stage HTML")
+		}
+		if err := broadcastGames(c.Request().Context(), hub, list); err != nil {
+			return c.String(500, "Error generating game list HTML")
+		}
+		return c.NoContent(204)
+	}
+}
+
+// AnswerHandler records the caller's choice if the question is still open.
+// The question is revealed as soon as every player has answered.
+func AnswerHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		viewer := session.Get(c).ID
+		choice, err := strconv.Atoi(c.FormValue("choice"))
+		if err != nil {
+			return c.String(400, "Invalid choice")
+		}
+
+		mu.Lock()
+		g, exists := games[c.Param("id")]
+		if !exists {
+			mu.Unlock()
+			return c.String(404, "Game not found")
+		}
+		now := time.Now()
+		if err := g.answer(viewer, choice, now); err != nil {
+			mu.Unlock()
+			return quizError(c, "#quiz-answer", "Answer not counted: "+err.Error()+".")
+		}
+		picked := g.question().Choices[choice]
+		progress := g.progress()
+		var update *stageUpdate
+		if g.allAnswered() {
+			g.reveal()
+			update = snapshot(g, now)
+		}
+		mu.Unlock()
+
+		ctx := c.Request().Context()
+		if update != nil {
+			if err := update.broadcast(ctx, hub); err != nil {
+				return c.String(500, "Error generating stage HTML")
+			}
+			return c.NoContent(204)
+		}
+		if err := broadcastProgress(ctx, hub, g.ID, progress); err != nil {
+			return c.String(500, "Error generating progress HTML")
+		}
+		return experiments.QuizAnswered(picked).Render(ctx, c.Response().Writer)
+	}
+}
+
+// expire reveals a question when its time runs out, unless it was already
+// revealed.
+func expire(hub *sse.Hub, id string, round int) {
+	mu.Lock()
+	g, exists := games[id]
+	if !exists || g.phase != phaseQuestion || g.round != round {
+		mu.Unlock()
+		return
+	}
+	g.timer = nil
+	g.reveal()
+	update := snapshot(g, time.Now())
+	mu.Unlock()
+
+	if err := update.broadcast(context.Background(), hub); err != nil {
+		slog.Error("broadcasting quiz reveal", "experiment", "quiz", "game", id, "err", err)
+	}
+}
+
+// stageUpdate is everything that changes when a game changes phase.
+type stageUpdate struct {
+	id          string
+	stage       experiments.QuizStage
+	progress    experiments.QuizProgress
+	leaderboard experiments.QuizLeaderboard
+}
+
+func snapshot(g *game, now time.Time) *stageUpdate {
+	return &stageUpdate{id: g.ID, stage: g.stage(now), progress: g.progress(), leaderboard: g.leaderboard()}
+}
+
+func (u *stageUpdate) broadcast(ctx context.Context, hub *sse.Hub) error {
+	var builder strings.Builder
+	if err := experiments.QuizStageView(u.stage).Render(ctx, &builder); err != nil {
+		return err
+	}
+	hub.Broadcast(sse.Event{Name: stageEvent(u.id), Data: builder.String(), Topic: Topic})
+	if err := broadcastProgress(ctx, hub, u.id, u.progress); err != nil {
+		return err
+	}
+	return broadcastLeaderboard(ctx, hub, u.id, u.leaderboard)
+}
+
+func broadcastProgress(ctx context.Context, hub *sse.Hub, id string, progress experiments.QuizProgress) error {
+	var builder strings.Builder
+	if err := experiments.QuizProgressView(progress).Render(ctx, &builder); err != nil {
+		return err
+	}
+	hub.Broadcast(sse.Event{Name: progressEvent(id), Data: builder.String(), Topic: Topic})
+	return nil
+}
+
+func broadcastLeaderboard(ctx context.Context, hub *sse.Hub, id string, leaderboard experiments.QuizLeaderboard) error {
+	var builder strings.Builder
+	if err := experiments.QuizLeaderboardView(leaderboard).Render(ctx, &builder); err != nil {
+		return err
+	}
+	hub.Broadcast(sse.Event{Name: leaderboardEvent(id), Data: builder.String(), Topic: Topic})
+	return nil
+}
+
+func broadcastGames(ctx context.Context, hub *sse.Hub, list []experiments.QuizSummary) error {
+	var builder strings.Builder
+	if err := experiments.QuizGameList(list).Render(ctx, &builder); err != nil {
+		return err
+	}
+	hub.Broadcast(sse.Event{Name: gamesEvent, Data: builder.String(), Topic: Topic})
+	return nil
+}
+
+// quizError shows a message in the given slot instead of swapping the
+// element the request targets.
+func quizError(c echo.Context, target, message string) error {
+	c.Response().Header().Set("HX-Retarget", target)
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return experiments.QuizError(message).Render(c.Request().Context(), c.Response().Writer)
+}
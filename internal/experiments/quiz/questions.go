@@ -0,0 +1,166 @@
+package quiz
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	minChoices   = 2
+	maxChoices   = 6
+	minTimeLimit = 5 * time.Second
+	maxTimeLimit = 2 * time.Minute
+	// defaultPoints is the most a question is worth, for an instant answer.
+	defaultPoints = 1000
+)
+
+//go:embed questions
+var builtin embed.FS
+
+// questionSet is the file format, in JSON or YAML:
+//
+//	title: Web Basics
+//	questions:
+//	  - text: What does SSE stand for?
+//	    choices: [Server-Sent Events, Secure Socket Exchange]
+//	    answer: 0          # index into choices
+//	    time_limit: 15     # seconds, optional
+//	    points: 1000       # optional
+type questionSet struct {
+	ID        string     `json:"-" yaml:"-"`
+	Title     string     `json:"title" yaml:"title"`
+	Questions []question `json:"questions" yaml:"questions"`
+}
+
+type question struct {
+	Text      string   `json:"text" yaml:"text"`
+	Choices   []string `json:"choices" yaml:"choices"`
+	Answer    int      `json:"answer" yaml:"answer"`
+	TimeLimit int      `json:"time_limit" yaml:"time_limit"`
+	Points    int      `json:"points" yaml:"points"`
+}
+
+func (q question) limit() time.Duration {
+	if q.TimeLimit > 0 {
+		return time.Duration(q.TimeLimit) * time.Second
+	}
+	return settings.TimeLimit
+}
+
+var sets []*questionSet
+
+// loadSets reads the built-in sets and any in settings.QuestionsDir.
+func loadSets() ([]*questionSet, error) {
+	loaded, err := readSets(builtin, "questions")
+	if err != nil {
+		return nil, err
+	}
+	if settings.QuestionsDir != "" {
+		extra, err := readSets(os.DirFS(settings.QuestionsDir), ".")
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, extra...)
+	}
+
+	seen := make(map[string]bool)
+	for _, set := range loaded {
+		if seen[set.ID] {
+			return nil, fmt.Errorf("question set %q is defined twice", set.ID)
+		}
+		seen[set.ID] = true
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Title < loaded[j].Title })
+	return loaded, nil
+}
+
+func readSets(fsys fs.FS, dir string) ([]*questionSet, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []*questionSet
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		set, err := parseSet(data, ext)
+		if err != nil {
+			return nil, fmt.Errorf("question set %s: %w", entry.Name(), err)
+		}
+		set.ID = strings.TrimSuffix(entry.Name(), ext)
+		loaded = append(loaded, set)
+	}
+	return loaded, nil
+}
+
+func parseSet(data []byte, ext string) (*questionSet, error) {
+	set := &questionSet{}
+	var err error
+	if ext == ".json" {
+		err = json.Unmarshal(data, set)
+	} else {
+		err = yaml.Unmarshal(data, set)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func (s *questionSet) validate() error {
+	s.Title = strings.TrimSpace(s.Title)
+	if s.Title == "" {
+		return errors.New("a title is required")
+	}
+	if len(s.Questions) == 0 {
+		return errors.New("at least one question is required")
+	}
+	for i := range s.Questions {
+		q := &s.Questions[i]
+		q.Text = strings.TrimSpace(q.Text)
+		switch {
+		case q.Text == "":
+			return fmt.Errorf("question %d: text is required", i+1)
+		case len(q.Choices) < minChoices || len(q.Choices) > maxChoices:
+			return fmt.Errorf("question %d: between %d and %d choices are required", i+1, minChoices, maxChoices)
+		case q.Answer < 0 || q.Answer >= len(q.Choices):
+			return fmt.Errorf("question %d: answer must be a choice index from 0 to %d", i+1, len(q.Choices)-1)
+		case q.TimeLimit != 0 && (time.Duration(q.TimeLimit)*time.Second < minTimeLimit || time.Duration(q.TimeLimit)*time.Second > maxTimeLimit):
+			return fmt.Errorf("question %d: time_limit must be between %d and %d seconds", i+1, int(minTimeLimit.Seconds()), int(maxTimeLimit.Seconds()))
+		case q.Points < 0:
+			return fmt.Errorf("question %d: points can't be negative", i+1)
+		}
+		if q.Points == 0 {
+			q.Points = defaultPoints
+		}
+	}
+	return nil
+}
+
+func lookupSet(id string) *questionSet {
+	for _, set := range sets {
+		if set.ID == id {
+			return set
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package quiz
+
+import "time"
+
+// Settings controls where question sets come from and game defaults.
+type Settings struct {
+	// QuestionsDir is a directory of extra .json, .yaml, or .yml question
+	// sets loaded alongside the built-in ones. Empty means built-ins only.
+	QuestionsDir string
+	// TimeLimit is the answer window for questions that don't set their own.
+	TimeLimit time.Duration
+}
+
+func DefaultSettings() Settings {
+	return Settings{TimeLimit: 20 * time.Second}
+}
+
+var settings = DefaultSettings()
+
+// configure sets where extra question sets are loaded from when the
+// experiment starts, and the default answer window.
+func configure(s Settings) {
+	settings = s
+}
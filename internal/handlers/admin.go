@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/templates/layout"
+	"hypermedia-sync/internal/templates/pages"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminAuth only lets through requests carrying "Authorization: Bearer
+// <token>".
+func AdminAuth(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			given, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				return c.String(401, "Unauthorized")
+			}
+			return next(c)
+		}
+	}
+}
+
+// ExperimentStatusHandler changes an experiment's status and pushes the new
+// banner to everyone viewing it, along with the badge on the listing page.
+func ExperimentStatusHandler(registry *experiment.Registry, hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+		status := experiment.Status(c.FormValue("status"))
+
+		if _, ok := registry.Get(id); !ok {
+			return c.String(404, "Experiment not found")
+		}
+		if !status.Valid() {
+			return c.String(400, "Unknown status")
+		}
+		if err := registry.SetStatus(id, status); err != nil {
+			return c.String(500, "Error updating status")
+		}
+
+		var bannerBuilder strings.Builder
+		err := layout.ExperimentStatusNotice(status.Label(), status.Notice()).Render(c.Request().Context(), &bannerBuilder)
+		if err != nil {
+			return c.String(500, "Error generating banner HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name: experiment.StatusEvent(id),
+			Data: bannerBuilder.String(),
+		})
+
+		var badgeBuilder strings.Builder
+		err = pages.ExperimentStatus(status.Label()).Render(c.Request().Context(), &badgeBuilder)
+		if err != nil {
+			return c.String(500, "Error generating status HTML")
+		}
+		hub.Broadcast(sse.Event{
+			Name: experiment.StatusEvent(id) + "-badge",
+			Data: badgeBuilder.String(),
+		})
+
+		return c.String(200, fmt.Sprintf("%s is now %s\n", id, status))
+	}
+}
@@ -1,34 +1,46 @@
 package handlers
 
 import (
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/session"
 	"hypermedia-sync/internal/templates/pages"
 
 	"github.com/labstack/echo/v4"
 )
 
-func ExperimentsListHandler(c echo.Context) error {
-	experiments := []pages.Experiment{
-		{
-			ID:          "checkboxes",
-			Name:        "10,000 Checkboxes",  
-			Description: "Real-time synchronized checkboxes demonstrating hypermedia-driven state management with SSE",
-			Path:        "/experiments/checkboxes",
-			Status:      "Active",
-		},
-		{
-			ID:          "canvas-draw-sync",
-			Name:        "Canvas",  
-			Description: "Collaborative real-time canvas where multiple users can draw, sketch, and create together using pure hypermedia",
-			Path:        "/experiments/canvas-draw-sync",
-			Status:      "Active",
-		},
-	}
+func ExperimentsListHandler(registry *experiment.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		signedIn := session.Get(c).SignedIn()
+		var experiments []pages.Experiment
+		for _, exp := range registry.All() {
+			meta := exp.Metadata()
+			status := registry.Status(meta.ID)
+			if status == experiment.StatusDraft {
+				continue
+			}
+			access := registry.Access(meta.ID)
+			listed := pages.Experiment{
+				ID:          meta.ID,
+				Name:        meta.Name,
+				Description: meta.Description,
+				Path:        meta.Path(),
+				Status:      status.Label(),
+				Access:      access.Label(),
+			}
+			// The thumbnail is served by the experiment, so it's only
+			// shown to visitors allowed to view it.
+			if access.Allows(signedIn, false) {
+				listed.Thumbnail = meta.Thumbnail
+			}
+			experiments = append(experiments, listed)
+		}
+
+		if c.Request().Header.Get("HX-Request") == "true" {
+			component := pages.ExperimentsListContent(experiments)
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
 
-	if c.Request().Header.Get("HX-Request") == "true" {
-		component := pages.ExperimentsListContent(experiments)
+		component := pages.ExperimentsListPage(experiments)
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
-
-	component := pages.ExperimentsListPage(experiments)
-	return component.Render(c.Request().Context(), c.Response().Writer)
-}
\ No newline at end of file
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// healthTimeout bounds how long a probe waits on any one component.
+	healthTimeout = time.Second
+	// queueSaturation is the fraction of the broadcast queue that may be
+	// in use before the server stops reporting ready.
+	queueSaturation = 0.9
+)
+
+// componentHealth is one entry in a health report.
+type componentHealth struct {
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS float64        `json:"duration_ms"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+type healthReport struct {
+	Status     string                     `json:"status"`
+	Service    string                     `json:"service"`
+	Timestamp  string                     `json:"timestamp"`
+	Components map[string]componentHealth `json:"components"`
+}
+
+// healthCheck returns optional details and an error if the component is
+// unhealthy.
+type healthCheck func(ctx context.Context) (map[string]any, error)
+
+// LivezHandler reports whether the process is worth keeping: the hub loop
+// must answer a ping. A failure here means restarting is the only fix.
+func LivezHandler(hub *sse.Hub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return writeHealth(c, map[string]healthCheck{
+			"hub": pingHub(hub),
+		})
+	}
+}
+
+// ReadyzHandler reports whether the server should receive traffic. On top
+// of the liveness check it requires room in the broadcast queue and every
+// experiment's state to be readable.
+func ReadyzHandler(hub *sse.Hub, registry *experiment.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		checks := map[string]healthCheck{
+			"hub": pingHub(hub),
+			"broadcast_queue": func(ctx context.Context) (map[string]any, error) {
+				stats := hub.Stats()
+				details := map[string]any{"depth": stats.QueueDepth, "capacity": stats.QueueCapacity}
+				if float64(stats.QueueDepth) >= queueSaturation*float64(stats.QueueCapacity) {
+					return details, fmt.Errorf("queue saturated: %d of %d", stats.QueueDepth, stats.QueueCapacity)
+				}
+				return details, nil
+			},
+			"experiments": func(ctx context.Context) (map[string]any, error) {
+				details := make(map[string]any)
+				var failed int
+				for id, err := range registry.Check(ctx) {
+					if err != nil {
+						details[id] = err.Error()
+						failed++
+					} else {
+						details[id] = "ok"
+					}
+				}
+				if failed > 0 {
+					return details, fmt.Errorf("%d experiment(s) unavailable", failed)
+				}
+				return details, nil
+			},
+		}
+		return writeHealth(c, checks)
+	}
+}
+
+func pingHub(hub *sse.Hub) healthCheck {
+	return func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"connections": hub.GetOnlineCount()}, hub.Ping(ctx)
+	}
+}
+
+// writeHealth runs checks concurrently and responds 200 if all pass, 503
+// otherwise.
+func writeHealth(c echo.Context, checks map[string]healthCheck) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), healthTimeout)
+	defer cancel()
+
+	report := healthReport{
+		Status:     "ok",
+		Service:    "hypermedia-sync",
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Components: make(map[string]componentHealth, len(checks)),
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			details, err := check(ctx)
+			result := componentHealth{
+				Status:     "ok",
+				DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+				Details:    details,
+			}
+			if err != nil {
+				result.Status, result.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			report.Components[name] = result
+			if err != nil {
+				report.Status = "fail"
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if report.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	return c.JSON(code, report)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/templates/layout"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SessionChipHandler renders the header's name chip, such as when the edit
+// form is cancelled.
+func SessionChipHandler(c echo.Context) error {
+	return layout.SessionChip(session.Get(c)).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// SessionFormHandler renders the form for changing name and color, with
+// sign-in and sign-out when authEnabled.
+func SessionFormHandler(authEnabled bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return layout.SessionForm(session.Get(c), "", authEnabled).Render(c.Request().Context(), c.Response().Writer)
+	}
+}
+
+// UpdateSessionHandler saves a new name and color. HTMX requests get the
+// chip back, or the form with an error; plain form posts are sent back to
+// the page they came from.
+func UpdateSessionHandler(secret []byte, authEnabled bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s, err := session.Update(c, secret, c.FormValue("name"), c.FormValue("color"))
+		htmx := c.Request().Header.Get("HX-Request") == "true"
+		if err != nil {
+			if !htmx {
+				return c.String(400, "Invalid session: "+err.Error())
+			}
+			current := session.Get(c)
+			current.Name = c.FormValue("name")
+			return layout.SessionForm(current, "Not saved: "+err.Error()+".", authEnabled).Render(c.Request().Context(), c.Response().Writer)
+		}
+
+		if htmx {
+			return layout.SessionChip(s).Render(c.Request().Context(), c.Response().Writer)
+		}
+		return c.Redirect(http.StatusSeeOther, sameOriginReferer(c))
+	}
+}
+
+// sameOriginReferer returns the path of the referring page on this site,
+// or "/" if there isn't one.
+func sameOriginReferer(c echo.Context) string {
+	ref, err := url.Parse(c.Request().Referer())
+	if err != nil || ref.Host != c.Request().Host || ref.Path == "" {
+		return "/"
+	}
+	return ref.RequestURI()
+}
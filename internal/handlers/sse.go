@@ -1,21 +1,34 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
-	"time"
+	"strings"
 
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/session"
 	"hypermedia-sync/internal/sse"
 
 	"github.com/labstack/echo/v4"
 )
 
-func SSEHandler(hub *sse.Hub) echo.HandlerFunc {
+// errSignInToFollow rejects a stream subscribed to an experiment the
+// visitor must sign in to view.
+var errSignInToFollow = errors.New("Sign in to follow this experiment live.")
+
+func SSEHandler(hub *sse.Hub, registry *experiment.Registry) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		originatorID := c.QueryParam("originator")
 		if originatorID == "" {
-			connCount := hub.GetOnlineCount()
-			originatorID = fmt.Sprintf("sse-%d", connCount)
+			id := make([]byte, 8)
+			if _, err := rand.Read(id); err != nil {
+				return c.String(500, "Error creating connection")
+			}
+			originatorID = "sse-" + hex.EncodeToString(id)
 		}
 
 		c.Response().Header().Set("Content-Type", "text/event-stream")
@@ -30,27 +43,46 @@ func SSEHandler(hub *sse.Hub) echo.HandlerFunc {
 		fmt.Fprintf(c.Response().Writer, ": connected\n\n")
 		c.Response().Flush()
 
+		s := session.Get(c)
 		conn := &sse.Connection{
-			ID:     originatorID,
-			Writer: c.Response().Writer,
-			Done:   make(chan struct{}),
+			ID:       originatorID,
+			Writer:   c.Response().Writer,
+			Done:     make(chan struct{}),
+			Topics:   make(map[string]bool),
+			Excluded: make(map[string]bool),
+			Logger:   logging.FromContext(c.Request().Context()).With("conn_id", originatorID),
+			IP:       c.RealIP(),
+			Session:  s.ID,
+		}
+		// Unknown topics are ignored rather than rejected so stale pages
+		// still connect, receiving untopiced events such as the online
+		// count.
+		for _, topic := range strings.Split(c.QueryParam("topic"), ",") {
+			if topic = strings.TrimSpace(topic); hub.KnownTopic(topic) {
+				conn.Topics[topic] = true
+			}
+		}
+		for _, topic := range registry.HiddenTopics(s.SignedIn()) {
+			if conn.Topics[topic] {
+				conn.Close()
+				return sse.WriteRejection(c.Response(), errSignInToFollow)
+			}
+			conn.Excluded[topic] = true
 		}
 
-		hub.Register(conn)
+		if err := hub.Register(conn); err != nil {
+			conn.Close()
+			return sse.WriteRejection(c.Response(), err)
+		}
 		defer func() {
 			hub.Unregister(conn)
-			close(conn.Done)
+			conn.Close()
 		}()
 
-		<-c.Request().Context().Done()
+		select {
+		case <-c.Request().Context().Done():
+		case <-conn.Evicted():
+		}
 		return nil
 	}
 }
-
-func HealthHandler(c echo.Context) error {
-	return c.JSON(200, map[string]string{
-		"status": "healthy",
-		"service": "hypermedia-sync",
-		"timestamp": time.Now().Format(time.RFC3339),
-	})
-}
\ No newline at end of file
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hypermedia-sync/internal/experiment"
+	"hypermedia-sync/internal/sse"
+
+	"github.com/labstack/echo/v4"
+)
+
+// openStream starts an /events request claiming to come from forwardedFor.
+// The stream is cut off after a few seconds, so a test expecting it to be
+// rejected fails instead of hanging.
+func openStream(t *testing.T, url, forwardedFor string) *http.Response {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestSpoofedForwardedForSharesIPLimit(t *testing.T) {
+	hub := sse.NewHub(10, sse.WithLimits(sse.Limits{
+		PerIP: sse.Limit{Max: 1, Policy: sse.PolicyReject},
+	}))
+	go hub.Run()
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.GET("/events", SSEHandler(hub, experiment.NewRegistry()))
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	first := openStream(t, server.URL, "203.0.113.1")
+	if line, err := bufio.NewReader(first.Body).ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("first stream: %q, %v", line, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetOnlineCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("first stream was not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	second := openStream(t, server.URL, "203.0.113.2")
+	body, _ := io.ReadAll(second.Body)
+	if !strings.Contains(string(body), "Too many live connections from your network") {
+		t.Errorf("second stream from the same address with a different X-Forwarded-For was not rejected:\n%s", body)
+	}
+	if got := hub.GetOnlineCount(); got != 1 {
+		t.Errorf("online = %d, want 1", got)
+	}
+}
+
+func TestFallbackIDsDontCollide(t *testing.T) {
+	hub := sse.NewHub(10)
+	go hub.Run()
+
+	e := echo.New()
+	e.GET("/events", SSEHandler(hub, experiment.NewRegistry()))
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	waitOnline := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for hub.GetOnlineCount() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("online = %d, want %d", hub.GetOnlineCount(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Without an originator the ID used to be the online count, so closing
+	// the first stream made the third reuse the second's ID.
+	first := openStream(t, server.URL, "203.0.113.1")
+	waitOnline(1)
+	openStream(t, server.URL, "203.0.113.1")
+	waitOnline(2)
+	first.Body.Close()
+	waitOnline(1)
+	openStream(t, server.URL, "203.0.113.1")
+	waitOnline(2)
+}
@@ -0,0 +1,99 @@
+// Package logging sets up structured logging and carries request-scoped
+// loggers through echo handlers.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// New returns a logger writing JSON or text records at level and above.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored by WithLogger, or the default
+// logger when there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID assigns each request an ID, honoring an incoming X-Request-ID,
+// echoes it in the response, and stores a logger tagged with it in the
+// request context.
+func RequestID() echo.MiddlewareFunc {
+	return middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, id string) {
+			req := c.Request()
+			logger := slog.Default().With("request_id", id)
+			c.SetRequest(req.WithContext(WithLogger(req.Context(), logger)))
+		},
+	})
+}
+
+// AccessLog logs one record per request once it completes. Static assets
+// are logged at debug so they don't drown out everything else.
+func AccessLog() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			req, res := c.Request(), c.Response()
+			level := slog.LevelInfo
+			switch {
+			case res.Status >= 500:
+				level = slog.LevelError
+			case c.Path() == "/static*", c.Path() == "/livez", c.Path() == "/readyz", c.Path() == "/health":
+				level = slog.LevelDebug
+			}
+			attrs := []any{
+				"method", req.Method,
+				"path", req.URL.Path,
+				"route", c.Path(),
+				"status", res.Status,
+				"bytes", res.Size,
+				"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"remote_ip", c.RealIP(),
+			}
+			if err != nil {
+				attrs = append(attrs, "err", err)
+			}
+			FromContext(req.Context()).Log(req.Context(), level, "request", attrs...)
+			return nil
+		}
+	}
+}
+
+// Recover turns handler panics into 500s and logs them with the request's
+// logger.
+func Recover() echo.MiddlewareFunc {
+	return middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			FromContext(c.Request().Context()).Error("panic recovered", "err", err, "stack", string(stack))
+			return err
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Sampler throttles log lines on hot paths. Each key logs at most once per
+// interval, and the next line that gets through reports how many were
+// suppressed in between. Keys should come from a small fixed set, such as
+// one per call site, since they are never forgotten.
+type Sampler struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*sampled
+}
+
+type sampled struct {
+	last       time.Time
+	suppressed int
+}
+
+func NewSampler(interval time.Duration) *Sampler {
+	return &Sampler{interval: interval, keys: make(map[string]*sampled)}
+}
+
+// Log writes the record through logger unless key already logged within
+// the interval.
+func (s *Sampler) Log(ctx context.Context, logger *slog.Logger, level slog.Level, key, msg string, args ...any) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	s.mu.Lock()
+	entry, ok := s.keys[key]
+	if !ok {
+		entry = &sampled{}
+		s.keys[key] = entry
+	}
+	now := time.Now()
+	if now.Sub(entry.last) < s.interval {
+		entry.suppressed++
+		s.mu.Unlock()
+		return
+	}
+	suppressed := entry.suppressed
+	entry.last, entry.suppressed = now, 0
+	s.mu.Unlock()
+
+	if suppressed > 0 {
+		args = append(args, "suppressed", suppressed)
+	}
+	logger.Log(ctx, level, msg, args...)
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var requestDuration = NewHistogram(
+	"http_request_duration_seconds",
+	"Time to serve HTTP requests, by route.",
+	DefaultBuckets,
+	"method", "route", "status",
+)
+
+// Handler serves r in the Prometheus text exposition format.
+func Handler(r *Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return r.WriteText(c.Response())
+	}
+}
+
+// Middleware records request latency per route. Routes matching skip, such
+// as long-lived SSE streams, are not recorded.
+func Middleware(skip ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, route := range skip {
+				if c.Path() == route {
+					return next(c)
+				}
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			requestDuration.Observe(time.Since(start).Seconds(), c.Request().Method, route, strconv.Itoa(c.Response().Status))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Package metrics keeps counters, gauges, and histograms and writes them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metric families in the order they were registered.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+type family interface {
+	describe() (name, help, kind string)
+	samples(emit func(suffix string, labels []string, values []string, value float64))
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Default is the registry served on /metrics. The package-level
+// constructors register with it.
+var Default = NewRegistry()
+
+func (r *Registry) register(f family) {
+	name, _, _ := f.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// WriteText writes every family in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		name, help, kind := f.describe()
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, kind)
+		f.samples(func(suffix string, labels, values []string, value float64) {
+			bw.WriteString(name + suffix)
+			if len(labels) > 0 {
+				bw.WriteByte('{')
+				for i, label := range labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					bw.WriteString(label + `="` + escapeLabel(values[i]) + `"`)
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(value))
+			bw.WriteByte('\n')
+		})
+	}
+	return bw.Flush()
+}
+
+// vec stores one value per combination of label values.
+type vec[T any] struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*T
+	values map[string][]string
+}
+
+func newVec[T any](name, help string, labels []string) vec[T] {
+	return vec[T]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+	}
+}
+
+// get returns the series for values, creating it with init. The caller
+// must hold v.mu.
+func (v *vec[T]) get(values []string, init func() *T) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = init()
+		v.series[key] = s
+		v.values[key] = slices.Clone(values)
+	}
+	return s
+}
+
+// each visits series sorted by label values. The caller must hold v.mu.
+func (v *vec[T]) each(fn func(values []string, s *T)) {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fn(v.values[key], v.series[key])
+	}
+}
+
+// Counter only goes up. Label values are passed to Inc and Add in the
+// order the labels were declared.
+type Counter struct {
+	vec[float64]
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newVec[float64](name, help, labels)}
+	if len(labels) == 0 {
+		// Unlabeled counters report zero before their first increment
+		c.Add(0)
+	}
+	Default.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.get(labelValues, func() *float64 { return new(float64) }) += delta
+}
+
+func (c *Counter) describe() (string, string, string) { return c.name, c.help, "counter" }
+
+func (c *Counter) samples(emit func(string, []string, []string, float64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.each(func(values []string, v *float64) {
+		emit("", c.labels, values, *v)
+	})
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	vec[histogramSeries]
+	buckets []float64
+}
+
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// DefaultBuckets suit request latencies in seconds.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{newVec[histogramSeries](name, help, labels), slices.Sorted(slices.Values(buckets))}
+	Default.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues, func() *histogramSeries {
+		return &histogramSeries{counts: make([]uint64, len(h.buckets))}
+	})
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *Histogram) describe() (string, string, string) { return h.name, h.help, "histogram" }
+
+func (h *Histogram) samples(emit func(string, []string, []string, float64)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	bucketLabels := append(slices.Clone(h.labels), "le")
+	h.each(func(values []string, s *histogramSeries) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			emit("_bucket", bucketLabels, append(slices.Clone(values), formatValue(bound)), float64(cumulative))
+		}
+		emit("_bucket", bucketLabels, append(slices.Clone(values), "+Inf"), float64(s.count))
+		emit("_sum", h.labels, values, s.sum)
+		emit("_count", h.labels, values, float64(s.count))
+	})
+}
+
+// Func reads its samples from elsewhere at scrape time, for values another
+// package already tracks.
+type Func struct {
+	name, help, kind string
+	labels           []string
+	collect          func(emit func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc registers a gauge whose samples come from collect.
+func NewGaugeFunc(name, help string, labels []string, collect func(emit func(value float64, labelValues ...string))) {
+	Default.register(&Func{name, help, "gauge", labels, collect})
+}
+
+// NewCounterFunc registers a counter whose samples come from collect.
+func NewCounterFunc(name, help string, labels []string, collect func(emit func(value float64, labelValues ...string))) {
+	Default.register(&Func{name, help, "counter", labels, collect})
+}
+
+func (f *Func) describe() (string, string, string) { return f.name, f.help, f.kind }
+
+func (f *Func) samples(emit func(string, []string, []string, float64)) {
+	f.collect(func(value float64, labelValues ...string) {
+		emit("", f.labels, labelValues, value)
+	})
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,125 @@
+// Package ratelimit applies token bucket limits per route, keyed by the
+// visitor's session where it has one and by IP otherwise, so it must run
+// after the session middleware. Denials are answered with a toast fragment
+// HTMX can show in place.
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/metrics"
+	"hypermedia-sync/internal/session"
+	"hypermedia-sync/internal/templates/layout"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Policy is a token bucket refilled at Rate tokens per second and holding
+// up to Burst. A zero Rate exempts the route from limiting.
+type Policy struct {
+	Rate  float64
+	Burst int
+}
+
+// Exempt reports whether requests under the policy are never limited.
+func (p Policy) Exempt() bool {
+	return p.Rate == 0
+}
+
+type Config struct {
+	// Default applies to routes without their own policy.
+	Default Policy
+	// Routes overrides Default by route pattern, as registered with echo
+	// (for example "/experiments/canvas-draw-sync/clear" or "/static*").
+	Routes map[string]Policy
+	// PerIP caps each IP across every route that isn't exempt, so visitors
+	// can't get fresh budgets by discarding their session cookie.
+	PerIP Policy
+	// Expires is how long an idle visitor's buckets are kept.
+	Expires time.Duration
+}
+
+var denials = metrics.NewCounter("http_rate_limit_denials_total", "Requests rejected by the rate limiter, by policy.", "policy")
+
+// Middleware enforces cfg. Each route policy has its own buckets, so
+// spending the budget for one route leaves the others untouched.
+func Middleware(cfg Config) echo.MiddlewareFunc {
+	routes := make(map[string]*store, len(cfg.Routes))
+	for route, policy := range cfg.Routes {
+		if !policy.Exempt() {
+			routes[route] = newStore(policy, cfg.Expires)
+		}
+	}
+	fallback := newStore(cfg.Default, cfg.Expires)
+	perIP := newStore(cfg.PerIP, cfg.Expires)
+	sampler := logging.NewSampler(10 * time.Second)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Path()
+			if policy, ok := cfg.Routes[route]; ok && policy.Exempt() {
+				return next(c)
+			}
+			s, name := routes[route], route
+			if s == nil {
+				s, name = fallback, "default"
+			}
+
+			now := time.Now()
+			ip := c.RealIP()
+			if ok, retryAfter := perIP.take(ip, now); !ok {
+				return deny(c, sampler, "ip", ip, retryAfter)
+			}
+			key := visitorKey(c)
+			if ok, retryAfter := s.take(key, now); !ok {
+				return deny(c, sampler, name, key, retryAfter)
+			}
+			return next(c)
+		}
+	}
+}
+
+// visitorKey identifies the visitor by their session. Sessions the
+// browser hasn't sent back yet are counted against the IP, so clients that
+// never keep cookies share a single budget.
+func visitorKey(c echo.Context) string {
+	if s := session.Get(c); s.ID != "" && !s.New {
+		return "session:" + s.ID
+	}
+	return "ip:" + c.RealIP()
+}
+
+// deny answers with 429 and Retry-After. HTMX requests get a toast
+// appended to the page's toast stack rather than replacing their target.
+func deny(c echo.Context, sampler *logging.Sampler, policy, key string, retryAfter time.Duration) error {
+	ctx := c.Request().Context()
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	sampler.Log(ctx, logging.FromContext(ctx), slog.LevelWarn, "deny:"+policy,
+		"rate limit exceeded", "policy", policy, "key", key, "retry_after_s", seconds)
+	denials.Inc(policy)
+
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	message := fmt.Sprintf("You're going a little fast. Try again in %s.", plural(seconds, "second"))
+
+	if c.Request().Header.Get("HX-Request") != "true" {
+		return c.String(http.StatusTooManyRequests, message)
+	}
+	c.Response().Header().Set("HX-Retarget", "#toasts")
+	c.Response().Header().Set("HX-Reswap", "beforeend")
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(http.StatusTooManyRequests)
+	return layout.Toast(message).Render(ctx, c.Response().Writer)
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// store keeps one token bucket per key for a policy, forgetting keys that
+// have been idle for longer than expires.
+type store struct {
+	policy  Policy
+	expires time.Duration
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newStore(policy Policy, expires time.Duration) *store {
+	return &store{
+		policy:      policy,
+		expires:     expires,
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+	}
+}
+
+// take spends a token for key. When none is available it reports how long
+// until one will be.
+func (s *store) take(key string, now time.Time) (ok bool, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastCleanup) > s.expires {
+		for k, b := range s.buckets {
+			if now.Sub(b.lastSeen) > s.expires {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastCleanup = now
+	}
+
+	b, exists := s.buckets[key]
+	if !exists {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(s.policy.Rate), s.policy.Burst)}
+		s.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
@@ -0,0 +1,195 @@
+// Package session gives each browser a stable identity with a display name
+// and color, carried in a signed cookie so it survives restarts without
+// any server-side storage.
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	CookieName = "session"
+	cookieAge  = 365 * 24 * time.Hour
+	// MaxNameLength is the longest display name in characters.
+	MaxNameLength = 32
+)
+
+// Colors are the choices offered for a session's color.
+var Colors = []string{"#f97316", "#22c55e", "#3b82f6", "#a855f7", "#ec4899", "#eab308", "#14b8a6", "#ef4444"}
+
+type Session struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	// Subject and Email identify the signed-in user, until SignedInUntil
+	// (Unix seconds). They are empty for anonymous visitors.
+	Subject       string `json:"sub,omitempty"`
+	Email         string `json:"email,omitempty"`
+	SignedInUntil int64  `json:"until,omitempty"`
+	// New is set when the session was created for this request rather
+	// than sent back by the browser.
+	New bool `json:"-"`
+}
+
+// SignedIn reports whether the session belongs to a user who has signed in
+// and whose sign-in hasn't expired.
+func (s Session) SignedIn() bool {
+	return s.Subject != "" && time.Now().Unix() < s.SignedInUntil
+}
+
+type contextKey struct{}
+
+// WithSession returns a copy of ctx carrying s.
+func WithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the session stored by the middleware. It is the zero
+// Session for requests the middleware skipped, and in contexts, such as
+// hub broadcasts, that don't belong to a request.
+func FromContext(ctx context.Context) Session {
+	s, _ := ctx.Value(contextKey{}).(Session)
+	return s
+}
+
+// Get returns the session for the request.
+func Get(c echo.Context) Session {
+	return FromContext(c.Request().Context())
+}
+
+// Middleware loads the session from its cookie, or starts a new one, and
+// stores it in the request context. Routes in skip, such as static files,
+// get no session.
+func Middleware(secret []byte, skip ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if slices.Contains(skip, c.Path()) {
+				return next(c)
+			}
+			s, ok := Session{}, false
+			if cookie, err := c.Cookie(CookieName); err == nil {
+				s, ok = decode(secret, cookie.Value)
+			}
+			if !ok {
+				s = newSession()
+				setCookie(c, secret, s)
+			}
+			c.SetRequest(c.Request().WithContext(WithSession(c.Request().Context(), s)))
+			return next(c)
+		}
+	}
+}
+
+// Update changes the request's session to the given name and color and
+// sends the new cookie. The name is trimmed; an empty name or unknown
+// color is an error.
+func Update(c echo.Context, secret []byte, name, color string) (Session, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	switch {
+	case name == "":
+		return Session{}, errors.New("name can't be empty")
+	case utf8.RuneCountInString(name) > MaxNameLength:
+		return Session{}, fmt.Errorf("name can be at most %d characters", MaxNameLength)
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return Session{}, errors.New("name contains invalid characters")
+	case !slices.Contains(Colors, color):
+		return Session{}, errors.New("pick one of the offered colors")
+	}
+
+	s := Get(c)
+	s.Name, s.Color = name, color
+	return Save(c, secret, s), nil
+}
+
+// Save sends s as the session cookie and makes it the request's session
+// for the rest of the request.
+func Save(c echo.Context, secret []byte, s Session) Session {
+	s.New = false
+	setCookie(c, secret, s)
+	c.SetRequest(c.Request().WithContext(WithSession(c.Request().Context(), s)))
+	return s
+}
+
+func setCookie(c echo.Context, secret []byte, s Session) {
+	c.SetCookie(&http.Cookie{
+		Name:     CookieName,
+		Value:    Seal(secret, s),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   c.Scheme() == "https",
+		Expires:  time.Now().Add(cookieAge),
+	})
+}
+
+func decode(secret []byte, value string) (Session, bool) {
+	var s Session
+	if !Open(secret, value, &s) || s.ID == "" {
+		return Session{}, false
+	}
+	return s, true
+}
+
+// Seal signs v as base64(json) + "." + base64(hmac), for cookies that must
+// come back unmodified.
+func Seal(secret []byte, v any) string {
+	payload, _ := json.Marshal(v)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(secret, encoded))
+}
+
+// Open checks a value made by Seal and decodes it into v.
+func Open(secret []byte, value string, v any) bool {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, sign(secret, encoded)) {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(payload, v) == nil
+}
+
+func sign(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+var (
+	adjectives = []string{"Swift", "Quiet", "Bright", "Clever", "Brave", "Calm", "Eager", "Gentle", "Happy", "Jolly", "Lucky", "Nimble", "Proud", "Sunny", "Witty", "Zesty"}
+	animals    = []string{"Otter", "Falcon", "Panda", "Fox", "Heron", "Lynx", "Koala", "Marmot", "Newt", "Owl", "Puffin", "Quokka", "Raven", "Seal", "Tiger", "Wombat"}
+)
+
+func newSession() Session {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return Session{
+		ID:    hex.EncodeToString(id),
+		Name:  adjectives[mathrand.IntN(len(adjectives))] + " " + animals[mathrand.IntN(len(animals))],
+		Color: Colors[mathrand.IntN(len(Colors))],
+		New:   true,
+	}
+}
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	secret := []byte("secret")
+	sealed := Seal(secret, Session{ID: "abc", Name: "Calm Otter", Color: Colors[0]})
+	encoded, signature, _ := strings.Cut(sealed, ".")
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("not json"))
+	changed := base64.RawURLEncoding.EncodeToString([]byte(`{"id":"abc","name":"Calm Otter","color":"#000000"}`))
+
+	tests := []struct {
+		name   string
+		secret []byte
+		value  string
+		ok     bool
+	}{
+		{"unchanged", secret, sealed, true},
+		{"changed payload", secret, changed + "." + signature, false},
+		{"wrong secret", []byte("other"), sealed, false},
+		{"no signature", secret, encoded, false},
+		{"truncated signature", secret, encoded + "." + signature[:len(signature)-2], false},
+		{"signature not base64", secret, encoded + ".!!", false},
+		{"signed non-JSON", secret, notJSON + "." + base64.RawURLEncoding.EncodeToString(sign(secret, notJSON)), false},
+		{"empty", secret, "", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var s Session
+			if ok := Open(tc.secret, tc.value, &s); ok != tc.ok {
+				t.Fatalf("Open = %v, want %v", ok, tc.ok)
+			}
+			if tc.ok && (s.ID != "abc" || s.Name != "Calm Otter" || s.Color != Colors[0]) {
+				t.Errorf("Open decoded %+v", s)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsMissingID(t *testing.T) {
+	secret := []byte("secret")
+	if _, ok := decode(secret, Seal(secret, Session{Name: "Calm Otter"})); ok {
+		t.Error("decode accepted a session without an ID")
+	}
+}
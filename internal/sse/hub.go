@@ -3,136 +3,408 @@ package sse
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
 	"net/http"
+	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"hypermedia-sync/internal/logging"
 	"hypermedia-sync/internal/templates/layout"
+	"hypermedia-sync/internal/tracing"
 )
 
 type Hub struct {
 	connections map[string]*Connection
 	broadcast   chan Event
-	register    chan *Connection
+	register    chan registration
 	unregister  chan *Connection
+	// ping is answered by Run, so a reply shows the loop is still serving.
+	ping        chan chan struct{}
 	connMu      sync.RWMutex
 	onlineCount int
+	topicsMu    sync.RWMutex
+	topics      map[string]bool
+	logger      *slog.Logger
+	// sampler throttles per-delivery failures, which can arrive once per
+	// connection per event.
+	sampler *logging.Sampler
+
+	events         atomic.Uint64
+	deliveries     atomic.Uint64
+	writeErrors    atomic.Uint64
+	latencyNanos   atomic.Uint64
+	latencySamples atomic.Uint64
+	connects       atomic.Uint64
+	disconnects    atomic.Uint64
+	bytesWritten   atomic.Uint64
+	renderErrors   atomic.Uint64
+	restarts       atomic.Uint64
+
+	// renderOnlineCount renders the online counter fragment. Tests can
+	// swap it with WithOnlineCountRenderer.
+	renderOnlineCount func(online int) (string, error)
+
+	// eventCounts counts broadcasts by eventKind.
+	eventCountsMu sync.Mutex
+	eventCounts   map[string]uint64
+
+	limits Limits
+	// rejected and evicted count connections turned away or closed by
+	// each limit.
+	limitCountsMu sync.Mutex
+	rejected      map[Scope]uint64
+	evicted       map[Scope]uint64
+}
+
+type registration struct {
+	conn   *Connection
+	result chan error
 }
 
 type Connection struct {
 	ID     string
 	Writer http.ResponseWriter
 	Done   chan struct{}
+	Topics map[string]bool // Subscribed topics; empty means untopiced events only
+	// Excluded topics are never delivered, even if subscribed, such as
+	// experiments the client may not view.
+	Excluded map[string]bool
+	// Logger is tagged with the connection's ID and the request that
+	// opened it. Nil falls back to the hub's logger.
+	Logger *slog.Logger
+	// IP and Session identify the client for per-IP and per-session
+	// limits. Empty values are not limited in that scope.
+	IP      string
+	Session string
+
+	opened  time.Time
+	evicted chan struct{}
+
+	// writeMu keeps concurrent deliveries from interleaving on the wire.
+	writeMu sync.Mutex
+	// pending counts deliveries started but not yet written.
+	pending atomic.Int64
+}
+
+// Subscribed reports whether the connection should receive events for topic.
+// Untopiced events go to everyone; topiced events only to subscribers.
+func (c *Connection) Subscribed(topic string) bool {
+	return topic == "" || c.Topics[topic] && !c.Excluded[topic]
 }
 
 type Event struct {
 	Name      string
 	Data      string
 	ExcludeID string // Originator ID to exclude from broadcast
+	Topic     string // Limits delivery to subscribers of this topic
+	// Trace is the span that broadcast the event; deliveries are traced
+	// as its children. BroadcastContext sets it.
+	Trace tracing.SpanContext
+
+	queued time.Time
+}
+
+// Option customizes a hub created by NewHub.
+type Option func(*Hub)
+
+// WithOnlineCountRenderer replaces how the online counter fragment is
+// rendered, such as to inject failures in tests.
+func WithOnlineCountRenderer(render func(online int) (string, error)) Option {
+	return func(h *Hub) { h.renderOnlineCount = render }
 }
 
-func NewHub() *Hub {
-	return &Hub{
+// NewHub returns a hub that queues up to buffer broadcasts before Broadcast
+// blocks.
+func NewHub(buffer int, opts ...Option) *Hub {
+	h := &Hub{
 		connections: make(map[string]*Connection),
-		broadcast:   make(chan Event, 100),
-		register:    make(chan *Connection),
+		broadcast:   make(chan Event, buffer),
+		register:    make(chan registration),
 		unregister:  make(chan *Connection),
+		ping:        make(chan chan struct{}),
+		topics:      make(map[string]bool),
+		eventCounts: make(map[string]uint64),
+		rejected:    make(map[Scope]uint64),
+		evicted:     make(map[Scope]uint64),
+		logger:      slog.Default().With("component", "hub"),
+		sampler:     logging.NewSampler(10 * time.Second),
+	}
+	h.renderOnlineCount = renderOnlineCount
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
+}
+
+func renderOnlineCount(online int) (string, error) {
+	var buf bytes.Buffer
+	err := layout.OnlineCounter(online).Render(context.Background(), &buf)
+	return buf.String(), err
 }
 
+// connLogger returns the logger for hub lines about conn.
+func (h *Hub) connLogger(conn *Connection) *slog.Logger {
+	if conn.Logger != nil {
+		return conn.Logger.With("component", "hub")
+	}
+	return h.logger.With("conn_id", conn.ID)
+}
+
+// RegisterTopics declares topics that connections may subscribe to.
+func (h *Hub) RegisterTopics(topics ...string) {
+	h.topicsMu.Lock()
+	defer h.topicsMu.Unlock()
+	for _, topic := range topics {
+		h.topics[topic] = true
+	}
+}
+
+// KnownTopic reports whether topic was registered.
+func (h *Hub) KnownTopic(topic string) bool {
+	h.topicsMu.RLock()
+	defer h.topicsMu.RUnlock()
+	return h.topics[topic]
+}
+
+// restartDelay is how long Run waits before restarting the loop after a
+// panic, so a panic on every event can't spin.
+const restartDelay = 100 * time.Millisecond
+
+// Run serves registrations and broadcasts until the process exits. A panic
+// in the loop is logged and counted, and the loop restarts with the same
+// connections.
 func (h *Hub) Run() {
 	for {
-		select {
-		case conn := <-h.register:
-			h.connMu.Lock()
-			h.connections[conn.ID] = conn
-			h.onlineCount = len(h.connections)
-			onlineCount := h.onlineCount
-			h.connMu.Unlock()
-
-			var buf bytes.Buffer
-			err := layout.OnlineCounter(onlineCount).Render(context.Background(), &buf)
-			if err != nil {
-				fmt.Printf("Error rendering online counter: %v\n", err)
-				return
-			}
-			h.broadcast <- Event{
-				Name: "online-count-updated",
-				Data: buf.String(),
-			}
+		if !h.loop() {
+			return
+		}
+		h.restarts.Add(1)
+		time.Sleep(restartDelay)
+	}
+}
 
+// loop runs the hub until it panics, in which case it returns true. Locks
+// are released before rendering or delivering, or by defer, so a panic
+// can't leave them held.
+func (h *Hub) loop() (restart bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.logger.Error("hub loop panicked, restarting", "panic", r, "stack", string(debug.Stack()))
+			restart = true
+		}
+	}()
+	for {
+		select {
+		case reg := <-h.register:
+			h.addConnection(reg)
 		case conn := <-h.unregister:
-			h.connMu.Lock()
-			if _, exists := h.connections[conn.ID]; exists {
-				delete(h.connections, conn.ID)
-				h.onlineCount = len(h.connections)
-				onlineCount := h.onlineCount
-				h.connMu.Unlock()
-
-				var buf bytes.Buffer
-				err := layout.OnlineCounter(onlineCount).Render(context.Background(), &buf)
-				if err != nil {
-					fmt.Printf("Error rendering online counter: %v\n", err)
-					h.connMu.Unlock()
-					return
-				}
-				h.broadcast <- Event{
-					Name: "online-count-updated",
-					Data: buf.String(),
-				}
-			} else {
-				h.connMu.Unlock()
-			}
-
+			h.removeConnection(conn)
+		case reply := <-h.ping:
+			close(reply)
 		case event := <-h.broadcast:
-			h.connMu.RLock()
-			for connID, conn := range h.connections {
-				if connID != event.ExcludeID {
-					go func(c *Connection) {
-						defer func() {
-							if r := recover(); r != nil {
-								fmt.Printf("Error broadcasting to connection: %v\n", r)
-							}
-						}()
-
-						select {
-						case <-c.Done:
-							return
-						default:
-						}
-
-						if c.Writer == nil {
-							fmt.Printf("Warning: Writer is nil for connection %s\n", c.ID)
-							return
-						}
-
-						eventData := strings.ReplaceAll(event.Data, "\n", "\ndata: ")
-						_, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Name, eventData)
-						if err != nil {
-							fmt.Printf("Error writing to connection %s: %v\n", c.ID, err)
-							return
-						}
-
-						if flusher, ok := c.Writer.(http.Flusher); ok {
-							flusher.Flush()
-						}
-					}(conn)
-				}
-			}
-			h.connMu.RUnlock()
+			h.dispatch(event)
 		}
 	}
 }
 
+// errRegistrationFailed answers a registration whose admission panicked.
+var errRegistrationFailed = errors.New("The server couldn't open a live connection. Try again in a minute.")
+
+// addConnection admits reg's connection within the limits, evicting older
+// connections if the limits call for it. The result is sent by defer, so
+// Register gets an answer even if admitting or announcing panics.
+func (h *Hub) addConnection(reg registration) {
+	conn := reg.conn
+	result := errRegistrationFailed
+	defer func() { reg.result <- result }()
+
+	h.connMu.Lock()
+	evictions, rejected := h.admit(conn)
+	if rejected != nil {
+		h.connMu.Unlock()
+		h.countLimit(h.rejected, rejected.Scope)
+		h.connLogger(conn).Info("connection rejected", "scope", rejected.Scope, "ip", conn.IP)
+		result = rejected
+		return
+	}
+	for _, e := range evictions {
+		delete(h.connections, e.conn.ID)
+	}
+	conn.opened = time.Now()
+	h.connections[conn.ID] = conn
+	h.onlineCount = len(h.connections)
+	onlineCount := h.onlineCount
+	h.connMu.Unlock()
+	result = nil
+
+	h.connects.Add(1)
+	h.connLogger(conn).Debug("connection registered", "online", onlineCount)
+	for _, e := range evictions {
+		h.disconnects.Add(1)
+		h.countLimit(h.evicted, e.scope)
+		h.connLogger(e.conn).Info("connection evicted", "scope", e.scope, "ip", e.conn.IP)
+		go h.evict(e.conn, e.scope)
+	}
+	h.announceOnlineCount(onlineCount)
+}
+
+func (h *Hub) removeConnection(conn *Connection) {
+	h.connMu.Lock()
+	// A stale connection whose ID has since been reused must not remove
+	// the newer one.
+	if h.connections[conn.ID] != conn {
+		h.connMu.Unlock()
+		return
+	}
+	delete(h.connections, conn.ID)
+	h.onlineCount = len(h.connections)
+	onlineCount := h.onlineCount
+	h.connMu.Unlock()
+	h.disconnects.Add(1)
+	h.connLogger(conn).Debug("connection unregistered", "online", onlineCount)
+	h.announceOnlineCount(onlineCount)
+}
+
+// announceOnlineCount sends the new count to everyone. It is delivered
+// directly rather than queued, since the loop would block on its own queue
+// when it is full. A render failure skips this update only.
+func (h *Hub) announceOnlineCount(online int) {
+	data, err := h.renderOnlineCount(online)
+	if err != nil {
+		h.renderErrors.Add(1)
+		h.sampler.Log(context.Background(), h.logger, slog.LevelError, "render-online-count",
+			"rendering online counter", "online", online, "err", err)
+		return
+	}
+	h.dispatch(Event{Name: "online-count-updated", Data: data})
+}
+
+// writeEvent writes one event in the text/event-stream format.
+func writeEvent(w io.Writer, name, data string) (int, error) {
+	return fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, strings.ReplaceAll(data, "\n", "\ndata: "))
+}
+
+// dispatch starts a delivery of event to every subscribed connection.
+func (h *Hub) dispatch(event Event) {
+	h.events.Add(1)
+	h.countEvent(event.Name)
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	for connID, conn := range h.connections {
+		if connID != event.ExcludeID && conn.Subscribed(event.Topic) {
+			conn.pending.Add(1)
+			go h.deliver(event, conn)
+		}
+	}
+}
+
+func (h *Hub) deliver(event Event, c *Connection) {
+	defer c.pending.Add(-1)
+	span := h.startDelivery(event, c)
+	defer span.End()
+	defer func() {
+		if r := recover(); r != nil {
+			h.sampler.Log(context.Background(), h.connLogger(c), slog.LevelError, "delivery-panic",
+				"delivery panicked", "event", event.Name, "panic", r)
+		}
+	}()
+
+	if c.Writer == nil {
+		h.connLogger(c).Warn("connection has no writer", "event", event.Name)
+		return
+	}
+
+	c.writeMu.Lock()
+	if c.closed() {
+		c.writeMu.Unlock()
+		return
+	}
+	n, err := writeEvent(c.Writer, event.Name, event.Data)
+	h.bytesWritten.Add(uint64(n))
+	if err != nil {
+		c.writeMu.Unlock()
+		h.writeErrors.Add(1)
+		span.RecordError(err)
+		h.sampler.Log(context.Background(), h.connLogger(c), slog.LevelWarn, "write-error",
+			"writing event", "event", event.Name, "err", err)
+		return
+	}
+
+	if flusher, ok := c.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	c.writeMu.Unlock()
+	h.deliveries.Add(1)
+	if !event.queued.IsZero() {
+		h.latencyNanos.Add(uint64(time.Since(event.queued)))
+		h.latencySamples.Add(1)
+	}
+}
+
 func (h *Hub) GetOnlineCount() int {
 	h.connMu.RLock()
 	defer h.connMu.RUnlock()
 	return len(h.connections)
 }
 
-func (h *Hub) Register(conn *Connection) {
-	h.register <- conn
+// Register adds conn to the hub, or returns a *LimitError if a limit
+// rejects it, or another error if the hub failed while admitting it. Connections evicted to make room for it are closed; callers
+// should stop serving a connection once Evicted is closed.
+func (h *Hub) Register(conn *Connection) error {
+	conn.evicted = make(chan struct{})
+	result := make(chan error, 1)
+	h.register <- registration{conn, result}
+	return <-result
+}
+
+// Close closes Done, waiting for any write in progress, so the hub doesn't
+// touch the Writer once Close returns. Handlers call it before returning
+// instead of closing Done themselves.
+func (c *Connection) Close() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	close(c.Done)
+}
+
+// Write sends one event on the connection, serialized with the hub's
+// deliveries, for handlers that stream their own events to a registered
+// connection. It returns net.ErrClosed once the connection is closed.
+func (c *Connection) Write(name, data string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed() {
+		return net.ErrClosed
+	}
+	if _, err := writeEvent(c.Writer, name, data); err != nil {
+		return err
+	}
+	if flusher, ok := c.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (c *Connection) closed() bool {
+	select {
+	case <-c.Done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Evicted is closed when the hub drops the connection to make room for
+// another. It is only valid after Register.
+func (c *Connection) Evicted() <-chan struct{} {
+	return c.evicted
 }
 
 func (h *Hub) Unregister(conn *Connection) {
@@ -140,6 +412,209 @@ func (h *Hub) Unregister(conn *Connection) {
 }
 
 func (h *Hub) Broadcast(event Event) {
+	event.queued = time.Now()
 	h.broadcast <- event
 }
 
+// Ping waits for Run to answer, failing if the loop has exited or is stuck
+// until ctx is done.
+func (h *Hub) Ping(ctx context.Context) error {
+	reply := make(chan struct{})
+	select {
+	case h.ping <- reply:
+	case <-ctx.Done():
+		return fmt.Errorf("hub loop not responding: %w", ctx.Err())
+	}
+	<-reply
+	return nil
+}
+
+// BroadcastContext broadcasts event as part of the trace in ctx. The wait
+// to enqueue is recorded as a span, and each delivery becomes its child.
+func (h *Hub) BroadcastContext(ctx context.Context, event Event) {
+	_, span := tracing.Start(ctx, "hub.broadcast", tracing.WithKind(tracing.KindProducer),
+		tracing.WithAttributes("sse.event", event.Name, "sse.topic", event.Topic))
+	defer span.End()
+	event.Trace = span.Context()
+	h.Broadcast(event)
+}
+
+// startDelivery starts a span for writing event to conn if the event is
+// traced. Its sse.queue_wait_ms attribute is the time since Broadcast.
+func (h *Hub) startDelivery(event Event, conn *Connection) *tracing.Span {
+	if !event.Trace.Sampled {
+		return nil
+	}
+	_, span := tracing.Start(context.Background(), "hub.deliver",
+		tracing.WithParent(event.Trace), tracing.WithKind(tracing.KindConsumer),
+		tracing.WithAttributes("sse.event", event.Name, "sse.connection", conn.ID, "sse.queue_wait_ms", time.Since(event.queued)))
+	return span
+}
+
+// Connected reports whether a connection with the given originator ID is open.
+func (h *Hub) Connected(id string) bool {
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	_, ok := h.connections[id]
+	return ok
+}
+
+// HasSubscribers reports whether any open connection is subscribed to
+// topic, so publishers can skip rendering events nobody would receive.
+func (h *Hub) HasSubscribers(topic string) bool {
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	for _, conn := range h.connections {
+		if conn.Topics[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionSubscribed reports whether any open connection from the given
+// session is subscribed to topic.
+func (h *Hub) SessionSubscribed(session, topic string) bool {
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	for _, conn := range h.connections {
+		if conn.Session == session && conn.Subscribed(topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// TopicSessions counts the distinct sessions with a connection subscribed
+// to topic, so a visitor with several tabs open counts once.
+func (h *Hub) TopicSessions(topic string) int {
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	sessions := make(map[string]bool)
+	for _, conn := range h.connections {
+		if conn.Session != "" && conn.Subscribed(topic) {
+			sessions[conn.Session] = true
+		}
+	}
+	return len(sessions)
+}
+
+// Stats is a snapshot of the hub's counters. Counters only grow; callers
+// sampling them compute rates from the difference between two snapshots.
+type Stats struct {
+	Connections int
+	Events      uint64 // Events taken off the broadcast queue
+	Deliveries  uint64 // Events written to a connection
+	WriteErrors uint64
+	// Latency is the total time from Broadcast to a completed write, over
+	// LatencySamples deliveries.
+	Latency        time.Duration
+	LatencySamples uint64
+	// Subscribers counts connections per registered topic. Connections
+	// without a topic, which only receive untopiced events, are counted
+	// under "".
+	Subscribers map[string]int
+
+	Connects     uint64
+	Disconnects  uint64
+	BytesWritten uint64
+	// EventsByKind counts broadcasts by event name, with IDs folded
+	// together (see eventKind).
+	EventsByKind map[string]uint64
+	// QueueDepth is how many broadcasts are waiting, out of QueueCapacity.
+	QueueDepth    int
+	QueueCapacity int
+	// Pending counts deliveries not yet written across all connections,
+	// and MaxPending is the most waiting on any one connection.
+	Pending    int
+	MaxPending int
+	// RenderErrors counts online counter updates skipped because they
+	// failed to render, and Restarts counts recovered loop panics.
+	RenderErrors uint64
+	Restarts     uint64
+	// Limits are the configured caps. Rejected and Evicted count
+	// connections refused or closed by each scope's limit.
+	Limits   Limits
+	Rejected map[Scope]uint64
+	Evicted  map[Scope]uint64
+}
+
+func (h *Hub) Stats() Stats {
+	stats := Stats{
+		Events:         h.events.Load(),
+		Deliveries:     h.deliveries.Load(),
+		WriteErrors:    h.writeErrors.Load(),
+		Latency:        time.Duration(h.latencyNanos.Load()),
+		LatencySamples: h.latencySamples.Load(),
+		Subscribers:    make(map[string]int),
+		Connects:       h.connects.Load(),
+		Disconnects:    h.disconnects.Load(),
+		BytesWritten:   h.bytesWritten.Load(),
+		EventsByKind:   make(map[string]uint64),
+		QueueDepth:     len(h.broadcast),
+		QueueCapacity:  cap(h.broadcast),
+		RenderErrors:   h.renderErrors.Load(),
+		Restarts:       h.restarts.Load(),
+		Limits:         h.limits,
+		Rejected:       make(map[Scope]uint64),
+		Evicted:        make(map[Scope]uint64),
+	}
+
+	h.limitCountsMu.Lock()
+	maps.Copy(stats.Rejected, h.rejected)
+	maps.Copy(stats.Evicted, h.evicted)
+	h.limitCountsMu.Unlock()
+
+	h.eventCountsMu.Lock()
+	maps.Copy(stats.EventsByKind, h.eventCounts)
+	h.eventCountsMu.Unlock()
+
+	h.topicsMu.RLock()
+	for topic := range h.topics {
+		stats.Subscribers[topic] = 0
+	}
+	h.topicsMu.RUnlock()
+
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	stats.Connections = len(h.connections)
+	for _, conn := range h.connections {
+		pending := int(conn.pending.Load())
+		stats.Pending += pending
+		stats.MaxPending = max(stats.MaxPending, pending)
+		if len(conn.Topics) == 0 {
+			stats.Subscribers[""]++
+		}
+		for topic := range conn.Topics {
+			stats.Subscribers[topic]++
+		}
+	}
+	return stats
+}
+
+// maxEventKinds bounds how many event kinds are counted separately; the
+// rest are counted as "other".
+const maxEventKinds = 256
+
+func (h *Hub) countEvent(name string) {
+	kind := eventKind(name)
+	h.eventCountsMu.Lock()
+	defer h.eventCountsMu.Unlock()
+	if _, ok := h.eventCounts[kind]; !ok && len(h.eventCounts) >= maxEventKinds {
+		kind = "other"
+	}
+	h.eventCounts[kind]++
+}
+
+// eventKind folds IDs out of an event name so that, for example, every
+// "checkbox-42-updated" counts as "checkbox-*-updated". Any dash-separated
+// part containing a digit is treated as an ID.
+func eventKind(name string) string {
+	parts := strings.Split(name, "-")
+	for i, part := range parts {
+		if strings.ContainsAny(part, "0123456789") {
+			parts[i] = "*"
+		}
+	}
+	return strings.Join(parts, "-")
+}
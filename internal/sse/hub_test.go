@@ -0,0 +1,200 @@
+package sse
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder is a ResponseWriter that hands each write to the test.
+type recorder struct {
+	header http.Header
+	writes chan string
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header), writes: make(chan string, 16)}
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+func (r *recorder) WriteHeader(int)     {}
+func (r *recorder) Write(p []byte) (int, error) {
+	r.writes <- string(p)
+	return len(p), nil
+}
+
+func newConnection(id string) (*Connection, *recorder) {
+	w := newRecorder()
+	return &Connection{ID: id, Writer: w, Done: make(chan struct{})}, w
+}
+
+// within fails the test if fn doesn't return in time.
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+// receive waits for an event named name on w, skipping others.
+func receive(t *testing.T, w *recorder, name string) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case write := <-w.writes:
+			if strings.HasPrefix(write, "event: "+name+"\n") {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("event %q not delivered", name)
+		}
+	}
+}
+
+// startHub runs a hub that logs nowhere, since the failures logged here
+// are expected.
+func startHub(opts ...Option) *Hub {
+	hub := NewHub(10, opts...)
+	hub.logger = slog.New(slog.DiscardHandler)
+	go hub.Run()
+	return hub
+}
+
+func TestRenderErrorSkipsOnlineCount(t *testing.T) {
+	hub := startHub(WithOnlineCountRenderer(func(int) (string, error) {
+		return "", errors.New("template broke")
+	}))
+
+	conn, w := newConnection("a")
+	within(t, "Register", func() {
+		if err := hub.Register(conn); err != nil {
+			t.Errorf("Register: %v", err)
+		}
+	})
+	hub.Broadcast(Event{Name: "hello", Data: "x"})
+	receive(t, w, "hello")
+
+	within(t, "Unregister", func() { hub.Unregister(conn) })
+	within(t, "Ping", func() { hub.Ping(t.Context()) })
+	if got := hub.Stats().RenderErrors; got != 2 {
+		t.Errorf("RenderErrors = %d, want 2", got)
+	}
+	if got := hub.Stats().Restarts; got != 0 {
+		t.Errorf("Restarts = %d, want 0", got)
+	}
+}
+
+func TestRenderPanicRestartsLoop(t *testing.T) {
+	hub := startHub(WithOnlineCountRenderer(func(int) (string, error) {
+		panic("template exploded")
+	}))
+
+	first, w := newConnection("a")
+	second, _ := newConnection("b")
+	for _, conn := range []*Connection{first, second} {
+		within(t, "Register", func() {
+			if err := hub.Register(conn); err != nil {
+				t.Errorf("Register %s: %v", conn.ID, err)
+			}
+		})
+	}
+	hub.Broadcast(Event{Name: "hello", Data: "x"})
+	receive(t, w, "hello")
+
+	within(t, "Unregister", func() { hub.Unregister(second) })
+	within(t, "Unregister", func() { hub.Unregister(first) })
+	if got := hub.GetOnlineCount(); got != 0 {
+		t.Errorf("online = %d after unregistering everyone, want 0", got)
+	}
+	within(t, "Ping", func() {
+		if err := hub.Ping(t.Context()); err != nil {
+			t.Errorf("Ping: %v", err)
+		}
+	})
+	if got := hub.Stats().Restarts; got < 4 {
+		t.Errorf("Restarts = %d, want at least 4", got)
+	}
+}
+
+func TestDuplicateIDEvictsOlderConnection(t *testing.T) {
+	hub := startHub()
+
+	old, _ := newConnection("a")
+	newer, w := newConnection("a")
+	for _, conn := range []*Connection{old, newer} {
+		within(t, "Register", func() {
+			if err := hub.Register(conn); err != nil {
+				t.Errorf("Register: %v", err)
+			}
+		})
+	}
+	within(t, "eviction", func() { <-old.Evicted() })
+	if got := hub.GetOnlineCount(); got != 1 {
+		t.Errorf("online = %d, want 1", got)
+	}
+	if got := hub.Stats().Evicted[ScopeID]; got != 1 {
+		t.Errorf("Evicted[id] = %d, want 1", got)
+	}
+
+	hub.Broadcast(Event{Name: "hello", Data: "x"})
+	receive(t, w, "hello")
+}
+
+func TestUnregisterStaleConnectionKeepsNewer(t *testing.T) {
+	hub := startHub()
+
+	old, _ := newConnection("a")
+	newer, w := newConnection("a")
+	for _, conn := range []*Connection{old, newer} {
+		within(t, "Register", func() {
+			if err := hub.Register(conn); err != nil {
+				t.Errorf("Register: %v", err)
+			}
+		})
+	}
+	within(t, "Unregister", func() { hub.Unregister(old) })
+	within(t, "Ping", func() { hub.Ping(t.Context()) })
+	if got := hub.GetOnlineCount(); got != 1 {
+		t.Errorf("online = %d after unregistering the stale connection, want 1", got)
+	}
+
+	hub.Broadcast(Event{Name: "hello", Data: "x"})
+	receive(t, w, "hello")
+}
+
+func TestTopicSessionsCountsEachSessionOnce(t *testing.T) {
+	hub := startHub()
+
+	for _, c := range []struct{ id, session, topic string }{
+		{"a", "alice", "canvas"},
+		{"b", "alice", "canvas"},
+		{"c", "bob", "canvas"},
+		{"d", "carol", "checkboxes"},
+	} {
+		conn, _ := newConnection(c.id)
+		conn.Session = c.session
+		conn.Topics = map[string]bool{c.topic: true}
+		within(t, "Register", func() {
+			if err := hub.Register(conn); err != nil {
+				t.Errorf("Register: %v", err)
+			}
+		})
+	}
+	if got := hub.TopicSessions("canvas"); got != 2 {
+		t.Errorf("TopicSessions = %d, want 2", got)
+	}
+	if !hub.SessionSubscribed("bob", "canvas") || hub.SessionSubscribed("carol", "canvas") {
+		t.Error("SessionSubscribed should only count connections on the topic")
+	}
+}
@@ -0,0 +1,186 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"hypermedia-sync/internal/templates/layout"
+)
+
+// Scope is what a connection limit counts: every connection, those from
+// one IP, or those from one session. ScopeID is not a limit; it counts
+// connections replaced by a newer one with the same ID.
+type Scope string
+
+const (
+	ScopeTotal   Scope = "total"
+	ScopeIP      Scope = "ip"
+	ScopeSession Scope = "session"
+	ScopeID      Scope = "id"
+)
+
+// LimitPolicy decides what happens to a connection that would go over a
+// limit.
+type LimitPolicy string
+
+const (
+	// PolicyReject turns the new connection away.
+	PolicyReject LimitPolicy = "reject"
+	// PolicyEvictOldest closes the oldest connections in the same scope to
+	// make room for the new one.
+	PolicyEvictOldest LimitPolicy = "evict-oldest"
+)
+
+// Limit caps connections in one scope. A zero Max is unlimited.
+type Limit struct {
+	Max    int
+	Policy LimitPolicy
+}
+
+type Limits struct {
+	Total      Limit
+	PerIP      Limit
+	PerSession Limit
+}
+
+// WithLimits caps the connections the hub accepts.
+func WithLimits(limits Limits) Option {
+	return func(h *Hub) { h.limits = limits }
+}
+
+// LimitError is returned by Register when a connection is rejected.
+type LimitError struct {
+	Scope Scope
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	switch e.Scope {
+	case ScopeIP:
+		return "Too many live connections from your network. Close some tabs and reload."
+	case ScopeSession:
+		return "Too many live connections from this browser. Close some tabs and reload."
+	}
+	return "The server is at its limit of live connections. Try again in a minute."
+}
+
+// evictionMessage is shown on a connection closed to make room for a
+// newer one.
+func evictionMessage(scope Scope) string {
+	switch scope {
+	case ScopeIP:
+		return "This tab was disconnected to make room for a newer one on your network. Reload to reconnect."
+	case ScopeSession:
+		return "This tab was disconnected because you opened the site in another tab. Reload to reconnect."
+	case ScopeID:
+		return "This tab was disconnected because it reconnected. Reload if it stops updating."
+	}
+	return "This tab was disconnected to make room for newer visitors. Reload to reconnect."
+}
+
+type eviction struct {
+	conn  *Connection
+	scope Scope
+}
+
+// admit decides whether conn fits within the limits, returning the
+// connections to evict to make room. Nothing is evicted unless every scope
+// admits the connection. The caller must hold connMu.
+func (h *Hub) admit(conn *Connection) ([]eviction, *LimitError) {
+	scopes := []struct {
+		scope   Scope
+		limit   Limit
+		matches func(*Connection) bool
+	}{
+		{ScopeSession, h.limits.PerSession, func(c *Connection) bool { return conn.Session != "" && c.Session == conn.Session }},
+		{ScopeIP, h.limits.PerIP, func(c *Connection) bool { return conn.IP != "" && c.IP == conn.IP }},
+		{ScopeTotal, h.limits.Total, func(*Connection) bool { return true }},
+	}
+
+	var evictions []eviction
+	evicted := make(map[*Connection]bool)
+	// A connection reusing a live ID, as a reconnecting page does, replaces
+	// the old one instead of leaving it running but untracked.
+	if old, exists := h.connections[conn.ID]; exists {
+		evicted[old] = true
+		evictions = append(evictions, eviction{old, ScopeID})
+	}
+	for _, s := range scopes {
+		if s.limit.Max <= 0 {
+			continue
+		}
+		var inScope []*Connection
+		for _, c := range h.connections {
+			if !evicted[c] && s.matches(c) {
+				inScope = append(inScope, c)
+			}
+		}
+		over := len(inScope) - s.limit.Max + 1
+		if over <= 0 {
+			continue
+		}
+		if s.limit.Policy != PolicyEvictOldest {
+			return nil, &LimitError{Scope: s.scope, Max: s.limit.Max}
+		}
+		slices.SortFunc(inScope, func(a, b *Connection) int { return a.opened.Compare(b.opened) })
+		for _, c := range inScope[:over] {
+			evicted[c] = true
+			evictions = append(evictions, eviction{c, s.scope})
+		}
+	}
+	return evictions, nil
+}
+
+// evict tells a connection already removed from the hub why it was closed
+// and ends its stream.
+func (h *Hub) evict(conn *Connection, scope Scope) {
+	defer close(conn.evicted)
+	if conn.Writer == nil {
+		return
+	}
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	if conn.closed() {
+		return
+	}
+	if err := writeClosed(conn.Writer, evictionMessage(scope)); err != nil {
+		h.connLogger(conn).Debug("writing eviction notice", "err", err)
+	}
+}
+
+// WriteRejection tells a client its connection was refused, with the
+// error's message as a toast. Clients that close on connection-error
+// stop there; others are asked to wait a minute before reconnecting.
+func WriteRejection(w http.ResponseWriter, err error) error {
+	return writeClosed(w, err.Error())
+}
+
+func writeClosed(w http.ResponseWriter, message string) error {
+	var buf bytes.Buffer
+	if err := layout.ConnectionClosedToast(message).Render(context.Background(), &buf); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(w, "retry: 60000\n"); err != nil {
+		return err
+	}
+	if _, err := writeEvent(w, ConnectionErrorEvent, buf.String()); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// ConnectionErrorEvent is sent before the hub closes a stream it rejected
+// or evicted. Pages close their EventSource when they receive it.
+const ConnectionErrorEvent = "connection-error"
+
+func (h *Hub) countLimit(counts map[Scope]uint64, scope Scope) {
+	h.limitCountsMu.Lock()
+	defer h.limitCountsMu.Unlock()
+	counts[scope]++
+}
@@ -0,0 +1,77 @@
+package sse
+
+import (
+	"maps"
+	"slices"
+
+	"hypermedia-sync/internal/metrics"
+)
+
+// RegisterMetrics exposes the hub's connection and delivery counters on
+// the default metrics registry. Each family reads a fresh snapshot at
+// scrape time.
+func (h *Hub) RegisterMetrics() {
+	stat := func(read func(Stats) float64) func(func(float64, ...string)) {
+		return func(emit func(float64, ...string)) {
+			emit(read(h.Stats()))
+		}
+	}
+
+	metrics.NewGaugeFunc("sse_connections_active", "Open SSE connections.", nil,
+		stat(func(s Stats) float64 { return float64(s.Connections) }))
+	metrics.NewCounterFunc("sse_connects_total", "SSE connections opened.", nil,
+		stat(func(s Stats) float64 { return float64(s.Connects) }))
+	metrics.NewCounterFunc("sse_disconnects_total", "SSE connections closed.", nil,
+		stat(func(s Stats) float64 { return float64(s.Disconnects) }))
+	metrics.NewCounterFunc("sse_deliveries_total", "Events written to a connection.", nil,
+		stat(func(s Stats) float64 { return float64(s.Deliveries) }))
+	metrics.NewCounterFunc("sse_bytes_written_total", "Bytes of event data written to connections.", nil,
+		stat(func(s Stats) float64 { return float64(s.BytesWritten) }))
+	metrics.NewCounterFunc("sse_write_errors_total", "Failed writes to a connection.", nil,
+		stat(func(s Stats) float64 { return float64(s.WriteErrors) }))
+	metrics.NewCounterFunc("sse_render_errors_total", "Online counter updates skipped because rendering failed.", nil,
+		stat(func(s Stats) float64 { return float64(s.RenderErrors) }))
+	metrics.NewCounterFunc("sse_hub_restarts_total", "Hub loop restarts after a panic.", nil,
+		stat(func(s Stats) float64 { return float64(s.Restarts) }))
+	metrics.NewGaugeFunc("sse_broadcast_queue_depth", "Broadcasts waiting for the hub loop.", nil,
+		stat(func(s Stats) float64 { return float64(s.QueueDepth) }))
+	metrics.NewGaugeFunc("sse_broadcast_queue_capacity", "Size of the broadcast queue.", nil,
+		stat(func(s Stats) float64 { return float64(s.QueueCapacity) }))
+
+	metrics.NewCounterFunc("sse_events_broadcast_total", "Events broadcast, by name with IDs replaced by *.", []string{"event"},
+		func(emit func(float64, ...string)) {
+			counts := h.Stats().EventsByKind
+			for _, kind := range slices.Sorted(maps.Keys(counts)) {
+				emit(float64(counts[kind]), kind)
+			}
+		})
+	// Pending deliveries are aggregated rather than labeled by connection:
+	// originator IDs are unbounded, and some experiments trust them.
+	metrics.NewGaugeFunc("sse_pending_deliveries", "Deliveries started but not yet written, across all connections.", nil,
+		stat(func(s Stats) float64 { return float64(s.Pending) }))
+	metrics.NewGaugeFunc("sse_connection_queue_depth_max", "Most deliveries waiting on any one connection.", nil,
+		stat(func(s Stats) float64 { return float64(s.MaxPending) }))
+
+	scopes := []Scope{ScopeTotal, ScopeIP, ScopeSession}
+	metrics.NewGaugeFunc("sse_connection_limit", "Maximum open connections per scope; 0 means unlimited.", []string{"scope"},
+		func(emit func(float64, ...string)) {
+			limits := h.Stats().Limits
+			emit(float64(limits.Total.Max), string(ScopeTotal))
+			emit(float64(limits.PerIP.Max), string(ScopeIP))
+			emit(float64(limits.PerSession.Max), string(ScopeSession))
+		})
+	metrics.NewCounterFunc("sse_connections_rejected_total", "Connections refused by a limit, by scope.", []string{"scope"},
+		func(emit func(float64, ...string)) {
+			rejected := h.Stats().Rejected
+			for _, scope := range scopes {
+				emit(float64(rejected[scope]), string(scope))
+			}
+		})
+	metrics.NewCounterFunc("sse_connections_evicted_total", "Connections closed to make room under a limit, by scope.", []string{"scope"},
+		func(emit func(float64, ...string)) {
+			evicted := h.Stats().Evicted
+			for _, scope := range scopes {
+				emit(float64(evicted[scope]), string(scope))
+			}
+		})
+}
@@ -16,12 +16,29 @@ import (
 )
 
 func getAttribute(data, attr string) string {
-	parts := strings.Split(data, " ")
-	for _, part := range parts {
-		if strings.HasPrefix(part, attr+"=") {
-			value := strings.TrimPrefix(part, attr+"=")
-			return strings.Trim(value, "\"")
+	key := attr + "="
+	for i := 0; i < len(data); {
+		idx := strings.Index(data[i:], key)
+		if idx < 0 {
+			return ""
 		}
+		start := i + idx
+		if start > 0 && data[start-1] != ' ' {
+			i = start + len(key)
+			continue
+		}
+		// Quoted values may contain spaces (e.g. text="hello world")
+		value := data[start+len(key):]
+		if strings.HasPrefix(value, "\"") {
+			if end := strings.Index(value[1:], "\""); end >= 0 {
+				return value[1 : end+1]
+			}
+			return value[1:]
+		}
+		if end := strings.Index(value, " "); end >= 0 {
+			return value[:end]
+		}
+		return value
 	}
 	return ""
 }
@@ -30,7 +47,7 @@ var canvasDrawSyncScriptHandle = templ.NewOnceHandle()
 
 type DrawingElement struct {
 	ID        string    `json:"id"`
-	Type      string    `json:"type"` // "path", "rect", "circle", "text"
+	Type      string    `json:"type"` // "path", "rect", "circle", "text", "snapshot"
 	Data      string    `json:"data"` // SVG path data or element attributes
 	Color     string    `json:"color"`
 	BrushSize string    `json:"brush_size"`
@@ -38,6 +55,24 @@ type DrawingElement struct {
 	Created   time.Time `json:"created"`
 }
 
+func clearPolicyLabel(policy string) string {
+	switch policy {
+	case "owner":
+		return "Owner only"
+	case "vote":
+		return "Vote to clear"
+	case "soft":
+		return "Clear with undo"
+	default:
+		return "Anyone clears"
+	}
+}
+
+// Attr returns a single attribute from the element's encoded Data.
+func (e DrawingElement) Attr(name string) string {
+	return getAttribute(e.Data, name)
+}
+
 type CanvasState struct {
 	Elements []DrawingElement `json:"elements"`
 	Width    int              `json:"width"`
@@ -48,6 +83,19 @@ type CanvasDrawSyncPageData struct {
 	Canvas       CanvasState
 	OriginatorID string
 	OnlineCount  int
+	OGImage      string
+	Clear        ClearControlsData
+	Banner       layout.StatusBanner
+}
+
+type ClearControlsData struct {
+	Policy       string
+	Policies     []string
+	IsOwner      bool
+	CanClaim     bool
+	Votes        int
+	VotesNeeded  int
+	RestoreUntil time.Time
 }
 
 func CanvasDrawSyncPageFull(data CanvasDrawSyncPageData) templ.Component {
@@ -89,7 +137,7 @@ func CanvasDrawSyncPageFull(data CanvasDrawSyncPageData) templ.Component {
 			}
 			return nil
 		})
-		templ_7745c5c3_Err = layout.AppWithSSE("Canvas - Collaborative Drawing - HTMX + SSE Hypermedia Sync", data.OnlineCount, data.OriginatorID).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = layout.AppWithSSEImage("Canvas - Collaborative Drawing - HTMX + SSE Hypermedia Sync", data.OGImage, data.OnlineCount, data.OriginatorID, "canvas-draw-sync").Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -122,7 +170,11 @@ func CanvasDrawSyncPageContent(data CanvasDrawSyncPageData) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = CanvasDrawSyncToolbar(data.OriginatorID).Render(ctx, templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = layout.ExperimentBanner(data.Banner).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CanvasDrawSyncToolbar(data.Clear).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -142,7 +194,7 @@ func CanvasDrawSyncPageContent(data CanvasDrawSyncPageData) templ.Component {
 	})
 }
 
-func CanvasDrawSyncToolbar(originatorID string) templ.Component {
+func CanvasDrawSyncToolbar(clear ClearControlsData) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -163,7 +215,23 @@ func CanvasDrawSyncToolbar(originatorID string) templ.Component {
 			templ_7745c5c3_Var4 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<div class=\"px-4 mb-4\"><div class=\"bg-secondary-800/30 rounded-xl border border-secondary-700 p-3 sm:p-4\"><div class=\"flex flex-wrap items-center gap-2 sm:gap-4\"><div class=\"flex items-center gap-2\"><label class=\"text-secondary-200 text-sm font-medium whitespace-nowrap\">Tool:</label> <select id=\"tool-select\" class=\"bg-secondary-700 border border-secondary-600 rounded-lg px-3 py-2 text-secondary-100 text-sm focus:ring-2 focus:ring-primary-500 focus:border-primary-500\"><option value=\"pen\">Pen</option> <option value=\"rect\">Rectangle</option> <option value=\"circle\">Circle</option> <option value=\"text\">Text</option></select></div><div class=\"flex items-center gap-2\"><label class=\"text-secondary-200 text-sm font-medium whitespace-nowrap\">Color:</label> <input type=\"color\" id=\"color-picker\" value=\"#f54a00\" class=\"w-10 h-10 rounded-lg border border-secondary-600 bg-secondary-700 cursor-pointer\"></div><div class=\"flex items-center gap-3\"><label class=\"text-secondary-200 text-sm font-medium whitespace-nowrap\">Size:</label> <input type=\"range\" id=\"brush-size\" min=\"1\" max=\"20\" value=\"3\" class=\"w-20 accent-primary-600\"> <span id=\"size-display\" class=\"text-secondary-200 text-sm font-mono min-w-[1rem] text-center\">3</span></div><button id=\"clear-canvas-btn\" class=\"px-4 py-2 bg-red-600 hover:bg-red-700 text-white rounded-lg text-sm font-medium transition-colors\" hx-post=\"/experiments/canvas-draw-sync/clear\" hx-target=\"#canvas-container\" hx-swap=\"innerHTML\">Clear Canvas</button><div id=\"status-message\" class=\"text-secondary-400 text-sm\"></div></div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<div class=\"px-4 mb-4\"><div class=\"bg-secondary-800/30 rounded-xl border border-secondary-700 p-3 sm:p-4\"><div class=\"flex flex-wrap items-center gap-2 sm:gap-4\"><div class=\"flex items-center gap-2\"><label class=\"text-secondary-200 text-sm font-medium whitespace-nowrap\">Tool:</label> <select id=\"tool-select\" class=\"bg-secondary-700 border border-secondary-600 rounded-lg px-3 py-2 text-secondary-100 text-sm focus:ring-2 focus:ring-primary-500 focus:border-primary-500\"><option value=\"pen\">Pen</option> <option value=\"rect\">Rectangle</option> <option value=\"circle\">Circle</option> <option value=\"text\">Text</option></select></div><div class=\"flex items-center gap-2\"><label class=\"text-secondary-200 text-sm font-medium whitespace-nowrap\">Color:</label> <input type=\"color\" id=\"color-picker\" value=\"#f54a00\" class=\"w-10 h-10 rounded-lg border border-secondary-600 bg-secondary-700 cursor-pointer\"></div><div class=\"flex items-center gap-3\"><label class=\"text-secondary-200 text-sm font-medium whitespace-nowrap\">Size:</label> <input type=\"range\" id=\"brush-size\" min=\"1\" max=\"20\" value=\"3\" class=\"w-20 accent-primary-600\"> <span id=\"size-display\" class=\"text-secondary-200 text-sm font-mono min-w-[1rem] text-center\">3</span></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CanvasClearControls(clear).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<a href=\"/experiments/canvas-draw-sync/export.svg\" download=\"canvas.svg\" hx-boost=\"false\" class=\"px-4 py-2 bg-secondary-700 hover:bg-secondary-600 text-secondary-100 rounded-lg text-sm font-medium transition-colors\">Export SVG</a> <a href=\"/experiments/canvas-draw-sync/export.png?scale=2\" download=\"canvas.png\" hx-boost=\"false\" class=\"px-4 py-2 bg-secondary-700 hover:bg-secondary-600 text-secondary-100 rounded-lg text-sm font-medium transition-colors\">Export PNG</a> <a href=\"/experiments/canvas-draw-sync/replay\" class=\"px-4 py-2 bg-secondary-700 hover:bg-secondary-600 text-secondary-100 rounded-lg text-sm font-medium transition-colors\">Replay</a><form id=\"import-svg-form\" hx-post=\"/experiments/canvas-draw-sync/import\" hx-encoding=\"multipart/form-data\" hx-target=\"#canvas-container\" hx-swap=\"innerHTML\" hx-trigger=\"change\"><label class=\"px-4 py-2 bg-secondary-700 hover:bg-secondary-600 text-secondary-100 rounded-lg text-sm font-medium transition-colors cursor-pointer inline-block\">Import SVG <input type=\"file\" name=\"file\" accept=\".svg,image/svg+xml\" class=\"hidden\"></label></form><div id=\"canvas-restore\" sse-swap=\"canvas-restore-updated\" hx-swap=\"innerHTML\" hx-target=\"this\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CanvasRestoreButton(clear.RestoreUntil).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div><div id=\"status-message\" class=\"text-secondary-400 text-sm\"></div></div></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -171,7 +239,7 @@ func CanvasDrawSyncToolbar(originatorID string) templ.Component {
 	})
 }
 
-func CanvasDrawSyncCanvas(canvas CanvasState) templ.Component {
+func CanvasClearControls(clear ClearControlsData) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -192,7 +260,397 @@ func CanvasDrawSyncCanvas(canvas CanvasState) templ.Component {
 			templ_7745c5c3_Var5 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"flex-1 flex flex-col px-4 pb-4\"><div class=\"flex-1 bg-secondary-800/30 rounded-xl border border-secondary-700 p-4 sm:p-6 overflow-auto\"><div id=\"canvas-container\" class=\"flex justify-center h-full items-center w-full\" sse-swap=\"canvas-cleared\" hx-swap=\"innerHTML\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<div id=\"clear-controls\" class=\"flex items-center gap-2\"><div id=\"clear-button-slot\" sse-swap=\"canvas-clear-policy-updated\" hx-swap=\"innerHTML\" hx-target=\"this\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CanvasClearButton(clear).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if clear.IsOwner {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<select name=\"policy\" title=\"Clear policy\" class=\"bg-secondary-700 border border-secondary-600 rounded-lg px-2 py-2 text-secondary-100 text-sm\" hx-post=\"/experiments/canvas-draw-sync/clear-policy\" hx-trigger=\"change\" hx-target=\"#clear-button-slot\" hx-swap=\"innerHTML\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, policy := range clear.Policies {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<option value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var6 string
+				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(policy)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 205, Col: 20}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if policy == clear.Policy {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, " selected")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, ">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var7 string
+				templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(clearPolicyLabel(policy))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 209, Col: 32}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</option>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "</select>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = clearOwnershipButton("/experiments/canvas-draw-sync/clear/release", "Give up ownership so someone else can claim the canvas", "Release").Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func clearOwnershipButton(url, title, label string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var8 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var8 == nil {
+			templ_7745c5c3_Var8 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<button title=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var9 string
+		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(title)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 219, Col: 15}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "\" class=\"px-3 py-2 bg-secondary-700 hover:bg-secondary-600 text-secondary-100 rounded-lg text-sm font-medium transition-colors\" hx-post=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var10 string
+		templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(url)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 221, Col: 15}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "\" hx-target=\"#clear-controls\" hx-swap=\"outerHTML\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var11 string
+		templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 225, Col: 9}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</button>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func CanvasClearButton(clear ClearControlsData) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var12 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var12 == nil {
+			templ_7745c5c3_Var12 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		switch clear.Policy {
+		case "owner":
+			if clear.IsOwner {
+				templ_7745c5c3_Err = clearButton("Clear Canvas").Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "<button id=\"clear-canvas-btn\" disabled title=\"Only the canvas owner can clear it\" class=\"px-4 py-2 bg-red-600/40 text-white/60 rounded-lg text-sm font-medium cursor-not-allowed\">Clear (owner only)</button> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+		case "vote":
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<button id=\"clear-canvas-btn\" class=\"px-4 py-2 bg-red-600 hover:bg-red-700 text-white rounded-lg text-sm font-medium transition-colors\" hx-post=\"/experiments/canvas-draw-sync/clear\" hx-target=\"#canvas-container\" hx-swap=\"innerHTML\">Vote to Clear <span id=\"clear-vote-tally\" class=\"ml-1 font-mono\" sse-swap=\"canvas-clear-votes\" hx-swap=\"innerHTML\" hx-target=\"this\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = ClearVoteTally(clear.Votes, clear.VotesNeeded).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "</span></button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		default:
+			templ_7745c5c3_Err = clearButton("Clear Canvas").Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if clear.CanClaim {
+			templ_7745c5c3_Err = clearOwnershipButton("/experiments/canvas-draw-sync/clear/claim", "Become the canvas owner and choose how it gets cleared", "Claim").Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		return nil
+	})
+}
+
+func clearButton(label string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var13 == nil {
+			templ_7745c5c3_Var13 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "<button id=\"clear-canvas-btn\" class=\"px-4 py-2 bg-red-600 hover:bg-red-700 text-white rounded-lg text-sm font-medium transition-colors\" hx-post=\"/experiments/canvas-draw-sync/clear\" hx-target=\"#canvas-container\" hx-swap=\"innerHTML\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var14 string
+		templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 268, Col: 9}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "</button>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func ClearVoteTally(votes int, needed int) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var15 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var15 == nil {
+			templ_7745c5c3_Var15 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		var templ_7745c5c3_Var16 string
+		templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d/%d", votes, needed))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 273, Col: 38}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func CanvasRestoreButton(until time.Time) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var17 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var17 == nil {
+			templ_7745c5c3_Var17 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		if !until.IsZero() {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "<button class=\"px-4 py-2 bg-primary-600 hover:bg-primary-500 text-white rounded-lg text-sm font-medium transition-colors\" hx-post=\"/experiments/canvas-draw-sync/restore\" hx-target=\"#canvas-container\" hx-swap=\"innerHTML\" title=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var18 string
+			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs("Available until " + until.Format("15:04:05"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 283, Col: 56}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "\">Restore Canvas</button>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		return nil
+	})
+}
+
+func StatusMessage(message string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var19 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var19 == nil {
+			templ_7745c5c3_Var19 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "<span class=\"text-red-400\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var20 string
+		templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(message)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 291, Col: 37}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "</span>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func CanvasDrawSyncCanvas(canvas CanvasState) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var21 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var21 == nil {
+			templ_7745c5c3_Var21 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "<div class=\"flex-1 flex flex-col px-4 pb-4\"><div class=\"flex-1 bg-secondary-800/30 rounded-xl border border-secondary-700 p-4 sm:p-6 overflow-auto\"><div id=\"canvas-container\" class=\"flex justify-center h-full items-center w-full\" sse-swap=\"canvas-cleared,canvas-restored\" hx-swap=\"innerHTML\" hx-target=\"this\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -200,7 +658,7 @@ func CanvasDrawSyncCanvas(canvas CanvasState) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</div></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -224,51 +682,129 @@ func CanvasSVG(canvas CanvasState) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var6 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var6 == nil {
-			templ_7745c5c3_Var6 = templ.NopComponent
+		templ_7745c5c3_Var22 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var22 == nil {
+			templ_7745c5c3_Var22 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<svg id=\"canvas-svg\" width=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "<svg id=\"canvas-svg\" width=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var7 string
-		templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", canvas.Width))
+		var templ_7745c5c3_Var23 string
+		templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", canvas.Width))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 117, Col: 41}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 307, Col: 41}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\" height=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "\" height=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var8 string
-		templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", canvas.Height))
+		var templ_7745c5c3_Var24 string
+		templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", canvas.Height))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 118, Col: 43}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 308, Col: 43}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "\" class=\"border border-secondary-600 bg-white rounded-lg cursor-crosshair w-full h-full\" sse-swap=\"canvas-element-added\" hx-swap=\"beforeend\" viewBox=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "\" class=\"border border-secondary-600 bg-white rounded-lg cursor-crosshair w-full h-full\" sse-swap=\"canvas-element-added\" hx-swap=\"beforeend\" viewBox=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var9 string
-		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("0 0 %d %d", canvas.Width, canvas.Height))
+		var templ_7745c5c3_Var25 string
+		templ_7745c5c3_Var25, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("0 0 %d %d", canvas.Width, canvas.Height))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 122, Col: 65}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 312, Col: 65}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var25))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "\" preserveAspectRatio=\"xMidYMid meet\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		for _, element := range canvas.Elements {
+			templ_7745c5c3_Err = DrawingElementSVG(element).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "</svg>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func CanvasSVGDocument(canvas CanvasState) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var26 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var26 == nil {
+			templ_7745c5c3_Var26 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<svg xmlns=\"http://www.w3.org/2000/svg\" version=\"1.1\" width=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var27 string
+		templ_7745c5c3_Var27, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", canvas.Width))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 325, Col: 41}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var27))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\" height=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var28 string
+		templ_7745c5c3_Var28, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", canvas.Height))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 326, Col: 43}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var28))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "\" viewBox=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\" preserveAspectRatio=\"xMidYMid meet\">")
+		var templ_7745c5c3_Var29 string
+		templ_7745c5c3_Var29, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("0 0 %d %d", canvas.Width, canvas.Height))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 327, Col: 65}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var29))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "\"><rect x=\"0\" y=\"0\" width=\"100%\" height=\"100%\" fill=\"#ffffff\"></rect> ")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -278,7 +814,7 @@ func CanvasSVG(canvas CanvasState) templ.Component {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</svg>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</svg>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -302,237 +838,372 @@ func DrawingElementSVG(element DrawingElement) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var10 == nil {
-			templ_7745c5c3_Var10 = templ.NopComponent
+		templ_7745c5c3_Var30 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var30 == nil {
+			templ_7745c5c3_Var30 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
 		switch element.Type {
 		case "path":
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<path d=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "<path data-id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var31 string
+			templ_7745c5c3_Var31, templ_7745c5c3_Err = templ.JoinStringErrs(element.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 339, Col: 29}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var31))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "\" d=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var11 string
-			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(element.Data)
+			var templ_7745c5c3_Var32 string
+			templ_7745c5c3_Var32, templ_7745c5c3_Err = templ.JoinStringErrs(element.Data)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 134, Col: 25}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 339, Col: 48}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var32))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "\" stroke=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "\" stroke=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var12 string
-			templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
+			var templ_7745c5c3_Var33 string
+			templ_7745c5c3_Var33, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 134, Col: 50}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 339, Col: 73}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var33))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "\" stroke-width=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "\" stroke-width=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var13 string
-			templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(element.BrushSize)
+			var templ_7745c5c3_Var34 string
+			templ_7745c5c3_Var34, templ_7745c5c3_Err = templ.JoinStringErrs(element.BrushSize)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 134, Col: 85}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 339, Col: 108}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var34))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\" fill=\"none\" stroke-linecap=\"round\" stroke-linejoin=\"round\"></path>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "\" fill=\"none\" stroke-linecap=\"round\" stroke-linejoin=\"round\"></path>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		case "rect":
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<rect x=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "<rect data-id=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var14 string
-			templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "x"))
+			var templ_7745c5c3_Var35 string
+			templ_7745c5c3_Var35, templ_7745c5c3_Err = templ.JoinStringErrs(element.ID)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 136, Col: 44}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 341, Col: 29}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var35))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "\" y=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "\" x=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var15 string
-			templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "y"))
+			var templ_7745c5c3_Var36 string
+			templ_7745c5c3_Var36, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "x"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 136, Col: 82}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 341, Col: 67}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var36))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "\" width=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "\" y=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var16 string
-			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "width"))
+			var templ_7745c5c3_Var37 string
+			templ_7745c5c3_Var37, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "y"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 136, Col: 128}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 341, Col: 105}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var37))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "\" height=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "\" width=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var17 string
-			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "height"))
+			var templ_7745c5c3_Var38 string
+			templ_7745c5c3_Var38, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "width"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 136, Col: 176}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 341, Col: 151}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var38))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "\" fill=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, "\" height=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var18 string
-			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
+			var templ_7745c5c3_Var39 string
+			templ_7745c5c3_Var39, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "height"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 136, Col: 199}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 341, Col: 199}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var39))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, "\" fill=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var40 string
+			templ_7745c5c3_Var40, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 341, Col: 222}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var40))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "\" opacity=\"0.7\"></rect>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, "\" opacity=\"0.7\"></rect>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		case "circle":
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<circle cx=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, "<circle data-id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var41 string
+			templ_7745c5c3_Var41, templ_7745c5c3_Err = templ.JoinStringErrs(element.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 343, Col: 31}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var41))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var19 string
-			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "cx"))
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, "\" cx=\"")
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 138, Col: 48}
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var42 string
+			templ_7745c5c3_Var42, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "cx"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 343, Col: 71}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var42))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "\" cy=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, "\" cy=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var20 string
-			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "cy"))
+			var templ_7745c5c3_Var43 string
+			templ_7745c5c3_Var43, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "cy"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 138, Col: 88}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 343, Col: 111}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var43))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "\" r=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "\" r=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var21 string
-			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "r"))
+			var templ_7745c5c3_Var44 string
+			templ_7745c5c3_Var44, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "r"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 138, Col: 126}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 343, Col: 149}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var44))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "\" fill=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "\" fill=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var22 string
-			templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
+			var templ_7745c5c3_Var45 string
+			templ_7745c5c3_Var45, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 138, Col: 149}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 343, Col: 172}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var45))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "\" opacity=\"0.7\"></circle>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "\" opacity=\"0.7\"></circle>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		case "text":
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "<text x=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "<text data-id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var46 string
+			templ_7745c5c3_Var46, templ_7745c5c3_Err = templ.JoinStringErrs(element.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 345, Col: 29}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var46))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "\" x=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var47 string
+			templ_7745c5c3_Var47, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "x"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 345, Col: 67}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var47))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "\" y=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var48 string
+			templ_7745c5c3_Var48, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "y"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 345, Col: 105}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var48))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "\" fill=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var49 string
+			templ_7745c5c3_Var49, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 345, Col: 128}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var49))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 63, "\" font-family=\"Inter, sans-serif\" font-size=\"16\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var50 string
+			templ_7745c5c3_Var50, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "text"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 345, Col: 214}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var50))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 64, "</text>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		case "snapshot":
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 65, "<image data-id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var51 string
+			templ_7745c5c3_Var51, templ_7745c5c3_Err = templ.JoinStringErrs(element.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 347, Col: 30}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var51))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 66, "\" x=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var52 string
+			templ_7745c5c3_Var52, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "x"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 347, Col: 68}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var52))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 67, "\" y=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var23 string
-			templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "x"))
+			var templ_7745c5c3_Var53 string
+			templ_7745c5c3_Var53, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "y"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 140, Col: 44}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 347, Col: 106}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var53))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "\" y=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 68, "\" width=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var24 string
-			templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "y"))
+			var templ_7745c5c3_Var54 string
+			templ_7745c5c3_Var54, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "width"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 140, Col: 82}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 347, Col: 152}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var54))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "\" fill=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 69, "\" height=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var25 string
-			templ_7745c5c3_Var25, templ_7745c5c3_Err = templ.JoinStringErrs(element.Color)
+			var templ_7745c5c3_Var55 string
+			templ_7745c5c3_Var55, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "height"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 140, Col: 105}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 347, Col: 200}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var25))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var55))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "\" font-family=\"Inter, sans-serif\" font-size=\"16\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 70, "\" href=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var26 string
-			templ_7745c5c3_Var26, templ_7745c5c3_Err = templ.JoinStringErrs(getAttribute(element.Data, "text"))
+			var templ_7745c5c3_Var56 string
+			templ_7745c5c3_Var56, templ_7745c5c3_Err = templ.JoinStringErrs(templ.SafeURL(getAttribute(element.Data, "href")))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 140, Col: 191}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 347, Col: 259}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var26))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var56))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</text>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 71, "\"></image>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -557,9 +1228,9 @@ func DrawingElementSSE(element DrawingElement) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var27 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var27 == nil {
-			templ_7745c5c3_Var27 = templ.NopComponent
+		templ_7745c5c3_Var57 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var57 == nil {
+			templ_7745c5c3_Var57 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
 		templ_7745c5c3_Err = DrawingElementSVG(element).Render(ctx, templ_7745c5c3_Buffer)
@@ -570,6 +1241,98 @@ func DrawingElementSSE(element DrawingElement) templ.Component {
 	})
 }
 
+// CanvasCompactedSSE carries a new snapshot and the space-separated IDs of
+// the elements it replaces, which clients remove before inserting the
+// snapshot beneath everything else.
+func CanvasCompactedSSE(snapshot DrawingElement, removed []string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var58 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var58 == nil {
+			templ_7745c5c3_Var58 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 72, "<svg xmlns=\"http://www.w3.org/2000/svg\" data-removed=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var59 string
+		templ_7745c5c3_Var59, templ_7745c5c3_Err = templ.JoinStringErrs(strings.Join(removed, " "))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/canvas_draw_sync_content.templ`, Line: 359, Col: 82}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var59))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 73, "\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = DrawingElementSVG(snapshot).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 74, "</svg>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func DrawingElementsSSE(elements []DrawingElement) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var60 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var60 == nil {
+			templ_7745c5c3_Var60 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 75, "<svg xmlns=\"http://www.w3.org/2000/svg\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		for _, element := range elements {
+			templ_7745c5c3_Err = DrawingElementSVG(element).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 76, "</svg>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
 func CanvasDrawSyncScript(originatorID string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -586,16 +1349,16 @@ func CanvasDrawSyncScript(originatorID string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var28 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var28 == nil {
-			templ_7745c5c3_Var28 = templ.NopComponent
+		templ_7745c5c3_Var61 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var61 == nil {
+			templ_7745c5c3_Var61 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
 		templ_7745c5c3_Err = templ.JSONScript("canvasDrawSyncOriginatorId", originatorID).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Var29 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_Var62 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
 			if !templ_7745c5c3_IsBuffer {
@@ -607,13 +1370,13 @@ func CanvasDrawSyncScript(originatorID string) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "<script type=\"text/javascript\">\n\t\t\t(function () {\n\t\t\t\tvar originatorId = JSON.parse(document.getElementById('canvasDrawSyncOriginatorId').textContent);\n\t\t\t\tvar isDrawing = false;\n\t\t\t\tvar currentPath = '';\n\t\t\t\tvar currentTool = 'pen';\n\t\t\t\tvar currentColor = '#f54a00';\n\t\t\t\tvar brushSize = 3;\n\t\t\t\t\n\t\t\t\t// Get canvas and toolbar elements\n\t\t\t\tvar canvas = document.getElementById('canvas-svg');\n\t\t\t\t\n\t\t\t\t// Add originator ID to all HTMX requests\n\t\t\t\tdocument.addEventListener('htmx:configRequest', function(evt) {\n\t\t\t\t\tevt.detail.headers['X-Originator-ID'] = originatorId;\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\t// HTMX SSE debugging - let's trace all SSE events\n\t\t\t\tconsole.log('Setting up HTMX SSE event listeners...');\n\t\t\t\t\n\t\t\t\t\n\t\t\t\t// Listen for specific canvas events\n\t\t\t\tdocument.addEventListener('htmx:sseMessage', function(evt) {\n\t\t\t\t\tif (evt.detail.type === 'canvas-element-added') {\n\t\t\t\t\t\tconsole.log('[CANVAS] Processing canvas-element-added event');\n\t\t\t\t\t\tconsole.log('[CANVAS] Event data:', evt.detail.data);\n\t\t\t\t\t\t\n\t\t\t\t\t\ttry {\n\t\t\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\t\t\tif (!currentCanvas) {\n\t\t\t\t\t\t\t\tconsole.error('[CANVAS] Canvas not found');\n\t\t\t\t\t\t\t\treturn;\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tif (evt.detail.data.includes('<svg')) {\n\t\t\t\t\t\t\t\tvar parser = new DOMParser();\n\t\t\t\t\t\t\t\tvar svgDoc = parser.parseFromString(evt.detail.data, 'image/svg+xml');\n\t\t\t\t\t\t\t\tvar receivedSvg = svgDoc.documentElement;\n\t\t\t\t\t\t\t\t\n\t\t\t\t\t\t\t\t// Extract all child elements (path, rect, circle, text) from the received SVG\n\t\t\t\t\t\t\t\tvar elements = receivedSvg.children;\n\t\t\t\t\t\t\t\tfor (var i = 0; i < elements.length; i++) {\n\t\t\t\t\t\t\t\t\tvar importedElement = document.importNode(elements[i], true);\n\t\t\t\t\t\t\t\t\tcurrentCanvas.appendChild(importedElement);\n\t\t\t\t\t\t\t\t\tconsole.log('[CANVAS] Imported element from complete SVG:', importedElement.tagName);\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t// Single element received - parse normally\n\t\t\t\t\t\t\t\tvar parser = new DOMParser();\n\t\t\t\t\t\t\t\tvar svgDoc = parser.parseFromString('<svg xmlns=\"http://www.w3.org/2000/svg\">' + evt.detail.data + '</svg>', 'image/svg+xml');\n\t\t\t\t\t\t\t\tvar svgElement = svgDoc.documentElement.firstElementChild;\n\t\t\t\t\t\t\t\t\n\t\t\t\t\t\t\t\tif (svgElement) {\n\t\t\t\t\t\t\t\t\tvar importedElement = document.importNode(svgElement, true);\n\t\t\t\t\t\t\t\t\tcurrentCanvas.appendChild(importedElement);\n\t\t\t\t\t\t\t\t\tconsole.log('[CANVAS] SVG element successfully added to canvas');\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\t\tconsole.error('[CANVAS] Error processing canvas SSE event:', error);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\t\n\t\t\t\tvar toolSelect = document.getElementById('tool-select');\n\t\t\t\tvar colorPicker = document.getElementById('color-picker');\n\t\t\t\tvar brushSizeSlider = document.getElementById('brush-size');\n\t\t\t\tvar sizeDisplay = document.getElementById('size-display');\n\t\t\t\t\n\t\t\t\ttoolSelect.addEventListener('change', function() {\n\t\t\t\t\tcurrentTool = this.value;\n\t\t\t\t\tupdateCursor();\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tcolorPicker.addEventListener('change', function() {\n\t\t\t\t\tcurrentColor = this.value;\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tbrushSizeSlider.addEventListener('input', function() {\n\t\t\t\t\tbrushSize = this.value;\n\t\t\t\t\tsizeDisplay.textContent = this.value;\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tfunction updateCursor() {\n\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\tif (!currentCanvas) return;\n\t\t\t\t\tswitch(currentTool) {\n\t\t\t\t\t\tcase 'pen':\n\t\t\t\t\t\t\tcurrentCanvas.style.cursor = 'crosshair';\n\t\t\t\t\t\t\tbreak;\n\t\t\t\t\t\tcase 'rect':\n\t\t\t\t\t\tcase 'circle':\n\t\t\t\t\t\t\tcurrentCanvas.style.cursor = 'copy';\n\t\t\t\t\t\t\tbreak;\n\t\t\t\t\t\tcase 'text':\n\t\t\t\t\t\t\tcurrentCanvas.style.cursor = 'text';\n\t\t\t\t\t\t\tbreak;\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\t// Function to attach drawing handlers\n\t\t\t\tfunction attachDrawingHandlers(wasCleared = false) {\n\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\tif (currentCanvas) {\n\t\t\t\t\t\t// Remove existing listeners if any\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mousedown', startDrawing);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mousemove', draw);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mouseup', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mouseleave', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('click', handleShapeClick);\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Add listeners\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mousedown', startDrawing);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mousemove', draw);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mouseup', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mouseleave', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('click', handleShapeClick);\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Update canvas reference\n\t\t\t\t\t\tcanvas = currentCanvas;\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Only remove HTMX SSE attributes if canvas was cleared (SSE context broken)\n\t\t\t\t\t\tif (wasCleared && currentCanvas.hasAttribute('sse-swap')) {\n\t\t\t\t\t\t\tconsole.log('Canvas was cleared - removing broken sse-swap attribute, using custom handler instead');\n\t\t\t\t\t\t\tcurrentCanvas.removeAttribute('sse-swap');\n\t\t\t\t\t\t\tcurrentCanvas.removeAttribute('hx-swap');\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\t// Initial attachment\n\t\t\t\tattachDrawingHandlers();\n\t\t\t\t\n\t\t\t\t// Re-attach handlers when canvas is cleared/replaced\n\t\t\t\tdocument.addEventListener('htmx:afterSwap', function(evt) {\n\t\t\t\t\tif (evt.detail && evt.detail.target && evt.detail.target.id === 'canvas-container') {\n\t\t\t\t\t\tconsole.log('Canvas was replaced, re-attaching drawing handlers');\n\t\t\t\t\t\tattachDrawingHandlers(true); // Pass true to indicate canvas was cleared\n\t\t\t\t\t\tupdateCursor();\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tfunction getMousePos(e) {\n\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\tif (!currentCanvas) return {x: 0, y: 0};\n\t\t\t\t\tvar rect = currentCanvas.getBoundingClientRect();\n\t\t\t\t\treturn {\n\t\t\t\t\t\tx: e.clientX - rect.left,\n\t\t\t\t\t\ty: e.clientY - rect.top\n\t\t\t\t\t};\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction startDrawing(e) {\n\t\t\t\t\tif (currentTool === 'pen') {\n\t\t\t\t\t\tisDrawing = true;\n\t\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\t\tcurrentPath = 'M' + pos.x + ',' + pos.y;\n\t\t\t\t\t} else if (currentTool === 'text') {\n\t\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\t\tvar text = prompt('Enter text:');\n\t\t\t\t\t\tif (text) {\n\t\t\t\t\t\t\t// Create text element immediately\n\t\t\t\t\t\t\tvar textElement = document.createElementNS('http://www.w3.org/2000/svg', 'text');\n\t\t\t\t\t\t\ttextElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\t\ttextElement.setAttribute('x', pos.x);\n\t\t\t\t\t\t\ttextElement.setAttribute('y', pos.y);\n\t\t\t\t\t\t\ttextElement.setAttribute('fill', currentColor);\n\t\t\t\t\t\t\ttextElement.setAttribute('font-family', 'Inter, sans-serif');\n\t\t\t\t\t\t\ttextElement.setAttribute('font-size', '16');\n\t\t\t\t\t\t\ttextElement.textContent = text;\n\t\t\t\t\t\t\tcanvas.appendChild(textElement);\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tsendDrawingData('text', `x=\"${pos.x}\" y=\"${pos.y}\" text=\"${text}\"`);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction draw(e) {\n\t\t\t\t\tif (!isDrawing || currentTool !== 'pen') return;\n\t\t\t\t\t\n\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\tcurrentPath += ' L' + pos.x + ',' + pos.y;\n\t\t\t\t\t\n\t\t\t\t\t// Update preview path immediately for visual feedback\n\t\t\t\t\tvar previewPath = document.getElementById('preview-path');\n\t\t\t\t\tif (!previewPath) {\n\t\t\t\t\t\tpreviewPath = document.createElementNS('http://www.w3.org/2000/svg', 'path');\n\t\t\t\t\t\tpreviewPath.id = 'preview-path';\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke', currentColor);\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke-width', brushSize);\n\t\t\t\t\t\tpreviewPath.setAttribute('fill', 'none');\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke-linecap', 'round');\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke-linejoin', 'round');\n\t\t\t\t\t\tcanvas.appendChild(previewPath);\n\t\t\t\t\t}\n\t\t\t\t\tpreviewPath.setAttribute('d', currentPath);\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction stopDrawing(e) {\n\t\t\t\t\tif (!isDrawing) return;\n\t\t\t\t\tisDrawing = false;\n\t\t\t\t\t\n\t\t\t\t\tif (currentTool === 'pen' && currentPath) {\n\t\t\t\t\t\t// Remove preview path\n\t\t\t\t\t\tvar previewPath = document.getElementById('preview-path');\n\t\t\t\t\t\tif (previewPath) {\n\t\t\t\t\t\t\tpreviewPath.remove();\n\t\t\t\t\t\t}\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Create permanent path element immediately\n\t\t\t\t\t\tvar pathElement = document.createElementNS('http://www.w3.org/2000/svg', 'path');\n\t\t\t\t\t\tpathElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\tpathElement.setAttribute('d', currentPath);\n\t\t\t\t\t\tpathElement.setAttribute('stroke', currentColor);\n\t\t\t\t\t\tpathElement.setAttribute('stroke-width', brushSize);\n\t\t\t\t\t\tpathElement.setAttribute('fill', 'none');\n\t\t\t\t\t\tpathElement.setAttribute('stroke-linecap', 'round');\n\t\t\t\t\t\tpathElement.setAttribute('stroke-linejoin', 'round');\n\t\t\t\t\t\tcanvas.appendChild(pathElement);\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Send to server\n\t\t\t\t\t\tsendDrawingData('path', currentPath);\n\t\t\t\t\t\tcurrentPath = '';\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\t// Handle shape drawing (simplified - could be enhanced with drag-to-size)\n\t\t\t\tfunction handleShapeClick(e) {\n\t\t\t\t\tif (currentTool === 'rect' || currentTool === 'circle') {\n\t\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\t\tvar size = brushSize * 10; // Scale size for shapes\n\t\t\t\t\t\t\n\t\t\t\t\t\tif (currentTool === 'rect') {\n\t\t\t\t\t\t\t// Create rect element immediately\n\t\t\t\t\t\t\tvar rectElement = document.createElementNS('http://www.w3.org/2000/svg', 'rect');\n\t\t\t\t\t\t\trectElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\t\trectElement.setAttribute('x', pos.x-size/2);\n\t\t\t\t\t\t\trectElement.setAttribute('y', pos.y-size/2);\n\t\t\t\t\t\t\trectElement.setAttribute('width', size);\n\t\t\t\t\t\t\trectElement.setAttribute('height', size);\n\t\t\t\t\t\t\trectElement.setAttribute('fill', currentColor);\n\t\t\t\t\t\t\trectElement.setAttribute('opacity', '0.7');\n\t\t\t\t\t\t\tcanvas.appendChild(rectElement);\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tsendDrawingData('rect', `x=\"${pos.x-size/2}\" y=\"${pos.y-size/2}\" width=\"${size}\" height=\"${size}\"`);\n\t\t\t\t\t\t} else if (currentTool === 'circle') {\n\t\t\t\t\t\t\t// Create circle element immediately\n\t\t\t\t\t\t\tvar circleElement = document.createElementNS('http://www.w3.org/2000/svg', 'circle');\n\t\t\t\t\t\t\tcircleElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\t\tcircleElement.setAttribute('cx', pos.x);\n\t\t\t\t\t\t\tcircleElement.setAttribute('cy', pos.y);\n\t\t\t\t\t\t\tcircleElement.setAttribute('r', size/2);\n\t\t\t\t\t\t\tcircleElement.setAttribute('fill', currentColor);\n\t\t\t\t\t\t\tcircleElement.setAttribute('opacity', '0.7');\n\t\t\t\t\t\t\tcanvas.appendChild(circleElement);\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tsendDrawingData('circle', `cx=\"${pos.x}\" cy=\"${pos.y}\" r=\"${size/2}\"`);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction sendDrawingData(type, data) {\n\t\t\t\t\t// Send to server in background (no visual feedback needed since we already drew it)\n\t\t\t\t\tfetch('/experiments/canvas-draw-sync/draw', {\n\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t'Content-Type': 'application/x-www-form-urlencoded',\n\t\t\t\t\t\t\t'X-Originator-ID': originatorId\n\t\t\t\t\t\t},\n\t\t\t\t\t\tbody: `type=${type}&data=${encodeURIComponent(data)}&color=${encodeURIComponent(currentColor)}&brushSize=${brushSize}`\n\t\t\t\t\t});\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tupdateCursor();\n\t\t\t\t\n\t\t\t\tconsole.log('Canvas initialized with originator:', originatorId);\n\t\t\t})();\n\t\t</script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 77, "<script type=\"text/javascript\">\n\t\t\t(function () {\n\t\t\t\tvar originatorId = JSON.parse(document.getElementById('canvasDrawSyncOriginatorId').textContent);\n\t\t\t\tvar isDrawing = false;\n\t\t\t\tvar currentPath = '';\n\t\t\t\tvar currentTool = 'pen';\n\t\t\t\tvar currentColor = '#f54a00';\n\t\t\t\tvar brushSize = 3;\n\t\t\t\t\n\t\t\t\t// Get canvas and toolbar elements\n\t\t\t\tvar canvas = document.getElementById('canvas-svg');\n\t\t\t\t\n\t\t\t\t// Add originator ID to all HTMX requests\n\t\t\t\tdocument.addEventListener('htmx:configRequest', function(evt) {\n\t\t\t\t\tevt.detail.headers['X-Originator-ID'] = originatorId;\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\t// HTMX SSE debugging - let's trace all SSE events\n\t\t\t\tconsole.log('Setting up HTMX SSE event listeners...');\n\t\t\t\t\n\t\t\t\t\n\t\t\t\t// Listen for specific canvas events\n\t\t\t\tdocument.addEventListener('htmx:sseMessage', function(evt) {\n\t\t\t\t\tif (evt.detail.type === 'canvas-element-added') {\n\t\t\t\t\t\tconsole.log('[CANVAS] Processing canvas-element-added event');\n\t\t\t\t\t\tconsole.log('[CANVAS] Event data:', evt.detail.data);\n\t\t\t\t\t\t\n\t\t\t\t\t\ttry {\n\t\t\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\t\t\tif (!currentCanvas) {\n\t\t\t\t\t\t\t\tconsole.error('[CANVAS] Canvas not found');\n\t\t\t\t\t\t\t\treturn;\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tif (evt.detail.data.includes('<svg')) {\n\t\t\t\t\t\t\t\tvar parser = new DOMParser();\n\t\t\t\t\t\t\t\tvar svgDoc = parser.parseFromString(evt.detail.data, 'image/svg+xml');\n\t\t\t\t\t\t\t\tvar receivedSvg = svgDoc.documentElement;\n\t\t\t\t\t\t\t\t\n\t\t\t\t\t\t\t\t// Extract all child elements (path, rect, circle, text) from the received SVG\n\t\t\t\t\t\t\t\tvar elements = receivedSvg.children;\n\t\t\t\t\t\t\t\tfor (var i = 0; i < elements.length; i++) {\n\t\t\t\t\t\t\t\t\tvar importedElement = document.importNode(elements[i], true);\n\t\t\t\t\t\t\t\t\tcurrentCanvas.appendChild(importedElement);\n\t\t\t\t\t\t\t\t\tconsole.log('[CANVAS] Imported element from complete SVG:', importedElement.tagName);\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t// Single element received - parse normally\n\t\t\t\t\t\t\t\tvar parser = new DOMParser();\n\t\t\t\t\t\t\t\tvar svgDoc = parser.parseFromString('<svg xmlns=\"http://www.w3.org/2000/svg\">' + evt.detail.data + '</svg>', 'image/svg+xml');\n\t\t\t\t\t\t\t\tvar svgElement = svgDoc.documentElement.firstElementChild;\n\t\t\t\t\t\t\t\t\n\t\t\t\t\t\t\t\tif (svgElement) {\n\t\t\t\t\t\t\t\t\tvar importedElement = document.importNode(svgElement, true);\n\t\t\t\t\t\t\t\t\tcurrentCanvas.appendChild(importedElement);\n\t\t\t\t\t\t\t\t\tconsole.log('[CANVAS] SVG element successfully added to canvas');\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\t\tconsole.error('[CANVAS] Error processing canvas SSE event:', error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} else if (evt.detail.type === 'canvas-compacted') {\n\t\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\t\tif (!currentCanvas) return;\n\t\t\t\t\t\tvar compacted = new DOMParser().parseFromString(evt.detail.data, 'image/svg+xml').documentElement;\n\t\t\t\t\t\t(compacted.getAttribute('data-removed') || '').split(' ').forEach(function(id) {\n\t\t\t\t\t\t\tif (!id) return;\n\t\t\t\t\t\t\tvar element = currentCanvas.querySelector('[data-id=\"' + CSS.escape(id) + '\"]');\n\t\t\t\t\t\t\tif (element) element.remove();\n\t\t\t\t\t\t});\n\t\t\t\t\t\tvar snapshot = compacted.firstElementChild;\n\t\t\t\t\t\tif (snapshot) {\n\t\t\t\t\t\t\tcurrentCanvas.insertBefore(document.importNode(snapshot, true), currentCanvas.firstChild);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\t\n\t\t\t\tvar toolSelect = document.getElementById('tool-select');\n\t\t\t\tvar colorPicker = document.getElementById('color-picker');\n\t\t\t\tvar brushSizeSlider = document.getElementById('brush-size');\n\t\t\t\tvar sizeDisplay = document.getElementById('size-display');\n\t\t\t\t\n\t\t\t\ttoolSelect.addEventListener('change', function() {\n\t\t\t\t\tcurrentTool = this.value;\n\t\t\t\t\tupdateCursor();\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tcolorPicker.addEventListener('change', function() {\n\t\t\t\t\tcurrentColor = this.value;\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tbrushSizeSlider.addEventListener('input', function() {\n\t\t\t\t\tbrushSize = this.value;\n\t\t\t\t\tsizeDisplay.textContent = this.value;\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tfunction updateCursor() {\n\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\tif (!currentCanvas) return;\n\t\t\t\t\tswitch(currentTool) {\n\t\t\t\t\t\tcase 'pen':\n\t\t\t\t\t\t\tcurrentCanvas.style.cursor = 'crosshair';\n\t\t\t\t\t\t\tbreak;\n\t\t\t\t\t\tcase 'rect':\n\t\t\t\t\t\tcase 'circle':\n\t\t\t\t\t\t\tcurrentCanvas.style.cursor = 'copy';\n\t\t\t\t\t\t\tbreak;\n\t\t\t\t\t\tcase 'text':\n\t\t\t\t\t\t\tcurrentCanvas.style.cursor = 'text';\n\t\t\t\t\t\t\tbreak;\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\t// Function to attach drawing handlers\n\t\t\t\tfunction attachDrawingHandlers(wasCleared = false) {\n\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\tif (currentCanvas) {\n\t\t\t\t\t\t// Remove existing listeners if any\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mousedown', startDrawing);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mousemove', draw);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mouseup', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('mouseleave', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.removeEventListener('click', handleShapeClick);\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Add listeners\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mousedown', startDrawing);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mousemove', draw);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mouseup', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('mouseleave', stopDrawing);\n\t\t\t\t\t\tcurrentCanvas.addEventListener('click', handleShapeClick);\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Update canvas reference\n\t\t\t\t\t\tcanvas = currentCanvas;\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Only remove HTMX SSE attributes if canvas was cleared (SSE context broken)\n\t\t\t\t\t\tif (wasCleared && currentCanvas.hasAttribute('sse-swap')) {\n\t\t\t\t\t\t\tconsole.log('Canvas was cleared - removing broken sse-swap attribute, using custom handler instead');\n\t\t\t\t\t\t\tcurrentCanvas.removeAttribute('sse-swap');\n\t\t\t\t\t\t\tcurrentCanvas.removeAttribute('hx-swap');\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\t// Initial attachment\n\t\t\t\tattachDrawingHandlers();\n\t\t\t\t\n\t\t\t\t// Re-attach handlers when canvas is cleared/replaced\n\t\t\t\tdocument.addEventListener('htmx:afterSwap', function(evt) {\n\t\t\t\t\tif (evt.detail && evt.detail.target && evt.detail.target.id === 'canvas-container') {\n\t\t\t\t\t\tconsole.log('Canvas was replaced, re-attaching drawing handlers');\n\t\t\t\t\t\tattachDrawingHandlers(true); // Pass true to indicate canvas was cleared\n\t\t\t\t\t\tupdateCursor();\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t\t\n\t\t\t\tfunction getMousePos(e) {\n\t\t\t\t\tvar currentCanvas = document.getElementById('canvas-svg');\n\t\t\t\t\tif (!currentCanvas) return {x: 0, y: 0};\n\t\t\t\t\tvar rect = currentCanvas.getBoundingClientRect();\n\t\t\t\t\treturn {\n\t\t\t\t\t\tx: e.clientX - rect.left,\n\t\t\t\t\t\ty: e.clientY - rect.top\n\t\t\t\t\t};\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction startDrawing(e) {\n\t\t\t\t\tif (currentTool === 'pen') {\n\t\t\t\t\t\tisDrawing = true;\n\t\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\t\tcurrentPath = 'M' + pos.x + ',' + pos.y;\n\t\t\t\t\t} else if (currentTool === 'text') {\n\t\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\t\tvar text = prompt('Enter text:');\n\t\t\t\t\t\tif (text) {\n\t\t\t\t\t\t\t// Create text element immediately\n\t\t\t\t\t\t\tvar textElement = document.createElementNS('http://www.w3.org/2000/svg', 'text');\n\t\t\t\t\t\t\ttextElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\t\ttextElement.setAttribute('x', pos.x);\n\t\t\t\t\t\t\ttextElement.setAttribute('y', pos.y);\n\t\t\t\t\t\t\ttextElement.setAttribute('fill', currentColor);\n\t\t\t\t\t\t\ttextElement.setAttribute('font-family', 'Inter, sans-serif');\n\t\t\t\t\t\t\ttextElement.setAttribute('font-size', '16');\n\t\t\t\t\t\t\ttextElement.textContent = text;\n\t\t\t\t\t\t\tcanvas.appendChild(textElement);\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tsendDrawingData('text', `x=\"${pos.x}\" y=\"${pos.y}\" text=\"${text}\"`, textElement);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction draw(e) {\n\t\t\t\t\tif (!isDrawing || currentTool !== 'pen') return;\n\t\t\t\t\t\n\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\tcurrentPath += ' L' + pos.x + ',' + pos.y;\n\t\t\t\t\t\n\t\t\t\t\t// Update preview path immediately for visual feedback\n\t\t\t\t\tvar previewPath = document.getElementById('preview-path');\n\t\t\t\t\tif (!previewPath) {\n\t\t\t\t\t\tpreviewPath = document.createElementNS('http://www.w3.org/2000/svg', 'path');\n\t\t\t\t\t\tpreviewPath.id = 'preview-path';\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke', currentColor);\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke-width', brushSize);\n\t\t\t\t\t\tpreviewPath.setAttribute('fill', 'none');\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke-linecap', 'round');\n\t\t\t\t\t\tpreviewPath.setAttribute('stroke-linejoin', 'round');\n\t\t\t\t\t\tcanvas.appendChild(previewPath);\n\t\t\t\t\t}\n\t\t\t\t\tpreviewPath.setAttribute('d', currentPath);\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction stopDrawing(e) {\n\t\t\t\t\tif (!isDrawing) return;\n\t\t\t\t\tisDrawing = false;\n\t\t\t\t\t\n\t\t\t\t\tif (currentTool === 'pen' && currentPath) {\n\t\t\t\t\t\t// Remove preview path\n\t\t\t\t\t\tvar previewPath = document.getElementById('preview-path');\n\t\t\t\t\t\tif (previewPath) {\n\t\t\t\t\t\t\tpreviewPath.remove();\n\t\t\t\t\t\t}\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Create permanent path element immediately\n\t\t\t\t\t\tvar pathElement = document.createElementNS('http://www.w3.org/2000/svg', 'path');\n\t\t\t\t\t\tpathElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\tpathElement.setAttribute('d', currentPath);\n\t\t\t\t\t\tpathElement.setAttribute('stroke', currentColor);\n\t\t\t\t\t\tpathElement.setAttribute('stroke-width', brushSize);\n\t\t\t\t\t\tpathElement.setAttribute('fill', 'none');\n\t\t\t\t\t\tpathElement.setAttribute('stroke-linecap', 'round');\n\t\t\t\t\t\tpathElement.setAttribute('stroke-linejoin', 'round');\n\t\t\t\t\t\tcanvas.appendChild(pathElement);\n\t\t\t\t\t\t\n\t\t\t\t\t\t// Send to server\n\t\t\t\t\t\tsendDrawingData('path', currentPath, pathElement);\n\t\t\t\t\t\tcurrentPath = '';\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\t// Handle shape drawing (simplified - could be enhanced with drag-to-size)\n\t\t\t\tfunction handleShapeClick(e) {\n\t\t\t\t\tif (currentTool === 'rect' || currentTool === 'circle') {\n\t\t\t\t\t\tvar pos = getMousePos(e);\n\t\t\t\t\t\tvar size = brushSize * 10; // Scale size for shapes\n\t\t\t\t\t\t\n\t\t\t\t\t\tif (currentTool === 'rect') {\n\t\t\t\t\t\t\t// Create rect element immediately\n\t\t\t\t\t\t\tvar rectElement = document.createElementNS('http://www.w3.org/2000/svg', 'rect');\n\t\t\t\t\t\t\trectElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\t\trectElement.setAttribute('x', pos.x-size/2);\n\t\t\t\t\t\t\trectElement.setAttribute('y', pos.y-size/2);\n\t\t\t\t\t\t\trectElement.setAttribute('width', size);\n\t\t\t\t\t\t\trectElement.setAttribute('height', size);\n\t\t\t\t\t\t\trectElement.setAttribute('fill', currentColor);\n\t\t\t\t\t\t\trectElement.setAttribute('opacity', '0.7');\n\t\t\t\t\t\t\tcanvas.appendChild(rectElement);\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tsendDrawingData('rect', `x=\"${pos.x-size/2}\" y=\"${pos.y-size/2}\" width=\"${size}\" height=\"${size}\"`, rectElement);\n\t\t\t\t\t\t} else if (currentTool === 'circle') {\n\t\t\t\t\t\t\t// Create circle element immediately\n\t\t\t\t\t\t\tvar circleElement = document.createElementNS('http://www.w3.org/2000/svg', 'circle');\n\t\t\t\t\t\t\tcircleElement.id = 'temp-' + Date.now();\n\t\t\t\t\t\t\tcircleElement.setAttribute('cx', pos.x);\n\t\t\t\t\t\t\tcircleElement.setAttribute('cy', pos.y);\n\t\t\t\t\t\t\tcircleElement.setAttribute('r', size/2);\n\t\t\t\t\t\t\tcircleElement.setAttribute('fill', currentColor);\n\t\t\t\t\t\t\tcircleElement.setAttribute('opacity', '0.7');\n\t\t\t\t\t\t\tcanvas.appendChild(circleElement);\n\t\t\t\t\t\t\t\n\t\t\t\t\t\t\tsendDrawingData('circle', `cx=\"${pos.x}\" cy=\"${pos.y}\" r=\"${size/2}\"`, circleElement);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tfunction sendDrawingData(type, data, element) {\n\t\t\t\t\t// Send to server in background (no visual feedback needed since we already drew it)\n\t\t\t\t\tfetch('/experiments/canvas-draw-sync/draw', {\n\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t'Content-Type': 'application/x-www-form-urlencoded',\n\t\t\t\t\t\t\t'X-Originator-ID': originatorId,\n\t\t\t\t\t\t\t'HX-Request': 'true'\n\t\t\t\t\t\t},\n\t\t\t\t\t\tbody: `type=${type}&data=${encodeURIComponent(data)}&color=${encodeURIComponent(currentColor)}&brushSize=${brushSize}`\n\t\t\t\t\t}).then(function(response) {\n\t\t\t\t\t\t// Rejected and rate limited drawings come back as a notice\n\t\t\t\t\t\t// for another element\n\t\t\t\t\t\tconst target = response.headers.get('HX-Retarget');\n\t\t\t\t\t\tif (target) {\n\t\t\t\t\t\t\tresponse.text().then(function(html) {\n\t\t\t\t\t\t\t\tconst el = document.querySelector(target);\n\t\t\t\t\t\t\t\tif (el) {\n\t\t\t\t\t\t\t\t\thtmx.swap(el, html, {swapStyle: response.headers.get('HX-Reswap') || 'innerHTML'});\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t});\n\t\t\t\t\t\t} else if (response.ok) {\n\t\t\t\t\t\t\t// Tag the local copy with the server's ID so compaction can\n\t\t\t\t\t\t\t// remove it\n\t\t\t\t\t\t\tresponse.text().then(function(html) {\n\t\t\t\t\t\t\t\tconst saved = new DOMParser().parseFromString('<svg xmlns=\"http://www.w3.org/2000/svg\">' + html + '</svg>', 'image/svg+xml').documentElement.firstElementChild;\n\t\t\t\t\t\t\t\tif (saved && saved.getAttribute('data-id')) {\n\t\t\t\t\t\t\t\t\telement.setAttribute('data-id', saved.getAttribute('data-id'));\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t});\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\t\t\t\t}\n\t\t\t\t\n\t\t\t\tupdateCursor();\n\t\t\t\t\n\t\t\t\tconsole.log('Canvas initialized with originator:', originatorId);\n\t\t\t})();\n\t\t</script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			return nil
 		})
-		templ_7745c5c3_Err = canvasDrawSyncScriptHandle.Once().Render(templ.WithChildren(ctx, templ_7745c5c3_Var29), templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = canvasDrawSyncScriptHandle.Once().Render(templ.WithChildren(ctx, templ_7745c5c3_Var62), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
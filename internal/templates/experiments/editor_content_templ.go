@@ -0,0 +1,332 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.924
+package experiments
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import (
+	"fmt"
+	"hypermedia-sync/internal/templates/layout"
+)
+
+type EditorSnapshot struct {
+	Text     string
+	Revision int
+}
+
+// EditorOperation is a batch of ops that produced Revision. Ops is JSON.
+type EditorOperation struct {
+	Revision int
+	Ops      string
+}
+
+type EditorPageData struct {
+	Snapshot     EditorSnapshot
+	OriginatorID string
+	OnlineCount  int
+	Banner       layout.StatusBanner
+}
+
+func EditorPageFull(data EditorPageData) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = EditorPageContent(data).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = layout.AppWithSSEImage("Shared Text Editor - HTMX + SSE Hypermedia Sync", layout.DefaultOGImage, data.OnlineCount, data.OriginatorID, "editor").Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func EditorPageContent(data EditorPageData) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var3 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var3 == nil {
+			templ_7745c5c3_Var3 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"flex-1 flex flex-col\" data-experiment=\"editor\"><div class=\"text-center py-4 border-b border-secondary-700\"><h2 class=\"text-xl sm:text-2xl font-bold text-secondary-50\">Shared Text Editor</h2><p class=\"text-sm text-secondary-400\">Concurrent Edits Merged with Operational Transform</p></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = layout.ExperimentBanner(data.Banner).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div class=\"flex-1 flex flex-col max-w-5xl w-full mx-auto p-4 gap-2\"><div class=\"flex justify-between text-xs text-secondary-400\"><span>Everyone on this page is editing the same document.</span> <span id=\"editor-status\" class=\"font-mono\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var4 string
+		templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("Revision %d", data.Snapshot.Revision))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/editor_content.templ`, Line: 42, Col: 99}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</span></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = EditorTextarea(data.Snapshot).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div id=\"editor-ops\" class=\"hidden\" sse-swap=\"editor-op\" hx-swap=\"none\"></div></div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = EditorScript(data.OriginatorID).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// EditorTextarea is the server-rendered snapshot late joiners start from.
+func EditorTextarea(snapshot EditorSnapshot) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var5 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var5 == nil {
+			templ_7745c5c3_Var5 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<textarea id=\"editor-text\" data-revision=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var6 string
+		templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", snapshot.Revision))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/editor_content.templ`, Line: 55, Col: 54}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" spellcheck=\"false\" aria-label=\"Shared document\" class=\"flex-1 min-h-[60vh] w-full bg-secondary-900/60 border border-secondary-700 rounded-xl p-4 font-mono text-sm text-secondary-100 focus:outline-none focus:border-primary-600/60 resize-none\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var7 string
+		templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(snapshot.Text)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/editor_content.templ`, Line: 59, Col: 17}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</textarea>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func EditorOp(op EditorOperation) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var8 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var8 == nil {
+			templ_7745c5c3_Var8 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<div class=\"editor-op\" data-rev=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var9 string
+		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", op.Revision))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/editor_content.templ`, Line: 63, Col: 65}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\" data-ops=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var10 string
+		templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(op.Ops)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/editor_content.templ`, Line: 63, Col: 85}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "\"></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func EditorAck(revision int) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var11 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var11 == nil {
+			templ_7745c5c3_Var11 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<div class=\"editor-ack\" data-rev=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var12 string
+		templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", revision))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/experiments/editor_content.templ`, Line: 67, Col: 63}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "\"></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// EditorScript keeps one request in flight at a time. Edits made while it
+// is pending are buffered, and remote ops are transformed against both
+// before being applied, mirroring the server's transform in ot.go.
+func EditorScript(originatorID string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var13 == nil {
+			templ_7745c5c3_Var13 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templ.JSONScript("editorOriginatorId", originatorID).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<script type=\"text/javascript\">\n\t\t(function () {\n\t\t\tvar originatorId = window.originatorId || JSON.parse(document.getElementById('editorOriginatorId').textContent);\n\t\t\tvar opsEl = document.getElementById('editor-ops');\n\t\t\tvar statusEl = document.getElementById('editor-status');\n\t\t\tvar textarea, state;\n\n\t\t\tfunction ins(p, t) { return { k: 'i', p: p, t: t }; }\n\t\t\tfunction del(p, n) { return { k: 'd', p: p, n: n }; }\n\t\t\tfunction size(o) { return o.k === 'i' ? o.t.length : o.n; }\n\n\t\t\tfunction transformInsertDelete(i, d) {\n\t\t\t\tif (i.p <= d.p) return [[i], [del(d.p + size(i), d.n)]];\n\t\t\t\tif (i.p >= d.p + d.n) return [[ins(i.p - d.n, i.t)], [d]];\n\t\t\t\tvar before = i.p - d.p;\n\t\t\t\treturn [[ins(d.p, i.t)], [del(d.p, before), del(d.p + size(i), d.n - before)]];\n\t\t\t}\n\n\t\t\t// b wins ties, as on the server\n\t\t\tfunction transform(a, b) {\n\t\t\t\tif (a.k === 'i' && b.k === 'i') {\n\t\t\t\t\tif (a.p < b.p) return [[a], [ins(b.p + size(a), b.t)]];\n\t\t\t\t\treturn [[ins(a.p + size(b), a.t)], [b]];\n\t\t\t\t}\n\t\t\t\tif (a.k === 'i') return transformInsertDelete(a, b);\n\t\t\t\tif (b.k === 'i') { var r = transformInsertDelete(b, a); return [r[1], r[0]]; }\n\t\t\t\tvar overlap = Math.max(0, Math.min(a.p + a.n, b.p + b.n) - Math.max(a.p, b.p));\n\t\t\t\tvar at = a.n - overlap > 0 ? [del(a.p - Math.min(b.n, Math.max(0, a.p - b.p)), a.n - overlap)] : [];\n\t\t\t\tvar bt = b.n - overlap > 0 ? [del(b.p - Math.min(a.n, Math.max(0, b.p - a.p)), b.n - overlap)] : [];\n\t\t\t\treturn [at, bt];\n\t\t\t}\n\n\t\t\tfunction transformList(a, b) {\n\t\t\t\tif (!a.length || !b.length) return [a, b];\n\t\t\t\tif (a.length > 1) {\n\t\t\t\t\tvar first = transformList(a.slice(0, 1), b);\n\t\t\t\t\tvar rest = transformList(a.slice(1), first[1]);\n\t\t\t\t\treturn [first[0].concat(rest[0]), rest[1]];\n\t\t\t\t}\n\t\t\t\tif (b.length > 1) {\n\t\t\t\t\tvar head = transformList(a, b.slice(0, 1));\n\t\t\t\t\tvar tail = transformList(head[0], b.slice(1));\n\t\t\t\t\treturn [tail[0], head[1].concat(tail[1])];\n\t\t\t\t}\n\t\t\t\treturn transform(a[0], b[0]);\n\t\t\t}\n\n\t\t\tfunction shiftIndex(index, o) {\n\t\t\t\tif (o.k === 'i') return o.p < index ? index + o.t.length : index;\n\t\t\t\treturn index - Math.min(o.n, Math.max(0, index - o.p));\n\t\t\t}\n\n\t\t\tfunction parse(html) {\n\t\t\t\tvar template = document.createElement('template');\n\t\t\t\ttemplate.innerHTML = html;\n\t\t\t\treturn template.content.firstElementChild;\n\t\t\t}\n\n\t\t\tfunction setStatus(text) {\n\t\t\t\tstatusEl.textContent = text || ('Revision ' + state.rev);\n\t\t\t}\n\n\t\t\tfunction reset() {\n\t\t\t\tif (state) clearTimeout(state.gapTimer);\n\t\t\t\ttextarea = document.getElementById('editor-text');\n\t\t\t\tstate = { rev: +textarea.dataset.revision, shadow: textarea.value, outstanding: null, buffer: [], ack: null, early: {}, gapTimer: null };\n\t\t\t\ttextarea.addEventListener('input', onInput);\n\t\t\t\tsetStatus();\n\t\t\t}\n\n\t\t\t// resync starts over from a fresh snapshot, dropping unsent edits.\n\t\t\tfunction resync() {\n\t\t\t\tsetStatus('Reloading…');\n\t\t\t\thtmx.ajax('GET', '/experiments/editor/snapshot', { target: '#editor-text', swap: 'outerHTML' }).then(reset);\n\t\t\t}\n\n\t\t\tfunction onInput() {\n\t\t\t\tvar prev = state.shadow, next = textarea.value;\n\t\t\t\tvar start = 0;\n\t\t\t\twhile (start < prev.length && start < next.length && prev[start] === next[start]) start++;\n\t\t\t\tvar end = 0;\n\t\t\t\twhile (end < prev.length - start && end < next.length - start && prev[prev.length - 1 - end] === next[next.length - 1 - end]) end++;\n\t\t\t\tvar removed = prev.length - start - end;\n\t\t\t\tvar inserted = next.slice(start, next.length - end);\n\t\t\t\tif (removed > 0) state.buffer.push(del(start, removed));\n\t\t\t\tif (inserted) state.buffer.push(ins(start, inserted));\n\t\t\t\tstate.shadow = next;\n\t\t\t\tflush();\n\t\t\t}\n\n\t\t\tfunction flush() {\n\t\t\t\tif (state.outstanding || !state.buffer.length) return;\n\t\t\t\tvar current = state;\n\t\t\t\tcurrent.outstanding = current.buffer;\n\t\t\t\tcurrent.buffer = [];\n\t\t\t\tsetStatus('Saving…');\n\t\t\t\tfetch('/experiments/editor/ops', {\n\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\theaders: { 'Content-Type': 'application/x-www-form-urlencoded', 'X-Originator-ID': originatorId, 'HX-Request': 'true' },\n\t\t\t\t\tbody: new URLSearchParams({ rev: current.rev, ops: JSON.stringify(current.outstanding) })\n\t\t\t\t}).then(function(response) {\n\t\t\t\t\tif (current !== state) return;\n\t\t\t\t\tif (!response.ok) return resync();\n\t\t\t\t\t// Rejected edits come back as a notice for another element\n\t\t\t\t\tvar target = response.headers.get('HX-Retarget');\n\t\t\t\t\treturn response.text().then(function(html) {\n\t\t\t\t\t\tif (current !== state) return;\n\t\t\t\t\t\tif (target) {\n\t\t\t\t\t\t\tvar el = document.querySelector(target);\n\t\t\t\t\t\t\tif (el) el.innerHTML = html;\n\t\t\t\t\t\t\treturn resync();\n\t\t\t\t\t\t}\n\t\t\t\t\t\tstate.ack = +parse(html).dataset.rev;\n\t\t\t\t\t\tdrain();\n\t\t\t\t\t});\n\t\t\t\t}).catch(function() {\n\t\t\t\t\tif (current === state) resync();\n\t\t\t\t});\n\t\t\t}\n\n\t\t\tfunction applyRemote(ops) {\n\t\t\t\tvar start = textarea.selectionStart, end = textarea.selectionEnd, value = textarea.value;\n\t\t\t\tops.forEach(function(o) {\n\t\t\t\t\tif (o.k === 'i') value = value.slice(0, o.p) + o.t + value.slice(o.p);\n\t\t\t\t\telse value = value.slice(0, o.p) + value.slice(o.p + o.n);\n\t\t\t\t\tstart = shiftIndex(start, o);\n\t\t\t\t\tend = shiftIndex(end, o);\n\t\t\t\t});\n\t\t\t\ttextarea.value = value;\n\t\t\t\tstate.shadow = value;\n\t\t\t\tif (document.activeElement === textarea) textarea.setSelectionRange(start, end);\n\t\t\t}\n\n\t\t\t// drain applies remote ops and our own acknowledgement strictly in\n\t\t\t// revision order; SSE events can arrive out of order.\n\t\t\tfunction drain() {\n\t\t\t\tfor (;;) {\n\t\t\t\t\tvar next = state.rev + 1;\n\t\t\t\t\tif (state.ack === next) {\n\t\t\t\t\t\tstate.rev = next;\n\t\t\t\t\t\tstate.ack = null;\n\t\t\t\t\t\tstate.outstanding = null;\n\t\t\t\t\t\tflush();\n\t\t\t\t\t\tcontinue;\n\t\t\t\t\t}\n\t\t\t\t\tvar ops = state.early[next];\n\t\t\t\t\tif (!ops) break;\n\t\t\t\t\tdelete state.early[next];\n\t\t\t\t\tvar withOutstanding = transformList(state.outstanding || [], ops);\n\t\t\t\t\tif (state.outstanding) state.outstanding = withOutstanding[0];\n\t\t\t\t\tvar withBuffer = transformList(state.buffer, withOutstanding[1]);\n\t\t\t\t\tstate.buffer = withBuffer[0];\n\t\t\t\t\tapplyRemote(withBuffer[1]);\n\t\t\t\t\tstate.rev = next;\n\t\t\t\t}\n\t\t\t\tclearTimeout(state.gapTimer);\n\t\t\t\tif (Object.keys(state.early).length || (state.ack && state.ack > state.rev + 1)) {\n\t\t\t\t\tstate.gapTimer = setTimeout(resync, 3000);\n\t\t\t\t}\n\t\t\t\tif (!state.outstanding) setStatus();\n\t\t\t}\n\n\t\t\topsEl.addEventListener('htmx:sseBeforeMessage', function(evt) {\n\t\t\t\tevt.preventDefault();\n\t\t\t\tvar op = parse(evt.detail.data);\n\t\t\t\tvar rev = +op.dataset.rev;\n\t\t\t\tif (rev > state.rev) {\n\t\t\t\t\tstate.early[rev] = JSON.parse(op.dataset.ops) || [];\n\t\t\t\t\tdrain();\n\t\t\t\t}\n\t\t\t});\n\n\t\t\treset();\n\t\t})();\n\t</script>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
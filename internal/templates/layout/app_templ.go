@@ -8,6 +8,21 @@ package layout
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
+import "net/url"
+
+const (
+	SiteURL        = "https://hypermedia.utilitygods.com"
+	DefaultOGImage = SiteURL + "/static/img/og.png"
+)
+
+func eventsURL(originatorID, topic string) string {
+	query := url.Values{"originator": {originatorID}}
+	if topic != "" {
+		query.Set("topic", topic)
+	}
+	return "/events?" + query.Encode()
+}
+
 func Head(title string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -29,20 +44,75 @@ func Head(title string) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = HeadWithImage(title, DefaultOGImage).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func HeadWithImage(title string, ogImage string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var2 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var2 == nil {
+			templ_7745c5c3_Var2 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
 		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var2 string
-		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(title)
+		var templ_7745c5c3_Var3 string
+		templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(title)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 25, Col: 15}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</title><!-- SEO Meta Tags --><meta name=\"description\" content=\"Explore real-time hypermedia synchronization with HTMX and Server-Sent Events. Interactive experiments demonstrating reactive UI patterns, live updates, and modern web architecture without JavaScript frameworks.\"><meta name=\"keywords\" content=\"HTMX, hypermedia, SSE, server-sent events, real-time, reactive UI, Go, Golang, web development, interactive experiments, live updates, hx-swap, hypermedia-driven applications\"><meta name=\"author\" content=\"UtilityGods\"><meta name=\"robots\" content=\"index, follow\"><link rel=\"canonical\" href=\"https://hypermedia.utilitygods.com\"><!-- Open Graph / Facebook --><meta property=\"og:type\" content=\"website\"><meta property=\"og:url\" content=\"https://hypermedia.utilitygods.com\"><meta property=\"og:title\" content=\"Hypermedia Sync Experiments - Real-Time HTMX + SSE Demos\"><meta property=\"og:description\" content=\"Interactive demonstrations of hypermedia-driven real-time synchronization using HTMX and Server-Sent Events. Experience reactive UIs without complex JavaScript frameworks.\"><meta property=\"og:image\" content=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var4 string
+		templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(ogImage)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 6, Col: 15}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 39, Col: 44}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</title><!-- SEO Meta Tags --><meta name=\"description\" content=\"Explore real-time hypermedia synchronization with HTMX and Server-Sent Events. Interactive experiments demonstrating reactive UI patterns, live updates, and modern web architecture without JavaScript frameworks.\"><meta name=\"keywords\" content=\"HTMX, hypermedia, SSE, server-sent events, real-time, reactive UI, Go, Golang, web development, interactive experiments, live updates, hx-swap, hypermedia-driven applications\"><meta name=\"author\" content=\"UtilityGods\"><meta name=\"robots\" content=\"index, follow\"><link rel=\"canonical\" href=\"https://hypermedia.utilitygods.com\"><!-- Open Graph / Facebook --><meta property=\"og:type\" content=\"website\"><meta property=\"og:url\" content=\"https://hypermedia.utilitygods.com\"><meta property=\"og:title\" content=\"Hypermedia Sync Experiments - Real-Time HTMX + SSE Demos\"><meta property=\"og:description\" content=\"Interactive demonstrations of hypermedia-driven real-time synchronization using HTMX and Server-Sent Events. Experience reactive UIs without complex JavaScript frameworks.\"><meta property=\"og:image\" content=\"https://hypermedia.utilitygods.com/static/img/og.png\"><meta property=\"og:image:width\" content=\"1200\"><meta property=\"og:image:height\" content=\"630\"><meta property=\"og:site_name\" content=\"Hypermedia Sync Experiments\"><!-- Twitter --><meta property=\"twitter:card\" content=\"summary_large_image\"><meta property=\"twitter:url\" content=\"https://hypermedia.utilitygods.com\"><meta property=\"twitter:title\" content=\"Hypermedia Sync Experiments - Real-Time HTMX + SSE\"><meta property=\"twitter:description\" content=\"Interactive demonstrations of hypermedia-driven real-time synchronization using HTMX and Server-Sent Events. Experience reactive UIs without complex JavaScript frameworks.\"><meta property=\"twitter:image\" content=\"https://hypermedia.utilitygods.com/static/img/og.png\"><meta property=\"twitter:creator\" content=\"@UtilityGods\"><!-- Favicon --><link rel=\"icon\" type=\"image/svg+xml\" href=\"/static/img/favicon.svg\"><meta name=\"theme-color\" content=\"#f54a00\"><!-- JSON-LD Structured Data --><script type=\"application/ld+json\">\n\t{\n\t\t\"@context\": \"https://schema.org\",\n\t\t\"@type\": \"WebApplication\",\n\t\t\"name\": \"Hypermedia Sync Experiments\",\n\t\t\"url\": \"https://hypermedia.utilitygods.com\",\n\t\t\"description\": \"Interactive demonstrations of hypermedia-driven real-time synchronization using HTMX and Server-Sent Events\",\n\t\t\"applicationCategory\": \"DeveloperApplication\",\n\t\t\"operatingSystem\": \"All\",\n\t\t\"browserRequirements\": \"Requires JavaScript and HTML5 SSE support\",\n\t\t\"creator\": {\n\t\t\t\"@type\": \"Organization\",\n\t\t\t\"name\": \"UtilityGods\",\n\t\t\t\"url\": \"https://utilitygods.com\"\n\t\t},\n\t\t\"keywords\": [\"HTMX\", \"hypermedia\", \"SSE\", \"real-time\", \"reactive UI\", \"Go\", \"web development\"],\n\t\t\"programmingLanguage\": [\"Go\", \"JavaScript\", \"HTML\"],\n\t\t\"screenshot\": \"https://hypermedia.utilitygods.com/static/img/og.png\"\n\t}\n\t</script><!-- Preload and Scripts --><link rel=\"preconnect\" href=\"https://fonts.googleapis.com\"><link rel=\"preconnect\" href=\"https://fonts.gstatic.com\" crossorigin><link href=\"https://fonts.googleapis.com/css2?family=Inter:wght@400;600;700&display=swap\" rel=\"stylesheet\"><script src=\"/static/js/htmx.js\"></script><script src=\"/static/js/sse.js\"></script><script src=\"/static/js/hyperscript.js\"></script><link rel=\"stylesheet\" href=\"/static/dist/styles.css\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\"><meta property=\"og:image:width\" content=\"1200\"><meta property=\"og:image:height\" content=\"630\"><meta property=\"og:site_name\" content=\"Hypermedia Sync Experiments\"><!-- Twitter --><meta property=\"twitter:card\" content=\"summary_large_image\"><meta property=\"twitter:url\" content=\"https://hypermedia.utilitygods.com\"><meta property=\"twitter:title\" content=\"Hypermedia Sync Experiments - Real-Time HTMX + SSE\"><meta property=\"twitter:description\" content=\"Interactive demonstrations of hypermedia-driven real-time synchronization using HTMX and Server-Sent Events. Experience reactive UIs without complex JavaScript frameworks.\"><meta property=\"twitter:image\" content=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var5 string
+		templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(ogImage)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 49, Col: 49}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "\"><meta property=\"twitter:creator\" content=\"@UtilityGods\"><!-- Favicon --><link rel=\"icon\" type=\"image/svg+xml\" href=\"/static/img/favicon.svg\"><meta name=\"theme-color\" content=\"#f54a00\"><!-- JSON-LD Structured Data --><script type=\"application/ld+json\">\n\t{\n\t\t\"@context\": \"https://schema.org\",\n\t\t\"@type\": \"WebApplication\",\n\t\t\"name\": \"Hypermedia Sync Experiments\",\n\t\t\"url\": \"https://hypermedia.utilitygods.com\",\n\t\t\"description\": \"Interactive demonstrations of hypermedia-driven real-time synchronization using HTMX and Server-Sent Events\",\n\t\t\"applicationCategory\": \"DeveloperApplication\",\n\t\t\"operatingSystem\": \"All\",\n\t\t\"browserRequirements\": \"Requires JavaScript and HTML5 SSE support\",\n\t\t\"creator\": {\n\t\t\t\"@type\": \"Organization\",\n\t\t\t\"name\": \"UtilityGods\",\n\t\t\t\"url\": \"https://utilitygods.com\"\n\t\t},\n\t\t\"keywords\": [\"HTMX\", \"hypermedia\", \"SSE\", \"real-time\", \"reactive UI\", \"Go\", \"web development\"],\n\t\t\"programmingLanguage\": [\"Go\", \"JavaScript\", \"HTML\"],\n\t\t\"screenshot\": \"https://hypermedia.utilitygods.com/static/img/og.png\"\n\t}\n\t</script><!-- Preload and Scripts --><link rel=\"preconnect\" href=\"https://fonts.googleapis.com\"><link rel=\"preconnect\" href=\"https://fonts.gstatic.com\" crossorigin><link href=\"https://fonts.googleapis.com/css2?family=Inter:wght@400;600;700&display=swap\" rel=\"stylesheet\"><!-- Swap 429s so rate limit toasts are shown rather than dropped --><meta name=\"htmx-config\" content='{\"responseHandling\":[{\"code\":\"204\",\"swap\":false},{\"code\":\"429\",\"swap\":true},{\"code\":\"[23]..\",\"swap\":true},{\"code\":\"[45]..\",\"swap\":false,\"error\":true},{\"code\":\"...\",\"swap\":false}]}'><script src=\"/static/js/htmx.js\"></script><script src=\"/static/js/sse.js\"></script><script src=\"/static/js/hyperscript.js\"></script><link rel=\"stylesheet\" href=\"/static/dist/styles.css\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -50,6 +120,9 @@ func Head(title string) templ.Component {
 	})
 }
 
+// App connects to /events without a topic, so its pages only receive
+// untopiced events such as the online count. Experiment pages use
+// AppWithSSEImage with their topic instead.
 func App(title string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -66,12 +139,12 @@ func App(title string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var3 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var3 == nil {
-			templ_7745c5c3_Var3 = templ.NopComponent
+		templ_7745c5c3_Var6 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var6 == nil {
+			templ_7745c5c3_Var6 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<!doctype html><html lang=\"en\"><head>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<!doctype html><html lang=\"en\"><head>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -79,7 +152,7 @@ func App(title string) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</head><body class=\"min-h-screen flex flex-col\"><script>\n\t\t\t\t// Generate unique originator ID for this browser tab\n\t\t\t\twindow.originatorId = 'global-' + Date.now() + '-' + Math.floor(Math.random() * 1000000);\n\t\t\t\t\n\t\t\t\t// Global go-to-top button functionality\n\t\t\t\twindow.updateGoToTopVisibility = function() {\n\t\t\t\t\tvar goTopButton = document.getElementById('go-to-top-btn');\n\t\t\t\t\tif (goTopButton) {\n\t\t\t\t\t\tif (window.scrollY > 300) {\n\t\t\t\t\t\t\tgoTopButton.style.opacity = '1';\n\t\t\t\t\t\t\tgoTopButton.style.pointerEvents = 'auto';\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tgoTopButton.style.opacity = '0';\n\t\t\t\t\t\t\tgoTopButton.style.pointerEvents = 'none';\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t};\n\t\t\t\t\n\t\t\t\t// Global scroll listener for go-to-top button\n\t\t\t\twindow.addEventListener('scroll', function() {\n\t\t\t\t\twindow.updateGoToTopVisibility();\n\t\t\t\t});\n\t\t\t</script><div hx-ext=\"sse\" class=\"flex-1 flex flex-col\" hx-boost=\"true\" hx-target=\"#main-content\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</head><body class=\"min-h-screen flex flex-col\"><script>\n\t\t\t\t// Generate unique originator ID for this browser tab\n\t\t\t\twindow.originatorId = 'global-' + Date.now() + '-' + Math.floor(Math.random() * 1000000);\n\t\t\t</script><div hx-ext=\"sse\" sse-close=\"connection-error\" class=\"flex-1 flex flex-col\" hx-boost=\"true\" hx-target=\"#main-content\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -87,15 +160,23 @@ func App(title string) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<div id=\"main-content\" class=\"flex-1\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<div id=\"main-content\" class=\"flex-1\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templ_7745c5c3_Var6.Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templ_7745c5c3_Var3.Render(ctx, templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = Toasts().Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</div><script>\n\t\t\t\t\t// Establish SSE connection with the originator ID\n\t\t\t\t\tconst sseDiv = document.querySelector('[hx-ext=\"sse\"]');\n\t\t\t\t\tsseDiv.setAttribute('sse-connect', '/events?originator=' + window.originatorId);\n\t\t\t\t\t// Process the SSE connection\n\t\t\t\t\thtmx.process(sseDiv);\n\t\t\t\t</script></div><footer class=\"mt-auto border-t border-secondary-700 bg-secondary-900/50 backdrop-blur-sm\"><div class=\"max-w-6xl mx-auto px-8 py-6 text-center\"><p class=\"text-secondary-300 text-sm\">Built with HTMX + Golang • Made by  <a href=\"https://utilitygods.com\" target=\"_blank\" class=\"text-primary-600 hover:text-primary-500 font-medium transition-colors\">UtilityGods</a></p></div></footer></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<script>\n\t\t\t\t\t// Establish SSE connection with the originator ID\n\t\t\t\t\tconst sseDiv = document.querySelector('[hx-ext=\"sse\"]');\n\t\t\t\t\tsseDiv.setAttribute('sse-connect', '/events?originator=' + window.originatorId);\n\t\t\t\t\t// Process the SSE connection\n\t\t\t\t\thtmx.process(sseDiv);\n\t\t\t\t</script></div><footer class=\"mt-auto border-t border-secondary-700 bg-secondary-900/50 backdrop-blur-sm\"><div class=\"max-w-6xl mx-auto px-8 py-6 text-center\"><p class=\"text-secondary-300 text-sm\">Built with HTMX + Golang • Made by  <a href=\"https://utilitygods.com\" target=\"_blank\" class=\"text-primary-600 hover:text-primary-500 font-medium transition-colors\">UtilityGods</a></p></div></footer></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -119,33 +200,82 @@ func AppWithSSE(title string, onlineCount int, originatorID string) templ.Compon
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var4 == nil {
-			templ_7745c5c3_Var4 = templ.NopComponent
+		templ_7745c5c3_Var7 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var7 == nil {
+			templ_7745c5c3_Var7 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<!doctype html><html lang=\"en\"><head>")
+		templ_7745c5c3_Var8 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templ_7745c5c3_Var7.Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = AppWithSSEImage(title, DefaultOGImage, onlineCount, originatorID, "").Render(templ.WithChildren(ctx, templ_7745c5c3_Var8), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = Head(title).Render(ctx, templ_7745c5c3_Buffer)
+		return nil
+	})
+}
+
+// AppWithSSEImage connects to /events for the given originator. A non-empty
+// topic limits topiced broadcasts to that experiment's events.
+func AppWithSSEImage(title string, ogImage string, onlineCount int, originatorID string, topic string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var9 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var9 == nil {
+			templ_7745c5c3_Var9 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<!doctype html><html lang=\"en\"><head>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</head><body class=\"min-h-screen flex flex-col\"><div hx-ext=\"sse\" sse-connect=\"")
+		templ_7745c5c3_Err = HeadWithImage(title, ogImage).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var5 string
-		templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs("/events?originator=" + originatorID)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</head><body class=\"min-h-screen flex flex-col\"><div hx-ext=\"sse\" sse-connect=\"")
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 131, Col: 71}
+			return templ_7745c5c3_Err
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+		var templ_7745c5c3_Var10 string
+		templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(eventsURL(originatorID, topic))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 145, Col: 65}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\" class=\"flex-1 flex flex-col\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "\" sse-close=\"connection-error\" class=\"flex-1 flex flex-col\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -153,15 +283,23 @@ func AppWithSSE(title string, onlineCount int, originatorID string) templ.Compon
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<div class=\"flex-1\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<div class=\"flex-1\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templ_7745c5c3_Var9.Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templ_7745c5c3_Var4.Render(ctx, templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "</div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div><footer class=\"mt-auto border-t border-secondary-700 bg-secondary-900/50 backdrop-blur-sm\"><div class=\"max-w-6xl mx-auto px-8 py-6 text-center\"><p class=\"text-secondary-300 text-sm\">Built with HTMX + Golang • Made by  <a href=\"https://utilitygods.com\" target=\"_blank\" class=\"text-primary-600 hover:text-primary-500 font-medium transition-colors\">UtilityGods</a></p></div></footer></body></html>")
+		templ_7745c5c3_Err = Toasts().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</div><footer class=\"mt-auto border-t border-secondary-700 bg-secondary-900/50 backdrop-blur-sm\"><div class=\"max-w-6xl mx-auto px-8 py-6 text-center\"><p class=\"text-secondary-300 text-sm\">Built with HTMX + Golang • Made by  <a href=\"https://utilitygods.com\" target=\"_blank\" class=\"text-primary-600 hover:text-primary-500 font-medium transition-colors\">UtilityGods</a></p></div></footer></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -185,78 +323,78 @@ func Breadcrumb(items []BreadcrumbItem) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var6 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var6 == nil {
-			templ_7745c5c3_Var6 = templ.NopComponent
+		templ_7745c5c3_Var11 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var11 == nil {
+			templ_7745c5c3_Var11 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<nav class=\"flex items-center gap-2 text-sm bg-gray-800 rounded-lg px-4 py-3 border border-gray-600 mb-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<nav class=\"flex items-center gap-2 text-sm bg-gray-800 rounded-lg px-4 py-3 border border-gray-600 mb-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for i, item := range items {
 			if i > 0 {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<span class=\"text-gray-400 mx-2\">/</span>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "<span class=\"text-gray-400 mx-2\">/</span>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if item.URL != "" {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<a href=\"")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<a href=\"")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var7 templ.SafeURL
-				templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(item.URL))
+				var templ_7745c5c3_Var12 templ.SafeURL
+				templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(item.URL))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 156, Col: 37}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 171, Col: 37}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "\" class=\"text-orange-500 hover:text-orange-400 hover:underline transition-colors px-2 py-1 rounded\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "\" class=\"text-orange-500 hover:text-orange-400 hover:underline transition-colors px-2 py-1 rounded\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var8 string
-				templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(item.Label)
+				var templ_7745c5c3_Var13 string
+				templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(item.Label)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 156, Col: 150}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 171, Col: 150}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</a>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "</a>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			} else {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<span class=\"text-gray-200 font-medium px-2 py-1\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "<span class=\"text-gray-200 font-medium px-2 py-1\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var9 string
-				templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(item.Label)
+				var templ_7745c5c3_Var14 string
+				templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(item.Label)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 158, Col: 66}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/templates/layout/app.templ`, Line: 173, Col: 66}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</span>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "</span>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</nav>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "</nav>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
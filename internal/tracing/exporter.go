@@ -0,0 +1,251 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/metrics"
+)
+
+const (
+	queueSize     = 2048
+	maxBatch      = 256
+	flushInterval = 2 * time.Second
+)
+
+var (
+	spansExported = metrics.NewCounter("tracing_spans_exported_total", "Spans sent to the collector.")
+	spansDropped  = metrics.NewCounter("tracing_spans_dropped_total", "Spans dropped because the export queue was full or the collector failed.")
+)
+
+type Config struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, such as
+	// http://localhost:4318. Spans are posted to Endpoint + "/v1/traces".
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded. Traces continued
+	// from a traceparent header follow the caller's decision.
+	SampleRatio float64
+}
+
+// Setup starts exporting spans to cfg.Endpoint. The returned function
+// flushes queued spans and stops the exporter.
+func Setup(cfg Config) (shutdown func(context.Context) error) {
+	e := &exporter{
+		url:     strings.TrimRight(cfg.Endpoint, "/") + "/v1/traces",
+		service: cfg.ServiceName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan *Span, queueSize),
+		flush:   make(chan chan struct{}),
+		errors:  logging.NewSampler(time.Minute),
+	}
+	current.Store(&tracer{sampleRatio: cfg.SampleRatio, exporter: e})
+	go e.run()
+
+	return func(ctx context.Context) error {
+		current.Store(nil)
+		done := make(chan struct{})
+		select {
+		case e.flush <- done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// exporter batches ended spans and posts them as OTLP JSON.
+type exporter struct {
+	url     string
+	service string
+	client  *http.Client
+	queue   chan *Span
+	flush   chan chan struct{}
+	errors  *logging.Sampler
+}
+
+// enqueue never blocks; spans are dropped when the queue is full so a slow
+// collector can't hold up requests.
+func (e *exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		spansDropped.Inc()
+	}
+}
+
+func (e *exporter) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) < maxBatch {
+				continue
+			}
+		case <-ticker.C:
+		case done := <-e.flush:
+			for len(e.queue) > 0 {
+				batch = append(batch, <-e.queue)
+			}
+			e.export(batch)
+			close(done)
+			return
+		}
+		e.export(batch)
+		batch = nil
+	}
+}
+
+func (e *exporter) export(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(e.payload(batch))
+	if err == nil {
+		err = e.post(body)
+	}
+	if err != nil {
+		spansDropped.Add(float64(len(batch)))
+		e.errors.Log(context.Background(), slog.Default(), slog.LevelWarn, "export", "exporting spans", "spans", len(batch), "err", err)
+		return
+	}
+	spansExported.Add(float64(len(batch)))
+}
+
+func (e *exporter) post(body []byte) error {
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below are the OTLP/HTTP JSON encoding of
+// ExportTraceServiceRequest. IDs are hex and 64-bit integers are strings,
+// as the protobuf JSON mapping requires.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              Kind            `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func (e *exporter) payload(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           s.sc.TraceID.String(),
+			SpanID:            s.sc.SpanID.String(),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parent.IsValid() {
+			span.ParentSpanID = s.parent.String()
+		}
+		for _, attr := range s.attrs {
+			span.Attributes = append(span.Attributes, otlpAttribute{attr.key, valueOf(attr.value)})
+		}
+		if s.err != "" {
+			span.Status = &otlpStatus{Code: 2, Message: s.err}
+		}
+		s.mu.Unlock()
+		spans = append(spans, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{{"service.name", valueOf(e.service)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "hypermedia-sync"}, Spans: spans}},
+	}}}
+}
+
+func valueOf(v any) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case time.Duration:
+		f := float64(v.Microseconds()) / 1000
+		return otlpValue{DoubleValue: &f}
+	default:
+		s := fmt.Sprint(v)
+		return otlpValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"slices"
+
+	"hypermedia-sync/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware starts a server span for each request, continuing the trace
+// from an incoming traceparent header. Routes in skip, such as long-lived
+// SSE streams, are not traced. Sampled requests also get trace_id on their
+// request logger.
+func Middleware(skip ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if current.Load() == nil || slices.Contains(skip, c.Path()) {
+				return next(c)
+			}
+
+			req := c.Request()
+			var opts []Option
+			if parent, ok := ParseTraceparent(req.Header.Get("traceparent")); ok {
+				opts = append(opts, WithParent(parent))
+			}
+			route := c.Path()
+			opts = append(opts, WithKind(KindServer), WithAttributes(
+				"http.request.method", req.Method,
+				"http.route", route,
+				"url.path", req.URL.Path,
+			))
+			ctx, span := Start(req.Context(), req.Method+" "+route, opts...)
+			if span != nil {
+				logger := logging.FromContext(ctx).With("trace_id", span.Context().TraceID.String())
+				ctx = logging.WithLogger(ctx, logger)
+			}
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+				span.RecordError(err)
+			}
+			span.SetAttributes("http.response.status_code", c.Response().Status)
+			span.End()
+			return nil
+		}
+	}
+}
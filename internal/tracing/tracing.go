@@ -0,0 +1,246 @@
+// Package tracing records spans and exports them to an OpenTelemetry
+// collector over OTLP/HTTP. Trace context crosses process boundaries in W3C
+// traceparent headers and goroutine boundaries in SpanContext values.
+//
+// Until Setup is called every span is a no-op, so instrumented code costs
+// next to nothing when tracing is off.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+// SpanContext identifies a span so that work elsewhere can be recorded as
+// its child.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Traceparent formats sc as a W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' || value[:2] == "ff" {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(value[3:35])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(value[36:52])); err != nil {
+		return sc, false
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(value[53:55])); err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+type contextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx in which sc is the current
+// span, so spans started from it become its children.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// SpanContextFromContext returns the current span in ctx, if any.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(contextKey{}).(SpanContext)
+	return sc
+}
+
+// Kind matches the OTLP span kinds.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindProducer Kind = 4
+	KindConsumer Kind = 5
+)
+
+// Span is one timed operation. A nil *Span is valid and does nothing, which
+// is what Start returns when tracing is off or the trace isn't sampled.
+type Span struct {
+	tracer *tracer
+	sc     SpanContext
+	parent SpanID
+	name   string
+	kind   Kind
+	start  time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []attribute
+	err   string
+	ended bool
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+type startConfig struct {
+	kind   Kind
+	parent *SpanContext
+	attrs  []any
+}
+
+type Option func(*startConfig)
+
+func WithKind(kind Kind) Option {
+	return func(c *startConfig) { c.kind = kind }
+}
+
+// WithParent makes the span a child of parent instead of the span in ctx,
+// for context that arrived in a header or an event.
+func WithParent(parent SpanContext) Option {
+	return func(c *startConfig) { c.parent = &parent }
+}
+
+// WithAttributes sets attributes from alternating keys and values, as with
+// slog.
+func WithAttributes(kv ...any) Option {
+	return func(c *startConfig) { c.attrs = append(c.attrs, kv...) }
+}
+
+// Start begins a span as a child of the current span in ctx and returns a
+// context in which it is current.
+func Start(ctx context.Context, name string, opts ...Option) (context.Context, *Span) {
+	t := current.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	cfg := startConfig{kind: KindInternal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	parent := SpanContextFromContext(ctx)
+	if cfg.parent != nil {
+		parent = *cfg.parent
+	}
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID, sc.Sampled = parent.TraceID, parent.Sampled
+	} else {
+		sc.TraceID, sc.Sampled = newTraceID(), mathrand.Float64() < t.sampleRatio
+	}
+	ctx = ContextWithSpanContext(ctx, sc)
+	if !sc.Sampled {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, sc: sc, parent: parent.SpanID, name: name, kind: cfg.kind, start: time.Now()}
+	span.SetAttributes(cfg.attrs...)
+	return ctx, span
+}
+
+// Context returns the span's identity for propagation. It is zero for a
+// nil span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+func (s *Span) SetAttributes(kv ...any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.attrs = append(s.attrs, attribute{fmt.Sprint(kv[i]), kv[i+1]})
+	}
+}
+
+// RecordError marks the span as failed. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+	s.tracer.exporter.enqueue(s)
+}
+
+type component interface {
+	Render(ctx context.Context, w io.Writer) error
+}
+
+// Render renders c to w inside a span named after the component.
+func Render(ctx context.Context, name string, c component, w io.Writer) error {
+	ctx, span := Start(ctx, "render "+name)
+	defer span.End()
+	err := c.Render(ctx, w)
+	span.RecordError(err)
+	return err
+}
+
+type tracer struct {
+	sampleRatio float64
+	exporter    *exporter
+}
+
+var current atomic.Pointer[tracer]
+
+func newTraceID() (id TraceID) {
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	rand.Read(id[:])
+	return id
+}
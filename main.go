@@ -1,80 +1,251 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	"hypermedia-sync/internal/experiments/checkboxes"
+	"hypermedia-sync/internal/auth"
+	"hypermedia-sync/internal/config"
+	"hypermedia-sync/internal/experiment"
 	canvasdrawsync "hypermedia-sync/internal/experiments/canvas-draw-sync"
+	"hypermedia-sync/internal/experiments/chat"
+	"hypermedia-sync/internal/experiments/checkboxes"
+	"hypermedia-sync/internal/experiments/dashboard"
+	"hypermedia-sync/internal/experiments/editor"
+	"hypermedia-sync/internal/experiments/games"
+	"hypermedia-sync/internal/experiments/kanban"
+	"hypermedia-sync/internal/experiments/life"
+	"hypermedia-sync/internal/experiments/polls"
+	"hypermedia-sync/internal/experiments/quiz"
 	"hypermedia-sync/internal/handlers"
+	"hypermedia-sync/internal/logging"
+	"hypermedia-sync/internal/metrics"
+	"hypermedia-sync/internal/ratelimit"
+	"hypermedia-sync/internal/session"
 	"hypermedia-sync/internal/sse"
+	"hypermedia-sync/internal/tracing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"golang.org/x/time/rate"
 )
 
-func configureRateLimiter() echo.MiddlewareFunc {
-	config := middleware.RateLimiterConfig{
-		Skipper: middleware.DefaultSkipper,
-		Store: middleware.NewRateLimiterMemoryStoreWithConfig(
-			middleware.RateLimiterMemoryStoreConfig{
-				Rate:      rate.Limit(10),  // 10 requests per second (higher for real-time app)
-				Burst:     20,              // Allow bursts of up to 20 requests
-				ExpiresIn: 1 * time.Minute, // Reset counters after 1 minute of inactivity
-			},
-		),
-		IdentifierExtractor: func(ctx echo.Context) (string, error) {
-			id := ctx.RealIP()
-			return id, nil
-		},
-		ErrorHandler: func(context echo.Context, err error) error {
-			fmt.Printf("Rate limiter error: %v\n", err)
-			return context.JSON(http.StatusInternalServerError, map[string]string{"error": "Internal Server Error"})
-		},
-		DenyHandler: func(context echo.Context, identifier string, err error) error {
-			return context.JSON(http.StatusTooManyRequests, map[string]string{
-				"error": "Rate limit exceeded. Please slow down and try again.",
-			})
-		},
-	}
-	return middleware.RateLimiterWithConfig(config)
+// rateLimits converts the configured limits into the limiter's policies.
+func rateLimits(cfg config.RateLimit) ratelimit.Config {
+	routes := make(map[string]ratelimit.Policy, len(cfg.Routes))
+	for route, limit := range cfg.Routes {
+		routes[route] = ratelimit.Policy{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	return ratelimit.Config{
+		Default: ratelimit.Policy{Rate: cfg.Rate, Burst: cfg.Burst},
+		Routes:  routes,
+		PerIP:   ratelimit.Policy{Rate: cfg.IPRate, Burst: cfg.IPBurst},
+		Expires: cfg.Expires,
+	}
+}
+
+// ipExtractor returns how to find the client IP. Without trusted proxies it
+// is the remote address, so a client can't pick its own IP by sending
+// X-Forwarded-For.
+func ipExtractor(proxies []string) echo.IPExtractor {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range proxies {
+		_, ipRange, _ := net.ParseCIDR(cidr)
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
 }
 
 func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logging.New(os.Stderr, cfg.Log.Format, cfg.Log.SlogLevel()))
+	slog.Info("effective configuration", "config", cfg)
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing.Endpoint != "" {
+		shutdownTracing = tracing.Setup(tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize SSE hub
-	hub := sse.NewHub()
+	hub := sse.NewHub(cfg.Hub.Buffer, sse.WithLimits(sse.Limits{
+		Total:      sse.Limit{Max: cfg.Connections.Max, Policy: sse.LimitPolicy(cfg.Connections.Policy)},
+		PerIP:      sse.Limit{Max: cfg.Connections.MaxPerIP, Policy: sse.LimitPolicy(cfg.Connections.PerIPPolicy)},
+		PerSession: sse.Limit{Max: cfg.Connections.MaxPerSession, Policy: sse.LimitPolicy(cfg.Connections.PerSessionPolicy)},
+	}))
 	go hub.Run()
+	hub.RegisterMetrics()
+
+	// Register experiments; the listing and routes are built from this
+	registry := experiment.NewRegistry()
+	for _, exp := range []experiment.Experiment{
+		checkboxes.New(hub, checkboxes.Settings{Count: cfg.Checkboxes.Count}),
+		canvasdrawsync.New(hub, canvasdrawsync.Settings{
+			Width:             cfg.Canvas.Width,
+			Height:            cfg.Canvas.Height,
+			SimplifyTolerance: cfg.Canvas.SimplifyTolerance,
+			MaxElements:       cfg.Canvas.MaxElements,
+			CompactAfter:      cfg.Canvas.CompactAfter,
+			CompactInterval:   cfg.Canvas.CompactInterval,
+			ClearPolicy:       canvasdrawsync.ClearPolicy(cfg.Canvas.ClearPolicy),
+			VoteQuorum:        cfg.Canvas.VoteQuorum,
+			VoteWindow:        cfg.Canvas.VoteWindow,
+			RestoreWindow:     cfg.Canvas.RestoreWindow,
+		}),
+		chat.New(hub, chat.Settings{
+			MaxRooms:       cfg.Chat.MaxRooms,
+			MaxRoomHistory: cfg.Chat.MaxRoomHistory,
+		}),
+		polls.New(hub, polls.Settings{MaxPolls: cfg.Polls.MaxPolls}),
+		kanban.New(hub, kanban.Settings{MaxCards: cfg.Kanban.MaxCards}),
+		editor.New(hub, editor.Settings{MaxDocumentLength: cfg.Editor.MaxDocumentLength}),
+		games.New(hub, games.Settings{MaxRooms: cfg.Games.MaxRooms}),
+		life.New(hub, life.Settings{Speed: cfg.Life.Speed}),
+		dashboard.New(hub, dashboard.Settings{SampleInterval: cfg.Dashboard.SampleInterval}),
+		quiz.New(hub, quiz.Settings{
+			QuestionsDir: cfg.Quiz.QuestionsDir,
+			TimeLimit:    cfg.Quiz.TimeLimit,
+		}),
+	} {
+		if err := registry.Register(exp); err != nil {
+			slog.Error("registering experiment", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	for id, status := range cfg.Experiments.Status {
+		if err := registry.SetStatus(id, status); err != nil {
+			slog.Error("applying experiment status", "experiment", id, "err", err)
+			os.Exit(1)
+		}
+	}
+	for id, access := range cfg.Experiments.Access {
+		if err := registry.SetAccess(id, access); err != nil {
+			slog.Error("applying experiment access", "experiment", id, "err", err)
+			os.Exit(1)
+		}
+	}
+
+	secret := []byte(cfg.Server.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+		slog.Warn("SESSION_SECRET is not set; sessions will reset when the server restarts")
+	}
+
+	var provider auth.Provider
+	switch cfg.Auth.Provider {
+	case "oidc":
+		provider = auth.NewOIDC(auth.OIDCConfig{
+			Issuer:       cfg.Auth.Issuer,
+			ClientID:     cfg.Auth.ClientID,
+			ClientSecret: cfg.Auth.ClientSecret,
+			RedirectURL:  cfg.Auth.RedirectURL,
+		})
+	case "dev":
+		provider = auth.Dev{}
+		slog.Warn("AUTH_PROVIDER is dev; anyone can sign in as anyone, so use it only for local testing")
+	}
 
 	e := echo.New()
-	e.Use(middleware.Recover())
-	e.Use(configureRateLimiter())
+	e.HideBanner = true
+	e.HidePort = true
+	e.IPExtractor = ipExtractor(cfg.Server.TrustedProxies)
+	e.Use(logging.RequestID())
+	e.Use(logging.AccessLog())
+	e.Use(logging.Recover())
+	e.Use(metrics.Middleware("/events"))
+	e.Use(tracing.Middleware("/events", "/static*", "/metrics", "/livez", "/readyz", "/health"))
+	e.Use(session.Middleware(secret, "/static*", "/metrics", "/livez", "/readyz", "/health"))
+	e.Use(ratelimit.Middleware(rateLimits(cfg.RateLimit)))
 	e.Use(middleware.CORS())
 
 	// Serve static files
 	e.Static("/static", "static")
 
 	// Main routes
-	e.GET("/", handlers.ExperimentsListHandler)
-	e.GET("/health", handlers.HealthHandler)
-	e.GET("/events", handlers.SSEHandler(hub))
+	e.GET("/", handlers.ExperimentsListHandler(registry))
+	e.GET("/livez", handlers.LivezHandler(hub))
+	e.GET("/readyz", handlers.ReadyzHandler(hub, registry))
+	// Older probes still point here; it is the readiness check
+	e.GET("/health", handlers.ReadyzHandler(hub, registry))
+	e.GET("/metrics", metrics.Handler(metrics.Default))
+	e.GET("/events", handlers.SSEHandler(hub, registry))
+	e.GET("/session", handlers.SessionChipHandler)
+	e.GET("/session/edit", handlers.SessionFormHandler(provider != nil))
+	e.POST("/session", handlers.UpdateSessionHandler(secret, provider != nil))
+
+	// Sign-in routes are only enabled when a provider is configured
+	if provider != nil {
+		auth.RegisterRoutes(e, provider, auth.Config{
+			Secret:         secret,
+			SessionLength:  cfg.Auth.SessionLength,
+			AllowedDomains: cfg.Auth.AllowedDomains,
+		})
+	}
+
+	// Admin routes are only enabled when a token is configured
+	if token := cfg.Server.AdminToken; token != "" {
+		admin := e.Group("/admin", handlers.AdminAuth(token))
+		admin.POST("/experiments/:id/status", handlers.ExperimentStatusHandler(registry, hub))
+	}
 
 	// Experiment routes
-	e.GET("/experiments/checkboxes", checkboxes.CheckboxesHandler(hub))
-	e.POST("/experiments/checkboxes/toggle/:id", checkboxes.ToggleHandler(hub))
-	
-	e.GET("/experiments/canvas-draw-sync", canvasdrawsync.CanvasDrawSyncHandler(hub))
-	e.POST("/experiments/canvas-draw-sync/draw", canvasdrawsync.DrawHandler(hub))
-	e.POST("/experiments/canvas-draw-sync/clear", canvasdrawsync.ClearCanvasHandler(hub))
-
-	// Start server on port from environment or 8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	port = ":" + port
-	fmt.Printf("Server starting on %s\n", port)
-	e.Logger.Fatal(e.Start(port))
-}
\ No newline at end of file
+	registry.Mount(e, hub)
+	if err := registry.Start(ctx); err != nil {
+		slog.Error("starting experiments", "err", err)
+		os.Exit(1)
+	}
+
+	port := fmt.Sprintf(":%d", cfg.Server.Port)
+	slog.Info("server starting", "addr", port)
+	go func() {
+		if err := e.Start(port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server stopped", "err", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutting down server", "err", err)
+	}
+	if err := registry.Stop(shutdownCtx); err != nil {
+		slog.Error("stopping experiments", "err", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("flushing traces", "err", err)
+	}
+}